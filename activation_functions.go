@@ -1,6 +1,9 @@
 package cnns
 
-import "math"
+import (
+	"math"
+	"reflect"
+)
 
 // ActivationTanh is hyperbolic tangent
 /*
@@ -82,3 +85,39 @@ func ActivationGaussian(v float64) float64 {
 func ActivationGaussianDerivative(v float64) float64 {
 	return -2.0 * v * math.Exp(-1.0*v*v)
 }
+
+// activations - names of activation functions used for serialization of nets
+var activations = []struct {
+	name string
+	f    func(v float64) float64
+}{
+	{"tanh", ActivationTanh},
+	{"sigmoid", ActivationSygmoid},
+	{"arctan", ActivationArcTan},
+	{"softplus", ActivationSoftPlus},
+	{"gaussian", ActivationGaussian},
+}
+
+// activationName - returns name of activation function ("" if it is not one of activation functions of package)
+func activationName(f func(v float64) float64) string {
+	if f == nil {
+		return ""
+	}
+	ptr := reflect.ValueOf(f).Pointer()
+	for _, a := range activations {
+		if reflect.ValueOf(a.f).Pointer() == ptr {
+			return a.name
+		}
+	}
+	return ""
+}
+
+// activationByName - returns activation function by its name (nil if name is unknown)
+func activationByName(name string) func(v float64) float64 {
+	for _, a := range activations {
+		if a.name == name {
+			return a.f
+		}
+	}
+	return nil
+}
@@ -0,0 +1,42 @@
+package cnns
+
+import (
+	"github.com/LdDl/cnns/tensor"
+)
+
+// ArenaOf - preallocated buffers for forward and backward passes through the net.
+/*
+	Activations - output buffer for every layer (used by Predictor);
+	Difference - buffer for difference between net's output and target (used in Backpropagate).
+
+	Arena is allocated once from layers' sizes, so steady-state passes do not perform heap allocations.
+	Arena should not be shared between goroutines.
+*/
+type ArenaOf[T tensor.Float] struct {
+	Activations []*tensor.TensorOf[T]
+	Difference  *tensor.TensorOf[T]
+}
+
+// Arena - arena of float64 precision
+type Arena = ArenaOf[float64]
+
+// NewArena - constructor for Arena. Buffers are allocated based on layers' output sizes.
+func NewArena[T tensor.Float](layers []LayerOf[T]) *ArenaOf[T] {
+	arena := &ArenaOf[T]{
+		Activations: make([]*tensor.TensorOf[T], len(layers)),
+	}
+	for i := range layers {
+		size := layers[i].GetOutputSize()
+		arena.Activations[i] = tensor.NewTensorOf[T](size.X, size.Y, size.Z)
+	}
+	if len(layers) != 0 {
+		size := layers[len(layers)-1].GetOutputSize()
+		arena.Difference = tensor.NewTensorOf[T](size.X, size.Y, size.Z)
+	}
+	return arena
+}
+
+// Output - returns buffer for last layer output
+func (arena *ArenaOf[T]) Output() *tensor.TensorOf[T] {
+	return arena.Activations[len(arena.Activations)-1]
+}
@@ -0,0 +1,163 @@
+// Package augment provides data augmentation for image tensors (X - width, Y - height, Z - channels). Transforms are composed into Pipeline and applied on the fly by AugmentedDataset, or used for test-time augmentation (see TTA).
+package augment
+
+import (
+	"errors"
+	"fmt"
+	"math/rand"
+	"sync/atomic"
+
+	"github.com/LdDl/cnns"
+	"github.com/LdDl/cnns/tensor"
+)
+
+// Transform - random transformation of image tensor. Apply should not modify source tensor and should use only provided source of randomness, so result depends on its seed only
+type Transform interface {
+	Apply(t *tensor.Tensor, rnd *rand.Rand) *tensor.Tensor
+}
+
+// Pipeline - transforms applied one after another
+type Pipeline []Transform
+
+// Apply - see Transform
+func (p Pipeline) Apply(t *tensor.Tensor, rnd *rand.Rand) *tensor.Tensor {
+	for _, tr := range p {
+		t = tr.Apply(t, rnd)
+	}
+	return t
+}
+
+// RandomApply - applies Transform with probability P
+type RandomApply struct {
+	P         float64
+	Transform Transform
+}
+
+// Apply - see Transform
+func (ra *RandomApply) Apply(t *tensor.Tensor, rnd *rand.Rand) *tensor.Tensor {
+	if rnd.Float64() >= ra.P {
+		return t
+	}
+	return ra.Transform.Apply(t, rnd)
+}
+
+// OneOf - applies one of Transforms chosen uniformly
+type OneOf []Transform
+
+// Apply - see Transform
+func (oo OneOf) Apply(t *tensor.Tensor, rnd *rand.Rand) *tensor.Tensor {
+	if len(oo) == 0 {
+		return t
+	}
+	return oo[rnd.Intn(len(oo))].Apply(t, rnd)
+}
+
+// sampleSeed - seed for sample of epoch
+func sampleSeed(seed int64, epoch, index int) int64 {
+	// SplitMix64 finalizer for every component, so neighbouring samples and epochs get unrelated seeds
+	mix := func(h, v uint64) uint64 {
+		h ^= v + 0x9e3779b97f4a7c15 + (h << 6) + (h >> 2)
+		h ^= h >> 30
+		h *= 0xbf58476d1ce4e5b9
+		h ^= h >> 27
+		h *= 0x94d049bb133111eb
+		h ^= h >> 31
+		return h
+	}
+	h := mix(uint64(seed), uint64(epoch))
+	h = mix(h, uint64(index))
+	return int64(h)
+}
+
+// AugmentedDataset - applies Transform to inputs of another dataset on the fly. Targets are not changed.
+/*
+	Random state of i-th sample depends on Seed, epoch (see cnns.EpochSetter) and i only,
+	so augmentation is reproducible regardless of number of loading workers and it is the same after resuming of training.
+*/
+type AugmentedDataset struct {
+	Dataset   cnns.Dataset
+	Transform Transform
+	Seed      int64
+	epoch     int64
+}
+
+// NewAugmentedDataset - constructor for AugmentedDataset
+func NewAugmentedDataset(dataset cnns.Dataset, seed int64, transforms ...Transform) *AugmentedDataset {
+	return &AugmentedDataset{
+		Dataset:   dataset,
+		Transform: Pipeline(transforms),
+		Seed:      seed,
+	}
+}
+
+// Len - number of samples
+func (ds *AugmentedDataset) Len() int {
+	return ds.Dataset.Len()
+}
+
+// Get - returns augmented input and target of i-th sample
+func (ds *AugmentedDataset) Get(i int) (*tensor.Tensor, *tensor.Tensor, error) {
+	input, target, err := ds.Dataset.Get(i)
+	if err != nil {
+		return nil, nil, err
+	}
+	epoch := int(atomic.LoadInt64(&ds.epoch))
+	rnd := rand.New(rand.NewSource(sampleSeed(ds.Seed, epoch, i)))
+	return ds.Transform.Apply(input, rnd), target, nil
+}
+
+// SetEpoch - see cnns.EpochSetter
+func (ds *AugmentedDataset) SetEpoch(epoch int) {
+	atomic.StoreInt64(&ds.epoch, int64(epoch))
+	if es, ok := ds.Dataset.(cnns.EpochSetter); ok {
+		es.SetEpoch(epoch)
+	}
+}
+
+// Model - anything which is able to predict output for input, e.g. cnns.Predictor
+type Model interface {
+	Predict(input *tensor.Tensor) (*tensor.Tensor, error)
+}
+
+// TTA - test-time augmentation: prediction is averaged over original input and its augmented views
+/*
+	Transforms - every transform produces one view (use deterministic ones, e.g. HorizontalFlip{P: 1}, or random ones with fixed Seed);
+	Seed - seed of random state for view k is derived from Seed and k, so views of the same input are always the same.
+*/
+type TTA struct {
+	Transforms []Transform
+	Seed       int64
+}
+
+// Views - returns original input and its augmented views
+func (tta *TTA) Views(input *tensor.Tensor) []*tensor.Tensor {
+	ret := make([]*tensor.Tensor, 0, len(tta.Transforms)+1)
+	ret = append(ret, input)
+	for k, tr := range tta.Transforms {
+		rnd := rand.New(rand.NewSource(sampleSeed(tta.Seed, 0, k)))
+		ret = append(ret, tr.Apply(input, rnd))
+	}
+	return ret
+}
+
+// Predict - returns mean of model's outputs over views of input
+func (tta *TTA) Predict(model Model, input *tensor.Tensor) (*tensor.Tensor, error) {
+	var ret *tensor.Tensor
+	views := tta.Views(input)
+	for k, view := range views {
+		out, err := model.Predict(view)
+		if err != nil {
+			return nil, fmt.Errorf("View #%d: %w", k, err)
+		}
+		if ret == nil {
+			ret = tensor.NewTensor(out.Size.X, out.Size.Y, out.Size.Z)
+		}
+		if len(out.Data) != len(ret.Data) {
+			return nil, errors.New("outputs of views have different sizes")
+		}
+		for i := range out.Data {
+			ret.Data[i] += out.Data[i] / float64(len(views))
+		}
+	}
+	return ret, nil
+}
@@ -0,0 +1,165 @@
+package augment
+
+import (
+	"math/rand"
+	"testing"
+
+	"github.com/LdDl/cnns"
+	"github.com/LdDl/cnns/tensor"
+)
+
+var _ cnns.EpochSetter = (*AugmentedDataset)(nil)
+
+func imageTensor(seed int64) *tensor.Tensor {
+	rnd := rand.New(rand.NewSource(seed))
+	t := tensor.NewTensor(8, 6, 3)
+	for i := range t.Data {
+		t.Data[i] = rnd.Float64()
+	}
+	return t
+}
+
+func equalData(a, b []float64) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+func TestTransforms(t *testing.T) {
+	transforms := map[string]Transform{
+		"HorizontalFlip":    &HorizontalFlip{P: 1},
+		"VerticalFlip":      &VerticalFlip{P: 1},
+		"Rotation":          &Rotation{MaxDegrees: 30},
+		"RandomCrop":        &RandomCrop{Padding: 2},
+		"Translation":       &Translation{MaxX: 2, MaxY: 2},
+		"Scaling":           &Scaling{Min: 0.8, Max: 1.2},
+		"ElasticDistortion": &ElasticDistortion{Alpha: 2, Sigma: 1.5},
+		"ColorJitter":       &ColorJitter{Brightness: 0.2, Contrast: 0.2, Clip: true},
+		"GaussianNoise":     &GaussianNoise{Std: 0.1},
+		"Cutout":            &Cutout{Size: 3, Holes: 2},
+	}
+	for name, tr := range transforms {
+		src := imageTensor(1)
+		original := append([]float64{}, src.Data...)
+		out1 := tr.Apply(src, rand.New(rand.NewSource(42)))
+		out2 := tr.Apply(src, rand.New(rand.NewSource(42)))
+		if out1.Size.X != 8 || out1.Size.Y != 6 || out1.Size.Z != 3 {
+			t.Errorf("%s has changed size: %dx%dx%d", name, out1.Size.X, out1.Size.Y, out1.Size.Z)
+		}
+		if !equalData(out1.Data, out2.Data) {
+			t.Errorf("%s is not deterministic for the same seed", name)
+		}
+		if !equalData(src.Data, original) {
+			t.Errorf("%s has modified source tensor", name)
+		}
+		if equalData(out1.Data, original) {
+			t.Errorf("%s has not changed image", name)
+		}
+	}
+
+	// Disabled flips and default probability
+	src := imageTensor(1)
+	for _, tr := range []Transform{&HorizontalFlip{}, &VerticalFlip{}} {
+		rnd := rand.New(rand.NewSource(3))
+		for i := 0; i < 20; i++ {
+			if out := tr.Apply(src, rnd); !equalData(out.Data, src.Data) {
+				t.Errorf("Flip with zero probability has changed image")
+				break
+			}
+		}
+	}
+	if NewHorizontalFlip().P != 0.5 || NewVerticalFlip().P != 0.5 {
+		t.Errorf("Default probability of flips should be 0.5")
+	}
+
+	// Negative max shifts mean no shift
+	if out := (&Translation{MaxX: -2, MaxY: -1}).Apply(src, rand.New(rand.NewSource(1))); !equalData(out.Data, src.Data) {
+		t.Errorf("Translation with negative max shifts has changed image")
+	}
+
+	crop := (&RandomCrop{Width: 4, Height: 4}).Apply(imageTensor(1), rand.New(rand.NewSource(1)))
+	if crop.Size.X != 4 || crop.Size.Y != 4 {
+		t.Errorf("Crop size should be 4x4, but got %dx%d", crop.Size.X, crop.Size.Y)
+	}
+}
+
+func TestAugmentedDataset(t *testing.T) {
+	inputs := make([]*tensor.Tensor, 10)
+	targets := make([]*tensor.Tensor, 10)
+	for i := range inputs {
+		inputs[i] = imageTensor(int64(i))
+		targets[i] = tensor.NewTensor(1, 1, 1)
+	}
+	data, err := cnns.NewInMemoryDataset(inputs, targets)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	ds := NewAugmentedDataset(data, 7, &Rotation{MaxDegrees: 15}, &GaussianNoise{Std: 0.05})
+
+	// Samples do not depend on number of workers and order of loading
+	load := func(epoch, workers int) [][]float64 {
+		loader := cnns.NewDataLoader[float64](ds, 3, true, 1)
+		loader.Workers = workers
+		it := loader.Iterate(epoch, 0)
+		defer it.Close()
+		ret := make([][]float64, ds.Len())
+		for {
+			batch, ok := it.Next()
+			if !ok {
+				break
+			}
+			for i, idx := range batch.Indices {
+				ret[idx] = batch.Inputs[i].Data
+			}
+		}
+		if it.Err() != nil {
+			t.Error(it.Err())
+		}
+		return ret
+	}
+	epoch0 := load(0, 1)
+	epoch0Parallel := load(0, 4)
+	epoch1 := load(1, 1)
+	for i := range epoch0 {
+		if !equalData(epoch0[i], epoch0Parallel[i]) {
+			t.Errorf("Sample #%d depends on number of workers", i)
+		}
+		if equalData(epoch0[i], epoch1[i]) {
+			t.Errorf("Sample #%d is the same in different epochs", i)
+		}
+	}
+}
+
+// meanModel - returns mean of left and right halves of image
+type meanModel struct{}
+
+func (meanModel) Predict(input *tensor.Tensor) (*tensor.Tensor, error) {
+	ret := tensor.NewTensor(2, 1, 1)
+	for y := 0; y < input.Size.Y; y++ {
+		for x := 0; x < input.Size.X; x++ {
+			ret.Data[2*x/input.Size.X] += input.Get(x, y, 0)
+		}
+	}
+	return ret, nil
+}
+
+func TestTTA(t *testing.T) {
+	input := tensor.NewTensor(4, 1, 1)
+	input.SetData(4, 1, 1, []float64{1, 1, 0, 0})
+	tta := &TTA{Transforms: []Transform{&HorizontalFlip{P: 1}}}
+	out, err := tta.Predict(meanModel{}, input)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	if out.Data[0] != 1.0 || out.Data[1] != 1.0 {
+		t.Errorf("Outputs should be averaged over original and flipped views. Expected value: [1 1]. Got: %v", out.Data)
+	}
+}
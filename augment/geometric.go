@@ -0,0 +1,199 @@
+package augment
+
+import (
+	"math"
+	"math/rand"
+
+	"github.com/LdDl/cnns/tensor"
+)
+
+// uniform - random value in [min, max]
+func uniform(rnd *rand.Rand, min, max float64) float64 {
+	return min + (max-min)*rnd.Float64()
+}
+
+// HorizontalFlip - mirrors image horizontally with probability P (never if P is zero, see NewHorizontalFlip)
+type HorizontalFlip struct {
+	P float64
+}
+
+// NewHorizontalFlip - constructor for HorizontalFlip with probability 0.5
+func NewHorizontalFlip() *HorizontalFlip {
+	return &HorizontalFlip{P: 0.5}
+}
+
+// Apply - see Transform
+func (hf *HorizontalFlip) Apply(t *tensor.Tensor, rnd *rand.Rand) *tensor.Tensor {
+	if rnd.Float64() >= hf.P {
+		return t
+	}
+	return t.FlipX()
+}
+
+// VerticalFlip - mirrors image vertically with probability P (never if P is zero, see NewVerticalFlip)
+type VerticalFlip struct {
+	P float64
+}
+
+// NewVerticalFlip - constructor for VerticalFlip with probability 0.5
+func NewVerticalFlip() *VerticalFlip {
+	return &VerticalFlip{P: 0.5}
+}
+
+// Apply - see Transform
+func (vf *VerticalFlip) Apply(t *tensor.Tensor, rnd *rand.Rand) *tensor.Tensor {
+	if rnd.Float64() >= vf.P {
+		return t
+	}
+	return t.FlipY()
+}
+
+// Rotation - rotates image around its center by random angle in [-MaxDegrees, MaxDegrees] (bilinear interpolation). Uncovered points have value Fill
+type Rotation struct {
+	MaxDegrees float64
+	Fill       float64
+}
+
+// Apply - see Transform
+func (r *Rotation) Apply(t *tensor.Tensor, rnd *rand.Rand) *tensor.Tensor {
+	return t.Rotate2D(uniform(rnd, -r.MaxDegrees, r.MaxDegrees), r.Fill)
+}
+
+// RandomCrop - pads image with Padding points of value Fill on every side and crops region Width x Height at random position (size of image if Width and Height are not set)
+type RandomCrop struct {
+	Width   int
+	Height  int
+	Padding int
+	Fill    float64
+}
+
+// Apply - see Transform
+func (rc *RandomCrop) Apply(t *tensor.Tensor, rnd *rand.Rand) *tensor.Tensor {
+	width, height := rc.Width, rc.Height
+	if width < 1 {
+		width = t.Size.X
+	}
+	if height < 1 {
+		height = t.Size.Y
+	}
+	x0 := -rc.Padding
+	if free := t.Size.X + 2*rc.Padding - width; free > 0 {
+		x0 += rnd.Intn(free + 1)
+	}
+	y0 := -rc.Padding
+	if free := t.Size.Y + 2*rc.Padding - height; free > 0 {
+		y0 += rnd.Intn(free + 1)
+	}
+	return t.Crop2D(x0, y0, width, height, rc.Fill)
+}
+
+// Translation - shifts image by random number of points in [-MaxX, MaxX] horizontally and in [-MaxY, MaxY] vertically (no shift along axis if its max is not positive). Uncovered points have value Fill
+type Translation struct {
+	MaxX int
+	MaxY int
+	Fill float64
+}
+
+// Apply - see Transform
+func (tr *Translation) Apply(t *tensor.Tensor, rnd *rand.Rand) *tensor.Tensor {
+	dx, dy := 0, 0
+	if tr.MaxX > 0 {
+		dx = rnd.Intn(2*tr.MaxX+1) - tr.MaxX
+	}
+	if tr.MaxY > 0 {
+		dy = rnd.Intn(2*tr.MaxY+1) - tr.MaxY
+	}
+	return t.Crop2D(-dx, -dy, t.Size.X, t.Size.Y, tr.Fill)
+}
+
+// Scaling - zooms image around its center by random factor in [Min, Max] keeping its size (bilinear interpolation). Uncovered points have value Fill
+type Scaling struct {
+	Min  float64
+	Max  float64
+	Fill float64
+}
+
+// Apply - see Transform
+func (s *Scaling) Apply(t *tensor.Tensor, rnd *rand.Rand) *tensor.Tensor {
+	factor := uniform(rnd, s.Min, s.Max)
+	if factor <= 0 {
+		return t
+	}
+	cx := float64(t.Size.X-1) / 2.0
+	cy := float64(t.Size.Y-1) / 2.0
+	return t.Warp2D(t.Size.X, t.Size.Y, func(x, y int) (float64, float64) {
+		return cx + (float64(x)-cx)/factor, cy + (float64(y)-cy)/factor
+	}, s.Fill)
+}
+
+// ElasticDistortion - moves every point by random displacement field smoothed with Gaussian filter. See ref. Simard et al., "Best Practices for Convolutional Neural Networks Applied to Visual Document Analysis"
+/*
+	Alpha - scale of displacements (in points);
+	Sigma - standard deviation of Gaussian filter (smoothness of field);
+	Fill - value of uncovered points.
+*/
+type ElasticDistortion struct {
+	Alpha float64
+	Sigma float64
+	Fill  float64
+}
+
+// Apply - see Transform
+func (ed *ElasticDistortion) Apply(t *tensor.Tensor, rnd *rand.Rand) *tensor.Tensor {
+	width, height := t.Size.X, t.Size.Y
+	dx := make([]float64, width*height)
+	dy := make([]float64, width*height)
+	for i := range dx {
+		dx[i] = uniform(rnd, -1, 1)
+		dy[i] = uniform(rnd, -1, 1)
+	}
+	dx = gaussianBlur(dx, width, height, ed.Sigma)
+	dy = gaussianBlur(dy, width, height, ed.Sigma)
+	return t.Warp2D(width, height, func(x, y int) (float64, float64) {
+		i := y*width + x
+		return float64(x) + ed.Alpha*dx[i], float64(y) + ed.Alpha*dy[i]
+	}, ed.Fill)
+}
+
+// gaussianBlur - separable Gaussian filter of 2-D field (zero outside of field)
+func gaussianBlur(field []float64, width, height int, sigma float64) []float64 {
+	if sigma <= 0 {
+		return field
+	}
+	radius := int(math.Ceil(3 * sigma))
+	kernel := make([]float64, 2*radius+1)
+	sum := 0.0
+	for i := range kernel {
+		d := float64(i - radius)
+		kernel[i] = math.Exp(-d * d / (2 * sigma * sigma))
+		sum += kernel[i]
+	}
+	for i := range kernel {
+		kernel[i] /= sum
+	}
+	tmp := make([]float64, len(field))
+	for y := 0; y < height; y++ {
+		for x := 0; x < width; x++ {
+			v := 0.0
+			for k := -radius; k <= radius; k++ {
+				if xk := x + k; xk >= 0 && xk < width {
+					v += kernel[k+radius] * field[y*width+xk]
+				}
+			}
+			tmp[y*width+x] = v
+		}
+	}
+	ret := make([]float64, len(field))
+	for y := 0; y < height; y++ {
+		for x := 0; x < width; x++ {
+			v := 0.0
+			for k := -radius; k <= radius; k++ {
+				if yk := y + k; yk >= 0 && yk < height {
+					v += kernel[k+radius] * tmp[yk*width+x]
+				}
+			}
+			ret[y*width+x] = v
+		}
+	}
+	return ret
+}
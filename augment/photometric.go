@@ -0,0 +1,97 @@
+package augment
+
+import (
+	"math"
+	"math/rand"
+
+	"github.com/LdDl/cnns/tensor"
+)
+
+// clip - limits values to [0, 1]
+func clip(t *tensor.Tensor) {
+	for i := range t.Data {
+		t.Data[i] = math.Min(math.Max(t.Data[i], 0), 1)
+	}
+}
+
+// ColorJitter - random brightness and contrast
+/*
+	Brightness - random value in [-Brightness, Brightness] is added to every point;
+	Contrast - deviation from mean value is multiplied by random factor in [1 - Contrast, 1 + Contrast];
+	Clip - limit values to [0, 1] afterwards.
+*/
+type ColorJitter struct {
+	Brightness float64
+	Contrast   float64
+	Clip       bool
+}
+
+// Apply - see Transform
+func (cj *ColorJitter) Apply(t *tensor.Tensor, rnd *rand.Rand) *tensor.Tensor {
+	delta := uniform(rnd, -cj.Brightness, cj.Brightness)
+	factor := uniform(rnd, 1-cj.Contrast, 1+cj.Contrast)
+	mean := 0.0
+	for _, v := range t.Data {
+		mean += v
+	}
+	mean /= float64(len(t.Data))
+	ret := tensor.NewTensor(t.Size.X, t.Size.Y, t.Size.Z)
+	for i, v := range t.Data {
+		ret.Data[i] = (v-mean)*factor + mean + delta
+	}
+	if cj.Clip {
+		clip(ret)
+	}
+	return ret
+}
+
+// GaussianNoise - adds noise with zero mean and standard deviation Std to every point. Clip - limit values to [0, 1] afterwards
+type GaussianNoise struct {
+	Std  float64
+	Clip bool
+}
+
+// Apply - see Transform
+func (gn *GaussianNoise) Apply(t *tensor.Tensor, rnd *rand.Rand) *tensor.Tensor {
+	ret := tensor.NewTensor(t.Size.X, t.Size.Y, t.Size.Z)
+	for i, v := range t.Data {
+		ret.Data[i] = v + gn.Std*rnd.NormFloat64()
+	}
+	if gn.Clip {
+		clip(ret)
+	}
+	return ret
+}
+
+// Cutout - fills Holes (1 if not set) squares Size x Size at random positions with value Fill in every channel. See ref. https://arxiv.org/abs/1708.04552
+type Cutout struct {
+	Size  int
+	Holes int
+	Fill  float64
+}
+
+// Apply - see Transform
+func (c *Cutout) Apply(t *tensor.Tensor, rnd *rand.Rand) *tensor.Tensor {
+	holes := c.Holes
+	if holes < 1 {
+		holes = 1
+	}
+	ret := tensor.NewTensor(t.Size.X, t.Size.Y, t.Size.Z)
+	copy(ret.Data, t.Data)
+	for h := 0; h < holes; h++ {
+		// Center of square is inside of image, square itself can be partially outside
+		cx := rnd.Intn(t.Size.X)
+		cy := rnd.Intn(t.Size.Y)
+		for y := cy - c.Size/2; y < cy-c.Size/2+c.Size; y++ {
+			for x := cx - c.Size/2; x < cx-c.Size/2+c.Size; x++ {
+				if x < 0 || y < 0 || x >= t.Size.X || y >= t.Size.Y {
+					continue
+				}
+				for z := 0; z < t.Size.Z; z++ {
+					ret.Set(x, y, z, c.Fill)
+				}
+			}
+		}
+	}
+	return ret
+}
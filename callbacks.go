@@ -0,0 +1,235 @@
+package cnns
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+	"log"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// TrainEvent - state of training process passed to callbacks
+/*
+	Epoch - index of current epoch (starting from 0);
+	Epochs - total number of epochs;
+	EpochsDone - number of epochs completed by current call of WholeNetOf.Train (current epoch is counted in OnEpochEnd already; epochs done before resuming from checkpoint are not counted);
+	Batch - index of current mini-batch in epoch (OnBatchEnd only);
+	Batches - number of mini-batches in epoch;
+	Step - number of weights' updates done so far;
+	Metrics - metrics of current batch (OnBatchEnd) or epoch (OnEpochEnd, OnTrainEnd): "loss", "val_loss" and etc.;
+	Elapsed - duration of current batch (OnBatchEnd), epoch (OnEpochEnd) or whole training (OnTrainEnd);
+	Params - parameters of optimizer used for training (see TrainConfig.Optimizer). Learning rate is the initial one: current one is reported as "lr" metric;
+	StopTraining - callback can set it to true to stop training after current epoch.
+*/
+type TrainEvent struct {
+	Epoch        int
+	Epochs       int
+	EpochsDone   int
+	Batch        int
+	Batches      int
+	Step         int
+	Metrics      map[string]float64
+	Elapsed      time.Duration
+	Params       LearningParams
+	StopTraining bool
+}
+
+// Callback - listener of training events. See WholeNetOf.Train
+type Callback interface {
+	// OnEpochStart - called before every epoch
+	OnEpochStart(event *TrainEvent)
+	// OnEpochEnd - called after every epoch (validation metrics are evaluated already)
+	OnEpochEnd(event *TrainEvent)
+	// OnBatchEnd - called after weights have been updated for mini-batch
+	OnBatchEnd(event *TrainEvent)
+	// OnTrainEnd - called once when training is done
+	OnTrainEnd(event *TrainEvent)
+}
+
+// BaseCallback - no-op implementation of Callback. Embed it to implement needed methods only
+type BaseCallback struct{}
+
+// OnEpochStart - does nothing
+func (BaseCallback) OnEpochStart(event *TrainEvent) {}
+
+// OnEpochEnd - does nothing
+func (BaseCallback) OnEpochEnd(event *TrainEvent) {}
+
+// OnBatchEnd - does nothing
+func (BaseCallback) OnBatchEnd(event *TrainEvent) {}
+
+// OnTrainEnd - does nothing
+func (BaseCallback) OnTrainEnd(event *TrainEvent) {}
+
+// ProgressLogger - callback which logs metrics of every epoch
+/*
+	Logger - destination (standard logger is used if nil);
+	EveryBatches - log metrics of every N-th mini-batch also (0 - do not log batches).
+*/
+type ProgressLogger struct {
+	BaseCallback
+	Logger       *log.Logger
+	EveryBatches int
+}
+
+// NewProgressLogger - constructor for ProgressLogger writing to w
+func NewProgressLogger(w io.Writer) *ProgressLogger {
+	return &ProgressLogger{
+		Logger: log.New(w, "", log.LstdFlags),
+	}
+}
+
+func (pl *ProgressLogger) printf(format string, v ...interface{}) {
+	if pl.Logger == nil {
+		log.Printf(format, v...)
+		return
+	}
+	pl.Logger.Printf(format, v...)
+}
+
+// OnBatchEnd - log batch metrics if EveryBatches is set
+func (pl *ProgressLogger) OnBatchEnd(event *TrainEvent) {
+	if pl.EveryBatches <= 0 || (event.Batch+1)%pl.EveryBatches != 0 {
+		return
+	}
+	pl.printf("Epoch #%v batch %v/%v: %s", event.Epoch, event.Batch+1, event.Batches, formatMetrics(event.Metrics))
+}
+
+// OnEpochEnd - log epoch metrics
+func (pl *ProgressLogger) OnEpochEnd(event *TrainEvent) {
+	pl.printf("Epoch #%v done in %v: %s", event.Epoch, event.Elapsed, formatMetrics(event.Metrics))
+}
+
+// OnTrainEnd - log total duration
+func (pl *ProgressLogger) OnTrainEnd(event *TrainEvent) {
+	pl.printf("Training %v epochs done in %v", event.EpochsDone, event.Elapsed)
+}
+
+// EpochLog - metrics of single epoch
+type EpochLog struct {
+	Epoch    int                `json:"Epoch"`
+	Duration time.Duration      `json:"Duration"`
+	Metrics  map[string]float64 `json:"Metrics"`
+}
+
+// History - callback which records metrics of every epoch
+/*
+	Epochs - metrics of epochs;
+	Params - parameters of optimizer used for training (nil until first epoch is done). See TrainEvent.Params.
+*/
+type History struct {
+	BaseCallback
+	Epochs []EpochLog      `json:"Epochs"`
+	Params *LearningParams `json:"Params,omitempty"`
+}
+
+// NewHistory - constructor for History
+func NewHistory() *History {
+	return &History{}
+}
+
+// OnEpochEnd - record epoch metrics
+func (h *History) OnEpochEnd(event *TrainEvent) {
+	metrics := make(map[string]float64, len(event.Metrics))
+	for k, v := range event.Metrics {
+		metrics[k] = v
+	}
+	h.Epochs = append(h.Epochs, EpochLog{
+		Epoch:    event.Epoch,
+		Duration: event.Elapsed,
+		Metrics:  metrics,
+	})
+	params := event.Params
+	h.Params = &params
+}
+
+// Metric - returns values of metric for every recorded epoch (NaN if metric is absent for epoch)
+func (h *History) Metric(name string) []float64 {
+	ret := make([]float64, len(h.Epochs))
+	for i := range h.Epochs {
+		v, ok := h.Epochs[i].Metrics[name]
+		if !ok {
+			ret[i] = math.NaN()
+			continue
+		}
+		ret[i] = v
+	}
+	return ret
+}
+
+// MetricNames - returns sorted names of all recorded metrics
+func (h *History) MetricNames() []string {
+	names := make(map[string]struct{})
+	for i := range h.Epochs {
+		for k := range h.Epochs[i].Metrics {
+			names[k] = struct{}{}
+		}
+	}
+	ret := make([]string, 0, len(names))
+	for k := range names {
+		ret = append(ret, k)
+	}
+	sort.Strings(ret)
+	return ret
+}
+
+// CSVLogger - callback which writes metrics of every epoch as CSV rows. Columns are "epoch", "duration" (seconds) and sorted names of metrics of first epoch
+type CSVLogger struct {
+	BaseCallback
+	writer  *csv.Writer
+	columns []string
+}
+
+// NewCSVLogger - constructor for CSVLogger writing to w
+func NewCSVLogger(w io.Writer) *CSVLogger {
+	return &CSVLogger{
+		writer: csv.NewWriter(w),
+	}
+}
+
+// OnEpochEnd - write row (and header for first epoch)
+func (cl *CSVLogger) OnEpochEnd(event *TrainEvent) {
+	if cl.columns == nil {
+		cl.columns = sortedKeys(event.Metrics)
+		cl.writer.Write(append([]string{"epoch", "duration"}, cl.columns...))
+	}
+	row := make([]string, 0, len(cl.columns)+2)
+	row = append(row, strconv.Itoa(event.Epoch), strconv.FormatFloat(event.Elapsed.Seconds(), 'f', -1, 64))
+	for _, c := range cl.columns {
+		v, ok := event.Metrics[c]
+		if !ok {
+			row = append(row, "")
+			continue
+		}
+		row = append(row, strconv.FormatFloat(v, 'g', -1, 64))
+	}
+	cl.writer.Write(row)
+	cl.writer.Flush()
+}
+
+// Error - returns error of underlying writer (if any)
+func (cl *CSVLogger) Error() error {
+	return cl.writer.Error()
+}
+
+func sortedKeys(metrics map[string]float64) []string {
+	keys := make([]string, 0, len(metrics))
+	for k := range metrics {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+func formatMetrics(metrics map[string]float64) string {
+	keys := sortedKeys(metrics)
+	parts := make([]string, len(keys))
+	for i, k := range keys {
+		parts[i] = fmt.Sprintf("%s=%.6f", k, metrics[k])
+	}
+	return strings.Join(parts, " ")
+}
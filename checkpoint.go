@@ -0,0 +1,348 @@
+package cnns
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+
+	"github.com/LdDl/cnns/tensor"
+)
+
+// CheckpointConfig - periodic saving of training state. See TrainConfigOf and WholeNetOf.Resume
+/*
+	Dir - directory for checkpoint files (created if it does not exist);
+	EveryEpochs - save checkpoint after every N epochs (0 - disabled);
+	EveryBatches - save checkpoint after every N mini-batches (0 - disabled);
+	KeepLast - keep only N most recent checkpoints in Dir (0 - keep all).
+*/
+type CheckpointConfig struct {
+	Dir          string
+	EveryEpochs  int
+	EveryBatches int
+	KeepLast     int
+}
+
+// CheckpointWeightsJSON - trainable tensor of layer with its momentum buffer and pruning mask
+type CheckpointWeightsJSON struct {
+	Layer   int       `json:"Layer"`
+	Weights []float64 `json:"Weights"`
+	Deltas  []float64 `json:"Deltas"`
+	Mask    []float64 `json:"Mask,omitempty"`
+}
+
+// Checkpoint - state of interrupted training process
+/*
+	Epoch - epoch in progress;
+	Batch - number of mini-batches of Epoch which are done already;
+	Step - number of weights' updates done so far;
+	Seed - seed of shuffling (order of samples depends on Seed and epoch only);
+	EpochLoss - sum of samples' losses of Epoch done so far;
+	LearningParams - learning parameters of optimizer (learning rate before scheduling);
+	LearningRate - current learning rate;
+	Precision - precision of net's weights;
+	Weights - weights, momentum buffers and masks of convolutional and fully connected layers;
+	History - metrics of finished epochs;
+	EarlyStopping - state of early stopping (if used);
+	Scheduler - state of learning rate scheduler (if used);
+	BestWeights - weights of best epoch (if early stopping restores them).
+*/
+type Checkpoint struct {
+	Epoch          int                     `json:"Epoch"`
+	Batch          int                     `json:"Batch"`
+	Step           int                     `json:"Step"`
+	Seed           int64                   `json:"Seed"`
+	EpochLoss      float64                 `json:"EpochLoss"`
+	LearningParams LearningParams          `json:"LearningParams"`
+	LearningRate   float64                 `json:"LearningRate"`
+	Precision      string                  `json:"Precision"`
+	Weights        []CheckpointWeightsJSON `json:"Weights"`
+	History        []EpochLog              `json:"History"`
+	EarlyStopping  *EarlyStopping          `json:"EarlyStopping,omitempty"`
+	Scheduler      json.RawMessage         `json:"Scheduler,omitempty"`
+	BestWeights    [][]float64             `json:"BestWeights,omitempty"`
+}
+
+// trainState - progress of training process. See Checkpoint
+type trainState[T tensor.Float] struct {
+	epoch        int
+	batch        int
+	step         int
+	epochLoss    float64
+	params       LearningParams
+	learningRate float64
+	history      []EpochLog
+	bestWeights  [][]T
+}
+
+// trainableTensor - weights of layer with momentum buffer and pruning mask (nil if layer has not been pruned)
+type trainableTensor[T tensor.Float] struct {
+	layer   int
+	weights *tensor.TensorOf[T]
+	deltas  *tensor.TensorOf[T]
+	mask    *tensor.TensorOf[T]
+}
+
+// trainable - returns trainable tensors of convolutional and fully connected layers in order of layers
+func (wh *WholeNetOf[T]) trainable() []trainableTensor[T] {
+	var ret []trainableTensor[T]
+	for l := range wh.Layers {
+		switch typed := wh.Layers[l].(type) {
+		case *ConvLayerOf[T]:
+			for k := range typed.Kernels {
+				tt := trainableTensor[T]{layer: l, weights: typed.Kernels[k], deltas: typed.PreviousKernelsDeltas[k]}
+				if typed.KernelsMasks != nil {
+					tt.mask = typed.KernelsMasks[k]
+				}
+				ret = append(ret, tt)
+			}
+		case *FullyConnectedLayerOf[T]:
+			ret = append(ret, trainableTensor[T]{layer: l, weights: typed.Weights, deltas: typed.PreviousIterationWeights, mask: typed.WeightsMask})
+		}
+	}
+	return ret
+}
+
+// makeCheckpoint - collects state of net and training process
+func (wh *WholeNetOf[T]) makeCheckpoint(state *trainState[T], cfg *TrainConfigOf[T]) (*Checkpoint, error) {
+	ckpt := &Checkpoint{
+		Epoch:          state.epoch,
+		Batch:          state.batch,
+		Step:           state.step,
+		Seed:           cfg.Seed,
+		EpochLoss:      state.epochLoss,
+		LearningParams: state.params,
+		LearningRate:   lp.LearningRate,
+		Precision:      tensor.Precision[T](),
+		History:        state.history,
+	}
+	for _, tt := range wh.trainable() {
+		cw := CheckpointWeightsJSON{
+			Layer:   tt.layer,
+			Weights: tensor.ConvertData[float64](tt.weights.Data),
+			Deltas:  tensor.ConvertData[float64](tt.deltas.Data),
+		}
+		if tt.mask != nil {
+			cw.Mask = tensor.ConvertData[float64](tt.mask.Data)
+		}
+		ckpt.Weights = append(ckpt.Weights, cw)
+	}
+	if cfg.EarlyStopping != nil {
+		es := *cfg.EarlyStopping
+		ckpt.EarlyStopping = &es
+	}
+	if cfg.Scheduler != nil {
+		schedulerState, err := json.Marshal(cfg.Scheduler)
+		if err != nil {
+			return nil, err
+		}
+		ckpt.Scheduler = schedulerState
+	}
+	for i := range state.bestWeights {
+		ckpt.BestWeights = append(ckpt.BestWeights, tensor.ConvertData[float64](state.bestWeights[i]))
+	}
+	return ckpt, nil
+}
+
+// restoreCheckpoint - restores weights, momentum buffers and masks of net and returns state of training process
+func (wh *WholeNetOf[T]) restoreCheckpoint(ckpt *Checkpoint) (*trainState[T], error) {
+	if ckpt.Precision != tensor.Precision[T]() {
+		return nil, fmt.Errorf("Checkpoint has precision '%s', but net has '%s'", ckpt.Precision, tensor.Precision[T]())
+	}
+	for _, cw := range ckpt.Weights {
+		if cw.Mask != nil {
+			// Allocate masks of all prunable layers
+			wh.prunable()
+			break
+		}
+	}
+	tts := wh.trainable()
+	if len(tts) != len(ckpt.Weights) {
+		return nil, fmt.Errorf("Checkpoint contains %d weight tensors, but net has %d", len(ckpt.Weights), len(tts))
+	}
+	for i, tt := range tts {
+		cw := ckpt.Weights[i]
+		if cw.Layer != tt.layer || len(cw.Weights) != len(tt.weights.Data) || len(cw.Deltas) != len(tt.deltas.Data) {
+			return nil, fmt.Errorf("Weights #%d of checkpoint do not fit layer #%d", i, tt.layer)
+		}
+		tensor.ConvertInto(tt.weights.Data, cw.Weights)
+		tensor.ConvertInto(tt.deltas.Data, cw.Deltas)
+		if tt.mask == nil {
+			continue
+		}
+		if cw.Mask == nil {
+			// Layer has not been pruned at the moment of saving
+			for j := range tt.mask.Data {
+				tt.mask.Data[j] = 1
+			}
+			continue
+		}
+		if len(cw.Mask) != len(tt.mask.Data) {
+			return nil, fmt.Errorf("Mask #%d of checkpoint does not fit layer #%d", i, tt.layer)
+		}
+		tensor.ConvertInto(tt.mask.Data, cw.Mask)
+	}
+	state := &trainState[T]{
+		epoch:        ckpt.Epoch,
+		batch:        ckpt.Batch,
+		step:         ckpt.Step,
+		epochLoss:    ckpt.EpochLoss,
+		learningRate: ckpt.LearningRate,
+		history:      ckpt.History,
+	}
+	for i := range ckpt.BestWeights {
+		state.bestWeights = append(state.bestWeights, tensor.ConvertData[T](ckpt.BestWeights[i]))
+	}
+	return state, nil
+}
+
+// checkpointFileName - name of checkpoint file. Names are sorted in order of saving.
+func checkpointFileName(epoch, step int) string {
+	return fmt.Sprintf("checkpoint-e%06d-s%09d.json", epoch, step)
+}
+
+// SaveCheckpoint - save checkpoint to JSON file
+func SaveCheckpoint(fname string, ckpt *Checkpoint) error {
+	fileContent, err := json.Marshal(ckpt)
+	if err != nil {
+		return err
+	}
+	// Write to temporary file first, so interruption does not leave broken checkpoint
+	tmp := fname + ".tmp"
+	err = ioutil.WriteFile(tmp, fileContent, 0644)
+	if err != nil {
+		return err
+	}
+	return os.Rename(tmp, fname)
+}
+
+// LoadCheckpoint - load checkpoint from JSON file
+func LoadCheckpoint(fname string) (*Checkpoint, error) {
+	fileContent, err := ioutil.ReadFile(fname)
+	if err != nil {
+		return nil, err
+	}
+	ckpt := &Checkpoint{}
+	err = json.Unmarshal(fileContent, ckpt)
+	if err != nil {
+		return nil, err
+	}
+	return ckpt, nil
+}
+
+// ListCheckpoints - returns checkpoint files in directory from oldest to newest
+func ListCheckpoints(dir string) ([]string, error) {
+	files, err := filepath.Glob(filepath.Join(dir, "checkpoint-e*-s*.json"))
+	if err != nil {
+		return nil, err
+	}
+	sort.Strings(files)
+	return files, nil
+}
+
+// LatestCheckpoint - returns most recent checkpoint file in directory
+func LatestCheckpoint(dir string) (string, error) {
+	files, err := ListCheckpoints(dir)
+	if err != nil {
+		return "", err
+	}
+	if len(files) == 0 {
+		return "", fmt.Errorf("No checkpoints in directory '%s'", dir)
+	}
+	return files[len(files)-1], nil
+}
+
+// saveCheckpoint - save state of training into cfg.Dir and remove old checkpoints according to cfg.KeepLast
+func (wh *WholeNetOf[T]) saveCheckpoint(state *trainState[T], cfg *TrainConfigOf[T]) error {
+	dir := cfg.Checkpoint.Dir
+	if dir == "" {
+		return errors.New("checkpoint directory is not set")
+	}
+	err := os.MkdirAll(dir, 0755)
+	if err != nil {
+		return err
+	}
+	ckpt, err := wh.makeCheckpoint(state, cfg)
+	if err != nil {
+		return err
+	}
+	err = SaveCheckpoint(filepath.Join(dir, checkpointFileName(state.epoch, state.step)), ckpt)
+	if err != nil {
+		return err
+	}
+	if cfg.Checkpoint.KeepLast <= 0 {
+		return nil
+	}
+	files, err := ListCheckpoints(dir)
+	if err != nil {
+		return err
+	}
+	for len(files) > cfg.Checkpoint.KeepLast {
+		err = os.Remove(files[0])
+		if err != nil {
+			return err
+		}
+		files = files[1:]
+	}
+	return nil
+}
+
+// Resume - continue training from checkpoint file. Net should have same architecture as one which has been trained.
+/*
+	fname - checkpoint file (see LatestCheckpoint)
+	inputs, desired, cfg - same as for Train. Training data, Seed, BatchSize and Shuffle should be the same as for interrupted training to get identical result.
+
+	Learning parameters of checkpoint are used if cfg.Optimizer is not set. States of cfg.EarlyStopping and cfg.Scheduler (should be pointer to scheduler of same type) are restored from checkpoint,
+	epochs of checkpoint's history are restored into History callbacks.
+*/
+func (wh *WholeNetOf[T]) Resume(fname string, inputs []*tensor.TensorOf[T], desired []*tensor.TensorOf[T], cfg TrainConfigOf[T]) (float64, float64, error) {
+	dataset, err := NewInMemoryDataset(inputs, desired)
+	if err != nil {
+		return 0.0, 0.0, err
+	}
+	return wh.ResumeOn(fname, dataset, cfg)
+}
+
+// ResumeOn - continue training on dataset from checkpoint file. See Resume and TrainOn
+func (wh *WholeNetOf[T]) ResumeOn(fname string, dataset DatasetOf[T], cfg TrainConfigOf[T]) (float64, float64, error) {
+	ckpt, err := LoadCheckpoint(fname)
+	if err != nil {
+		return 0.0, 0.0, err
+	}
+	if ckpt.Seed != cfg.Seed {
+		return 0.0, 0.0, fmt.Errorf("Checkpoint has been made with seed %d, but %d is provided", ckpt.Seed, cfg.Seed)
+	}
+	state, err := wh.restoreCheckpoint(ckpt)
+	if err != nil {
+		return 0.0, 0.0, err
+	}
+	if cfg.Optimizer == nil {
+		params := ckpt.LearningParams
+		cfg.Optimizer = &params
+	}
+	if cfg.EarlyStopping != nil && ckpt.EarlyStopping != nil {
+		cfg.EarlyStopping.Best = ckpt.EarlyStopping.Best
+		cfg.EarlyStopping.BestEpoch = ckpt.EarlyStopping.BestEpoch
+		cfg.EarlyStopping.Wait = ckpt.EarlyStopping.Wait
+		cfg.EarlyStopping.StoppedEpoch = ckpt.EarlyStopping.StoppedEpoch
+		if cfg.EarlyStopping.StoppedEpoch >= 0 {
+			// Training has been stopped already
+			cfg.Epochs = state.epoch
+		}
+	}
+	if cfg.Scheduler != nil && len(ckpt.Scheduler) != 0 {
+		err = json.Unmarshal(ckpt.Scheduler, cfg.Scheduler)
+		if err != nil {
+			return 0.0, 0.0, err
+		}
+	}
+	for _, cb := range cfg.Callbacks {
+		if h, ok := cb.(*History); ok {
+			h.Epochs = append([]EpochLog{}, ckpt.History...)
+		}
+	}
+	return wh.train(dataset, cfg, state)
+}
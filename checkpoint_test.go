@@ -0,0 +1,93 @@
+package cnns
+
+import (
+	"testing"
+)
+
+func TestResume(t *testing.T) {
+	inputs, desired := xorData()
+	newConfig := func(epochs int, history *History) TrainConfig {
+		return TrainConfig{
+			Epochs:            epochs,
+			BatchSize:         1,
+			Shuffle:           true,
+			Seed:              11,
+			ValidationInputs:  inputs,
+			ValidationTargets: desired,
+			EarlyStopping:     &EarlyStopping{Patience: 100, RestoreBest: true},
+			Scheduler:         &Warmup{Steps: 5, StartFactor: 0.1, After: &ReduceOnPlateau{Monitor: "loss", Factor: 0.5, Patience: 1}},
+			Callbacks:         []Callback{history},
+		}
+	}
+
+	// Uninterrupted training
+	straight := newXORNet(5)
+	straightHistory := NewHistory()
+	straightLoss, straightValLoss, err := straight.Train(inputs, desired, newConfig(6, straightHistory))
+	if err != nil {
+		t.Error(err)
+		return
+	}
+
+	// Training "killed" after 3 epochs. Checkpoints are made every 3 batches (4 batches per epoch)
+	dir := t.TempDir()
+	interrupted := newXORNet(5)
+	cfg := newConfig(3, NewHistory())
+	cfg.Checkpoint = &CheckpointConfig{Dir: dir, EveryBatches: 3, KeepLast: 2}
+	_, _, err = interrupted.Train(inputs, desired, cfg)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	files, err := ListCheckpoints(dir)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	if len(files) != 2 {
+		t.Errorf("Number of checkpoints should be %d, but got %d", 2, len(files))
+		return
+	}
+	ckpt, err := LoadCheckpoint(files[0])
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	if ckpt.Epoch != 2 || ckpt.Batch != 1 || ckpt.Step != 9 || len(ckpt.History) != 2 {
+		t.Errorf("Wrong checkpoint state: epoch %d, batch %d, step %d, history %d", ckpt.Epoch, ckpt.Batch, ckpt.Step, len(ckpt.History))
+	}
+
+	// Resume from middle of epoch in fresh process
+	resumed := newXORNet(100)
+	resumedHistory := NewHistory()
+	resumedLoss, resumedValLoss, err := resumed.Resume(files[0], inputs, desired, newConfig(6, resumedHistory))
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	if resumedLoss != straightLoss || resumedValLoss != straightValLoss {
+		t.Errorf("Losses should be equal. Expected values: %v, %v. Got: %v, %v", straightLoss, straightValLoss, resumedLoss, resumedValLoss)
+	}
+	if len(resumedHistory.Epochs) != len(straightHistory.Epochs) {
+		t.Errorf("History should contain %d epochs, but got %d", len(straightHistory.Epochs), len(resumedHistory.Epochs))
+		return
+	}
+	expectedLR := straightHistory.Metric("lr")
+	for i, lr := range resumedHistory.Metric("lr") {
+		if lr != expectedLR[i] {
+			t.Errorf("Epoch #%d: learning rates are not equal. Expected value: %v. Got: %v", i, expectedLR[i], lr)
+		}
+	}
+	for l := range straight.Layers {
+		expected := straight.Layers[l].(*FullyConnectedLayer)
+		got := resumed.Layers[l].(*FullyConnectedLayer)
+		for i := range expected.Weights.Data {
+			if expected.Weights.Data[i] != got.Weights.Data[i] {
+				t.Errorf("Layer #%d: weights are not equal at pos #%d. Expected value: %v. Got: %v", l, i, expected.Weights.Data[i], got.Weights.Data[i])
+			}
+			if expected.PreviousIterationWeights.Data[i] != got.PreviousIterationWeights.Data[i] {
+				t.Errorf("Layer #%d: momentum buffers are not equal at pos #%d. Expected value: %v. Got: %v", l, i, expected.PreviousIterationWeights.Data[i], got.PreviousIterationWeights.Data[i])
+			}
+		}
+	}
+}
@@ -8,39 +8,49 @@ import (
 	"github.com/LdDl/cnns/utils/u"
 )
 
-// ConvLayer is convolutional layer structure
-type ConvLayer struct {
-	DeltaWeightsComponent *tensor.Tensor
-	In                    *tensor.Tensor
-	Out                   *tensor.Tensor
-	Kernels               []*tensor.Tensor
-	PreviousKernelsDeltas []*tensor.Tensor
+// ConvLayerOf is convolutional layer structure (T is float32 or float64)
+type ConvLayerOf[T tensor.Float] struct {
+	DeltaWeightsComponent *tensor.TensorOf[T]
+	In                    *tensor.TensorOf[T]
+	Out                   *tensor.TensorOf[T]
+	Kernels               []*tensor.TensorOf[T]
+	PreviousKernelsDeltas []*tensor.TensorOf[T]
+	KernelsMasks          []*tensor.TensorOf[T]
+	KernelsGradients      []*tensor.TensorOf[T]
 	LocalDeltas           []*TensorGradient
 	Stride                int
 	KernelSize            int
 }
 
+// ConvLayer - convolutional layer of float64 precision
+type ConvLayer = ConvLayerOf[float64]
+
 // NewConvLayer - constructor for new convolutional layer. You need to specify striding step, size (square) of kernel, amount of kernels, input size.
 func NewConvLayer(stride, kernelSize, numberFilters int, inSize tensor.TDsize) Layer {
-	newLayer := &ConvLayer{
-		DeltaWeightsComponent: tensor.NewTensor(inSize.X, inSize.Y, inSize.Z),
-		In:                    tensor.NewTensor(inSize.X, inSize.Y, inSize.Z),
-		Out:                   tensor.NewTensor((inSize.X-kernelSize)/stride+1, (inSize.Y-kernelSize)/stride+1, numberFilters),
+	return NewConvLayerOf[float64](stride, kernelSize, numberFilters, inSize)
+}
+
+// NewConvLayerOf - constructor for new convolutional layer of given precision (float32 or float64). See NewConvLayer.
+func NewConvLayerOf[T tensor.Float](stride, kernelSize, numberFilters int, inSize tensor.TDsize) LayerOf[T] {
+	newLayer := &ConvLayerOf[T]{
+		DeltaWeightsComponent: tensor.NewTensorOf[T](inSize.X, inSize.Y, inSize.Z),
+		In:                    tensor.NewTensorOf[T](inSize.X, inSize.Y, inSize.Z),
+		Out:                   tensor.NewTensorOf[T]((inSize.X-kernelSize)/stride+1, (inSize.Y-kernelSize)/stride+1, numberFilters),
 		Stride:                stride,
 		KernelSize:            kernelSize,
 	}
 	for a := 0; a < numberFilters; a++ {
-		tmp := tensor.NewTensor(kernelSize, kernelSize, inSize.Z)
+		tmp := tensor.NewTensorOf[T](kernelSize, kernelSize, inSize.Z)
 		for i := 0; i < kernelSize; i++ {
 			for j := 0; j < kernelSize; j++ {
 				for z := 0; z < inSize.Z; z++ {
-					tmp.Set(i, j, z, rand.Float64()-0.5)
+					tmp.Set(i, j, z, T(rand.Float64()-0.5))
 				}
 			}
 		}
 		newLayer.Kernels = append(newLayer.Kernels, tmp)
 
-		tt := tensor.NewTensor(kernelSize, kernelSize, inSize.Z)
+		tt := tensor.NewTensorOf[T](kernelSize, kernelSize, inSize.Z)
 		for i := 0; i < kernelSize; i++ {
 			for j := 0; j < kernelSize; j++ {
 				for z := 0; z < inSize.Z; z++ {
@@ -59,7 +69,7 @@ func NewConvLayer(stride, kernelSize, numberFilters int, inSize tensor.TDsize) L
 }
 
 // SetCustomWeights - set user's weights (make it carefully)
-func (con *ConvLayer) SetCustomWeights(t []*tensor.Tensor) {
+func (con *ConvLayerOf[T]) SetCustomWeights(t []*tensor.TensorOf[T]) {
 	if len(con.Kernels) != len(t) {
 		fmt.Println("Amount of custom filters has to be equal to layer's amount of filters. Skipping...")
 		return
@@ -70,61 +80,66 @@ func (con *ConvLayer) SetCustomWeights(t []*tensor.Tensor) {
 }
 
 // GetOutputSize - returns output size (dimensions)
-func (con *ConvLayer) GetOutputSize() *tensor.TDsize {
+func (con *ConvLayerOf[T]) GetOutputSize() *tensor.TDsize {
 	return con.Out.Size
 }
 
 // GetInputSize - returns input size (dimensions)
-func (con *ConvLayer) GetInputSize() *tensor.TDsize {
+func (con *ConvLayerOf[T]) GetInputSize() *tensor.TDsize {
 	return con.In.Size
 }
 
 // GetOutput - returns convolutional layer's output
-func (con *ConvLayer) GetOutput() *tensor.Tensor {
+func (con *ConvLayerOf[T]) GetOutput() *tensor.TensorOf[T] {
 	return con.Out
 }
 
 // GetWeights - returns convolutional layer's weights
-func (con *ConvLayer) GetWeights() []*tensor.Tensor {
+func (con *ConvLayerOf[T]) GetWeights() []*tensor.TensorOf[T] {
 	return con.Kernels
 }
 
 // GetGradients - returns convolutional layer's gradients
-func (con *ConvLayer) GetGradients() *tensor.Tensor {
+func (con *ConvLayerOf[T]) GetGradients() *tensor.TensorOf[T] {
 	return con.DeltaWeightsComponent
 }
 
 // FeedForward - feed data to convolutional layer
-func (con *ConvLayer) FeedForward(t *tensor.Tensor) {
+func (con *ConvLayerOf[T]) FeedForward(t *tensor.TensorOf[T]) {
 	con.In = t
 	con.DoActivation()
 }
 
 // DoActivation - convolutional layer's output activation
-func (con *ConvLayer) DoActivation() {
+func (con *ConvLayerOf[T]) DoActivation() {
+	con.feedForwardInto(con.In, con.Out)
+}
+
+// feedForwardInto - convolve input with layer's kernels and store result in provided output. Layer's state is not touched.
+func (con *ConvLayerOf[T]) feedForwardInto(in, out *tensor.TensorOf[T]) {
 	for filter := 0; filter < len(con.Kernels); filter++ {
 		filterData := con.Kernels[filter]
-		for x := 0; x < con.Out.Size.X; x++ {
-			for y := 0; y < con.Out.Size.Y; y++ {
+		for x := 0; x < out.Size.X; x++ {
+			for y := 0; y < out.Size.Y; y++ {
 				mappedX, mappedY := x*con.Stride, y*con.Stride
-				sum := 0.0
+				var sum T
 				for i := 0; i < con.KernelSize; i++ {
 					for j := 0; j < con.KernelSize; j++ {
-						for z := 0; z < con.In.Size.Z; z++ {
+						for z := 0; z < in.Size.Z; z++ {
 							f := filterData.Get(i, j, z)
-							v := con.In.Get(mappedX+i, mappedY+j, z)
+							v := in.Get(mappedX+i, mappedY+j, z)
 							sum += f * v
 						}
 					}
 				}
-				con.Out.Set(x, y, filter, sum)
+				out.Set(x, y, filter, sum)
 			}
 		}
 	}
 }
 
 // CalculateGradients - calculate convolutional layer's gradients
-func (con *ConvLayer) CalculateGradients(nextLayerGrad *tensor.Tensor) {
+func (con *ConvLayerOf[T]) CalculateGradients(nextLayerGrad *tensor.TensorOf[T]) {
 	for k := 0; k < len(con.LocalDeltas); k++ {
 		for i := 0; i < con.KernelSize; i++ {
 			for j := 0; j < con.KernelSize; j++ {
@@ -138,7 +153,7 @@ func (con *ConvLayer) CalculateGradients(nextLayerGrad *tensor.Tensor) {
 		for y := 0; y < con.In.Size.Y; y++ {
 			rn := con.sameAsOuput(x, y)
 			for z := 0; z < con.In.Size.Z; z++ {
-				sumError := 0.0
+				var sumError T
 				for i := rn.MinX; i <= rn.MaxX; i++ {
 					minX := i * con.Stride
 					for j := rn.MinY; j <= rn.MaxY; j++ {
@@ -146,7 +161,7 @@ func (con *ConvLayer) CalculateGradients(nextLayerGrad *tensor.Tensor) {
 						for k := rn.MinZ; k <= rn.MaxZ; k++ {
 							weightApplied := con.Kernels[k].Get(x-minX, y-minY, z)
 							sumError += weightApplied * (*nextLayerGrad).Get(i, j, k)
-							con.LocalDeltas[k].AddToGrad(x-minX, y-minY, z, con.In.Get(x, y, z)*(*nextLayerGrad).Get(i, j, k))
+							con.LocalDeltas[k].AddToGrad(x-minX, y-minY, z, float64(con.In.Get(x, y, z)*(*nextLayerGrad).Get(i, j, k)))
 						}
 					}
 				}
@@ -157,34 +172,86 @@ func (con *ConvLayer) CalculateGradients(nextLayerGrad *tensor.Tensor) {
 }
 
 // UpdateWeights - update convolutional layer's weights
-func (con *ConvLayer) UpdateWeights() {
+func (con *ConvLayerOf[T]) UpdateWeights() {
 	for a := 0; a < len(con.Kernels); a++ {
 		for i := 0; i < con.KernelSize; i++ {
 			for j := 0; j < con.KernelSize; j++ {
 				for z := 0; z < con.In.Size.Z; z++ {
 					grad := con.LocalDeltas[a].Get(i, j, z)
 
-					prevDW := con.PreviousKernelsDeltas[a].Get(i, j, z)
+					prevDW := float64(con.PreviousKernelsDeltas[a].Get(i, j, z))
 					dw := (1.0-lp.Momentum)*(-1.0*(lp.LearningRate*grad.Grad*1.0)) + lp.Momentum*prevDW
 
-					con.PreviousKernelsDeltas[a].Set(i, j, z, dw)
-					con.Kernels[a].SetAdd(i, j, z, dw)
+					con.PreviousKernelsDeltas[a].Set(i, j, z, T(dw))
+					con.Kernels[a].SetAdd(i, j, z, T(dw))
 
 					con.LocalDeltas[a].Set(i, j, z, grad)
 				}
 			}
 		}
 	}
+	con.applyMasks()
+}
+
+// accumulateGradients - add gradients of kernels for current sample to the mini-batch sum
+func (con *ConvLayerOf[T]) accumulateGradients() {
+	if con.KernelsGradients == nil {
+		con.KernelsGradients = make([]*tensor.TensorOf[T], len(con.Kernels))
+		for a := range con.Kernels {
+			con.KernelsGradients[a] = tensor.NewTensorOf[T](con.Kernels[a].Size.X, con.Kernels[a].Size.Y, con.Kernels[a].Size.Z)
+		}
+	}
+	for a := 0; a < len(con.Kernels); a++ {
+		for i := 0; i < con.KernelSize; i++ {
+			for j := 0; j < con.KernelSize; j++ {
+				for z := 0; z < con.In.Size.Z; z++ {
+					con.KernelsGradients[a].SetAdd(i, j, z, T(con.LocalDeltas[a].Get(i, j, z).Grad))
+				}
+			}
+		}
+	}
+}
+
+// applyGradients - update kernels with mean gradients of mini-batch (same inertia rule as in UpdateWeights) and reset the sum
+func (con *ConvLayerOf[T]) applyGradients(batchSize int) {
+	if con.KernelsGradients == nil {
+		return
+	}
+	for a := range con.Kernels {
+		for i := range con.Kernels[a].Data {
+			grad := float64(con.KernelsGradients[a].Data[i]) / float64(batchSize)
+			dw := (1.0-lp.Momentum)*(-1.0*(lp.LearningRate*grad)) + lp.Momentum*float64(con.PreviousKernelsDeltas[a].Data[i])
+			con.PreviousKernelsDeltas[a].Data[i] = T(dw)
+			con.Kernels[a].Data[i] += T(dw)
+			con.KernelsGradients[a].Data[i] = 0
+		}
+	}
+	con.applyMasks()
+}
+
+// applyMasks - keep pruned weights (and their momentum) at zero. See WholeNetOf.Prune
+func (con *ConvLayerOf[T]) applyMasks() {
+	if con.KernelsMasks == nil {
+		return
+	}
+	for a := range con.Kernels {
+		for i, m := range con.KernelsMasks[a].Data {
+			if m == 0 {
+				con.Kernels[a].Data[i] = 0
+				con.PreviousKernelsDeltas[a].Data[i] = 0
+			}
+		}
+	}
 }
 
 // PrintOutput - print convolutional layer's output
-func (con *ConvLayer) PrintOutput() {
+func (con *ConvLayerOf[T]) PrintOutput() {
 	fmt.Println("Printing Convolutional Layer output...")
 	con.Out.Print()
 }
 
 // PrintWeights - print convolutional layer's weights
-func (con *ConvLayer) PrintWeights() {
+func (con *ConvLayerOf[T]) PrintWeights() {
 	fmt.Println("Printing Convolutional Layer kernels...")
 	for i := range con.Kernels {
 		fmt.Printf("Kernel #%v\n", i)
@@ -193,39 +260,39 @@ func (con *ConvLayer) PrintWeights() {
 }
 
 // PrintGradients - print convolutional layer's gradients
-func (con *ConvLayer) PrintGradients() {
+func (con *ConvLayerOf[T]) PrintGradients() {
 	fmt.Println("Printing Convolutional Layer gradients-weights...")
 	con.DeltaWeightsComponent.Print()
 }
 
 // SetActivationFunc - sets activation function for layer
-func (con *ConvLayer) SetActivationFunc(f func(v float64) float64) {
+func (con *ConvLayerOf[T]) SetActivationFunc(f func(v float64) float64) {
 	// Nothing here. Just for interface.
 	fmt.Println("You can not set activation function for convolutional layer")
 }
 
 // SetActivationDerivativeFunc sets derivative of activation function
-func (con *ConvLayer) SetActivationDerivativeFunc(f func(v float64) float64) {
+func (con *ConvLayerOf[T]) SetActivationDerivativeFunc(f func(v float64) float64) {
 	// Nothing here. Just for interface.
 	fmt.Println("You can not set derivative of activation function for convolutional layer")
 }
 
 // GetType - return "conv" as layer's type
-func (con *ConvLayer) GetType() string {
+func (con *ConvLayerOf[T]) GetType() string {
 	return "conv"
 }
 
 // GetStride - get stride of layer
-func (con *ConvLayer) GetStride() int {
+func (con *ConvLayerOf[T]) GetStride() int {
 	return con.Stride
 }
 
 // GetKernelSize - return kernel size
-func (con *ConvLayer) GetKernelSize() int {
+func (con *ConvLayerOf[T]) GetKernelSize() int {
 	return con.KernelSize
 }
 
-func (con *ConvLayer) mapToInput(i, j, k int) (x int, y int, z int) {
+func (con *ConvLayerOf[T]) mapToInput(i, j, k int) (x int, y int, z int) {
 	return i * con.Stride, j * con.Stride, k
 }
 
@@ -237,7 +304,7 @@ type Range struct {
 }
 
 // sameAsOuput - reshape convolutional layer's output
-func (con *ConvLayer) sameAsOuput(x, y int) Range {
+func (con *ConvLayerOf[T]) sameAsOuput(x, y int) Range {
 	a := float64(x)
 	b := float64(y)
 	return Range{
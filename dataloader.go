@@ -0,0 +1,187 @@
+package cnns
+
+import (
+	"fmt"
+	"sync"
+
+	"github.com/LdDl/cnns/tensor"
+)
+
+// DataLoaderOf - iterates over dataset by mini-batches. Samples of next batches are loaded on background goroutines while current batch is processed
+/*
+	BatchSize - number of samples in batch (1 if not set). Last batch of epoch can be smaller;
+	Shuffle - shuffle order of samples every epoch;
+	Seed - seed for shuffling. Order of samples for epoch e depends on Seed and e only;
+	Workers - number of goroutines loading batches (1 if not set);
+	Prefetch - number of batches loaded ahead (2 if not set).
+*/
+type DataLoaderOf[T tensor.Float] struct {
+	Dataset   DatasetOf[T]
+	BatchSize int
+	Shuffle   bool
+	Seed      int64
+	Workers   int
+	Prefetch  int
+}
+
+// DataLoader - data loader of float64 precision
+type DataLoader = DataLoaderOf[float64]
+
+// NewDataLoader - constructor for DataLoaderOf
+func NewDataLoader[T tensor.Float](dataset DatasetOf[T], batchSize int, shuffle bool, seed int64) *DataLoaderOf[T] {
+	return &DataLoaderOf[T]{
+		Dataset:   dataset,
+		BatchSize: batchSize,
+		Shuffle:   shuffle,
+		Seed:      seed,
+	}
+}
+
+// BatchOf - mini-batch of samples
+/*
+	Index - index of batch in epoch;
+	Indices - indices of samples in dataset;
+	Inputs, Targets - samples.
+*/
+type BatchOf[T tensor.Float] struct {
+	Index   int
+	Indices []int
+	Inputs  []*tensor.TensorOf[T]
+	Targets []*tensor.TensorOf[T]
+	err     error
+}
+
+// Batch - mini-batch of float64 precision
+type Batch = BatchOf[float64]
+
+// batchSize - returns effective batch size
+func (dl *DataLoaderOf[T]) batchSize() int {
+	if dl.BatchSize < 1 {
+		return 1
+	}
+	return dl.BatchSize
+}
+
+// NumBatches - returns number of batches in epoch
+func (dl *DataLoaderOf[T]) NumBatches() int {
+	bs := dl.batchSize()
+	return (dl.Dataset.Len() + bs - 1) / bs
+}
+
+// Iterate - starts loading of batches of given epoch beginning from fromBatch. Iterator should be closed after use. Epoch is passed to dataset if it is EpochSetter
+func (dl *DataLoaderOf[T]) Iterate(epoch, fromBatch int) *BatchIteratorOf[T] {
+	workers := dl.Workers
+	if workers < 1 {
+		workers = 1
+	}
+	prefetch := dl.Prefetch
+	if prefetch < 1 {
+		prefetch = 2
+	}
+	setEpoch(dl.Dataset, epoch)
+	order := make([]int, dl.Dataset.Len())
+	epochOrder(order, dl.Shuffle, dl.Seed, epoch)
+	bs := dl.batchSize()
+	numBatches := dl.NumBatches()
+
+	it := &BatchIteratorOf[T]{
+		ordered: make(chan chan *BatchOf[T], prefetch),
+		done:    make(chan struct{}),
+	}
+	type job struct {
+		index int
+		slot  chan *BatchOf[T]
+	}
+	jobs := make(chan job, prefetch)
+	for w := 0; w < workers; w++ {
+		go func() {
+			for j := range jobs {
+				from := j.index * bs
+				to := from + bs
+				if to > len(order) {
+					to = len(order)
+				}
+				j.slot <- dl.load(j.index, order[from:to])
+			}
+		}()
+	}
+	// Batches are dispatched in order, so consumer receives them in order regardless of which worker has loaded them
+	go func() {
+		defer close(jobs)
+		defer close(it.ordered)
+		for b := fromBatch; b < numBatches; b++ {
+			slot := make(chan *BatchOf[T], 1)
+			select {
+			case it.ordered <- slot:
+			case <-it.done:
+				return
+			}
+			select {
+			case jobs <- job{index: b, slot: slot}:
+			case <-it.done:
+				return
+			}
+		}
+	}()
+	return it
+}
+
+// load - reads samples of batch
+func (dl *DataLoaderOf[T]) load(index int, indices []int) *BatchOf[T] {
+	batch := &BatchOf[T]{
+		Index:   index,
+		Indices: indices,
+		Inputs:  make([]*tensor.TensorOf[T], len(indices)),
+		Targets: make([]*tensor.TensorOf[T], len(indices)),
+	}
+	for i, idx := range indices {
+		input, target, err := dl.Dataset.Get(idx)
+		if err != nil {
+			batch.err = fmt.Errorf("Batch #%d: %w", index, err)
+			return batch
+		}
+		batch.Inputs[i] = input
+		batch.Targets[i] = target
+	}
+	return batch
+}
+
+// BatchIteratorOf - iterator over batches of single epoch. See DataLoaderOf.Iterate. Iterator should be used from single goroutine
+type BatchIteratorOf[T tensor.Float] struct {
+	ordered   chan chan *BatchOf[T]
+	done      chan struct{}
+	closeOnce sync.Once
+	closed    bool
+	err       error
+}
+
+// Next - returns next batch or false if epoch is over or loading has failed (see Err)
+func (it *BatchIteratorOf[T]) Next() (*BatchOf[T], bool) {
+	if it.err != nil || it.closed {
+		return nil, false
+	}
+	slot, ok := <-it.ordered
+	if !ok {
+		return nil, false
+	}
+	batch := <-slot
+	if batch.err != nil {
+		it.err = batch.err
+		it.Close()
+		return nil, false
+	}
+	return batch, true
+}
+
+// Err - returns error of loading (if any)
+func (it *BatchIteratorOf[T]) Err() error {
+	return it.err
+}
+
+// Close - stops loading of batches
+func (it *BatchIteratorOf[T]) Close() {
+	it.closed = true
+	it.closeOnce.Do(func() {
+		close(it.done)
+	})
+}
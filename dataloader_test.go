@@ -0,0 +1,156 @@
+package cnns
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strconv"
+	"testing"
+
+	"github.com/LdDl/cnns/tensor"
+)
+
+func TestDataLoader(t *testing.T) {
+	inputs, targets := []*tensor.Tensor{}, []*tensor.Tensor{}
+	for i := 0; i < 10; i++ {
+		in := tensor.NewTensor(1, 1, 1)
+		in.Data[0] = float64(i)
+		inputs = append(inputs, in)
+		targets = append(targets, in)
+	}
+	first, err := NewInMemoryDataset(inputs[:4], targets[:4])
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	second, err := NewInMemoryDataset(inputs[4:], targets[4:])
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	dataset := NewConcatDataset[float64](first, second)
+	if dataset.Len() != 10 {
+		t.Errorf("Length of concatenated dataset should be %d, but got %d", 10, dataset.Len())
+	}
+
+	loader := &DataLoader{Dataset: dataset, BatchSize: 3, Shuffle: true, Seed: 5, Workers: 3, Prefetch: 2}
+	if loader.NumBatches() != 4 {
+		t.Errorf("Number of batches should be %d, but got %d", 4, loader.NumBatches())
+	}
+	collect := func(epoch int) []int {
+		var ret []int
+		it := loader.Iterate(epoch, 0)
+		defer it.Close()
+		expectedIndex := 0
+		for {
+			batch, ok := it.Next()
+			if !ok {
+				break
+			}
+			if batch.Index != expectedIndex {
+				t.Errorf("Batches should be received in order. Expected value: %d. Got: %d", expectedIndex, batch.Index)
+			}
+			expectedIndex++
+			for i, in := range batch.Inputs {
+				if int(in.Data[0]) != batch.Indices[i] {
+					t.Errorf("Sample does not fit its index. Expected value: %d. Got: %d", batch.Indices[i], int(in.Data[0]))
+				}
+				ret = append(ret, batch.Indices[i])
+			}
+		}
+		if it.Err() != nil {
+			t.Error(it.Err())
+		}
+		return ret
+	}
+	epoch0 := collect(0)
+	seen := make(map[int]bool)
+	for _, idx := range epoch0 {
+		seen[idx] = true
+	}
+	if len(epoch0) != 10 || len(seen) != 10 {
+		t.Errorf("Every sample should be visited once per epoch, but got %v", epoch0)
+	}
+	again := collect(0)
+	for i := range epoch0 {
+		if epoch0[i] != again[i] {
+			t.Errorf("Order of samples should depend on seed and epoch only. Expected: %v. Got: %v", epoch0, again)
+			break
+		}
+	}
+
+	subset, err := NewSubsetDataset[float64](dataset, []int{9, 1})
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	in, _, err := subset.Get(0)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	if in.Data[0] != 9 {
+		t.Errorf("Wrong sample of subset. Expected value: %f. Got: %f", 9.0, in.Data[0])
+	}
+}
+
+func TestLazyDataset(t *testing.T) {
+	dir := t.TempDir()
+	inputs, desired := xorData()
+	files := make([]string, len(inputs))
+	for i := range inputs {
+		files[i] = filepath.Join(dir, strconv.Itoa(i)+".txt")
+		content := strconv.FormatFloat(inputs[i].Data[0], 'f', -1, 64) + " " + strconv.FormatFloat(inputs[i].Data[1], 'f', -1, 64)
+		err := os.WriteFile(files[i], []byte(content), 0644)
+		if err != nil {
+			t.Error(err)
+			return
+		}
+	}
+	loader := func(fname string) (*tensor.Tensor, error) {
+		content, err := os.ReadFile(fname)
+		if err != nil {
+			return nil, err
+		}
+		ret := tensor.NewTensor(2, 1, 1)
+		for i, c := range []byte{content[0], content[2]} {
+			ret.Data[i] = float64(c - '0')
+		}
+		return ret, nil
+	}
+	dataset, err := NewLazyDataset(files, desired, loader)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+
+	// Training on lazily loaded dataset should be equal to training on in-memory one
+	lazyNet := newXORNet(13)
+	_, _, err = lazyNet.TrainOn(dataset, TrainConfig{Epochs: 5, BatchSize: 2, Shuffle: true, Seed: 3, Workers: 2})
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	net := newXORNet(13)
+	_, _, err = net.Train(inputs, desired, TrainConfig{Epochs: 5, BatchSize: 2, Shuffle: true, Seed: 3})
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	for l := range net.Layers {
+		expected := net.Layers[l].(*FullyConnectedLayer).Weights.Data
+		got := lazyNet.Layers[l].(*FullyConnectedLayer).Weights.Data
+		for i := range expected {
+			if expected[i] != got[i] {
+				t.Errorf("Layer #%d: weights are not equal at pos #%d. Expected value: %f. Got: %f", l, i, expected[i], got[i])
+			}
+		}
+	}
+
+	// Loading errors should be reported by Train
+	os.Remove(files[2])
+	_, _, err = lazyNet.TrainOn(dataset, TrainConfig{Epochs: 1})
+	if !errors.Is(err, os.ErrNotExist) {
+		t.Errorf("Training should fail with missing file error, but got %v", err)
+	}
+}
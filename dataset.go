@@ -0,0 +1,209 @@
+package cnns
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/LdDl/cnns/tensor"
+)
+
+// DatasetOf - indexed collection of samples (input and target)
+type DatasetOf[T tensor.Float] interface {
+	// Len - number of samples
+	Len() int
+	// Get - returns input and target of i-th sample
+	Get(i int) (*tensor.TensorOf[T], *tensor.TensorOf[T], error)
+}
+
+// Dataset - dataset of float64 precision
+type Dataset = DatasetOf[float64]
+
+// EpochSetter - dataset whose samples depend on epoch (e.g. random augmentation, see augment.AugmentedDataset). DataLoaderOf calls SetEpoch before loading samples of epoch
+type EpochSetter interface {
+	SetEpoch(epoch int)
+}
+
+// setEpoch - passes epoch to dataset if it is EpochSetter
+func setEpoch[T tensor.Float](dataset DatasetOf[T], epoch int) {
+	if es, ok := dataset.(EpochSetter); ok {
+		es.SetEpoch(epoch)
+	}
+}
+
+// InMemoryDatasetOf - dataset which keeps all samples in memory
+type InMemoryDatasetOf[T tensor.Float] struct {
+	Inputs  []*tensor.TensorOf[T]
+	Targets []*tensor.TensorOf[T]
+}
+
+// InMemoryDataset - in-memory dataset of float64 precision
+type InMemoryDataset = InMemoryDatasetOf[float64]
+
+// NewInMemoryDataset - constructor for InMemoryDatasetOf. Slices are not copied.
+func NewInMemoryDataset[T tensor.Float](inputs, targets []*tensor.TensorOf[T]) (*InMemoryDatasetOf[T], error) {
+	if len(inputs) != len(targets) {
+		return nil, errors.New("number of inputs not equal to number of targets")
+	}
+	return &InMemoryDatasetOf[T]{
+		Inputs:  inputs,
+		Targets: targets,
+	}, nil
+}
+
+// Len - see DatasetOf
+func (ds *InMemoryDatasetOf[T]) Len() int {
+	return len(ds.Inputs)
+}
+
+// Get - see DatasetOf
+func (ds *InMemoryDatasetOf[T]) Get(i int) (*tensor.TensorOf[T], *tensor.TensorOf[T], error) {
+	if i < 0 || i >= len(ds.Inputs) {
+		return nil, nil, fmt.Errorf("Sample index %d is out of range [0, %d)", i, len(ds.Inputs))
+	}
+	return ds.Inputs[i], ds.Targets[i], nil
+}
+
+// LazyDatasetOf - dataset which loads inputs from disk on demand (nothing is cached)
+/*
+	Files - paths to input files;
+	Targets - target of every file;
+	Loader - function which reads input from file (e.g. image decoding and conversion to tensor).
+*/
+type LazyDatasetOf[T tensor.Float] struct {
+	Files   []string
+	Targets []*tensor.TensorOf[T]
+	Loader  func(fname string) (*tensor.TensorOf[T], error)
+}
+
+// LazyDataset - lazily-loaded dataset of float64 precision
+type LazyDataset = LazyDatasetOf[float64]
+
+// NewLazyDataset - constructor for LazyDatasetOf
+func NewLazyDataset[T tensor.Float](files []string, targets []*tensor.TensorOf[T], loader func(fname string) (*tensor.TensorOf[T], error)) (*LazyDatasetOf[T], error) {
+	if len(files) != len(targets) {
+		return nil, errors.New("number of files not equal to number of targets")
+	}
+	if loader == nil {
+		return nil, errors.New("loader is not set")
+	}
+	return &LazyDatasetOf[T]{
+		Files:   files,
+		Targets: targets,
+		Loader:  loader,
+	}, nil
+}
+
+// Len - see DatasetOf
+func (ds *LazyDatasetOf[T]) Len() int {
+	return len(ds.Files)
+}
+
+// Get - see DatasetOf
+func (ds *LazyDatasetOf[T]) Get(i int) (*tensor.TensorOf[T], *tensor.TensorOf[T], error) {
+	if i < 0 || i >= len(ds.Files) {
+		return nil, nil, fmt.Errorf("Sample index %d is out of range [0, %d)", i, len(ds.Files))
+	}
+	input, err := ds.Loader(ds.Files[i])
+	if err != nil {
+		return nil, nil, fmt.Errorf("Can't load '%s': %w", ds.Files[i], err)
+	}
+	return input, ds.Targets[i], nil
+}
+
+// ConcatDatasetOf - datasets joined one after another
+type ConcatDatasetOf[T tensor.Float] struct {
+	datasets []DatasetOf[T]
+}
+
+// NewConcatDataset - constructor for ConcatDatasetOf. Lengths of datasets should not change after concatenation
+func NewConcatDataset[T tensor.Float](datasets ...DatasetOf[T]) *ConcatDatasetOf[T] {
+	return &ConcatDatasetOf[T]{
+		datasets: datasets,
+	}
+}
+
+// Len - see DatasetOf
+func (ds *ConcatDatasetOf[T]) Len() int {
+	total := 0
+	for _, d := range ds.datasets {
+		total += d.Len()
+	}
+	return total
+}
+
+// Get - see DatasetOf
+func (ds *ConcatDatasetOf[T]) Get(i int) (*tensor.TensorOf[T], *tensor.TensorOf[T], error) {
+	if i < 0 {
+		return nil, nil, fmt.Errorf("Sample index %d is out of range", i)
+	}
+	for _, d := range ds.datasets {
+		if i < d.Len() {
+			return d.Get(i)
+		}
+		i -= d.Len()
+	}
+	return nil, nil, fmt.Errorf("Sample index is out of range [0, %d)", ds.Len())
+}
+
+// SetEpoch - see EpochSetter
+func (ds *ConcatDatasetOf[T]) SetEpoch(epoch int) {
+	for _, d := range ds.datasets {
+		setEpoch(d, epoch)
+	}
+}
+
+// SubsetDatasetOf - subset of samples of another dataset
+type SubsetDatasetOf[T tensor.Float] struct {
+	dataset DatasetOf[T]
+	indices []int
+}
+
+// NewSubsetDataset - constructor for SubsetDatasetOf. indices - indices of samples in parent dataset
+func NewSubsetDataset[T tensor.Float](dataset DatasetOf[T], indices []int) (*SubsetDatasetOf[T], error) {
+	for _, idx := range indices {
+		if idx < 0 || idx >= dataset.Len() {
+			return nil, fmt.Errorf("Sample index %d is out of range [0, %d)", idx, dataset.Len())
+		}
+	}
+	return &SubsetDatasetOf[T]{
+		dataset: dataset,
+		indices: indices,
+	}, nil
+}
+
+// Len - see DatasetOf
+func (ds *SubsetDatasetOf[T]) Len() int {
+	return len(ds.indices)
+}
+
+// Get - see DatasetOf
+func (ds *SubsetDatasetOf[T]) Get(i int) (*tensor.TensorOf[T], *tensor.TensorOf[T], error) {
+	if i < 0 || i >= len(ds.indices) {
+		return nil, nil, fmt.Errorf("Sample index %d is out of range [0, %d)", i, len(ds.indices))
+	}
+	return ds.dataset.Get(ds.indices[i])
+}
+
+// SetEpoch - see EpochSetter
+func (ds *SubsetDatasetOf[T]) SetEpoch(epoch int) {
+	setEpoch(ds.dataset, epoch)
+}
+
+// SplitDataset - randomly splits dataset into two subsets (e.g. training and validation ones). fraction - fraction of samples in first subset
+func SplitDataset[T tensor.Float](dataset DatasetOf[T], fraction float64, seed int64) (*SubsetDatasetOf[T], *SubsetDatasetOf[T], error) {
+	if fraction < 0 || fraction > 1 {
+		return nil, nil, errors.New("fraction should be in range [0, 1]")
+	}
+	order := make([]int, dataset.Len())
+	epochOrder(order, true, seed, 0)
+	n := int(fraction * float64(len(order)))
+	first, err := NewSubsetDataset(dataset, order[:n])
+	if err != nil {
+		return nil, nil, err
+	}
+	second, err := NewSubsetDataset(dataset, order[n:])
+	if err != nil {
+		return nil, nil, err
+	}
+	return first, second, nil
+}
@@ -0,0 +1,90 @@
+package datasets
+
+import (
+	"errors"
+	"fmt"
+	"io"
+
+	"github.com/LdDl/cnns/tensor"
+)
+
+const (
+	// cifarSide - width and height of CIFAR images
+	cifarSide = 32
+	// cifarImageBytes - 32x32 pixels for each of red, green and blue channels
+	cifarImageBytes = cifarSide * cifarSide * 3
+)
+
+var (
+	// CIFAR10Classes - class names of CIFAR-10
+	CIFAR10Classes = []string{"airplane", "automobile", "bird", "cat", "deer", "dog", "frog", "horse", "ship", "truck"}
+)
+
+// ReadCIFAR - reads records of CIFAR binary batch. See ref. https://www.cs.toronto.edu/~kriz/cifar.html
+/*
+	labelBytes - number of label bytes before every image (1 for CIFAR-10, 2 for CIFAR-100);
+	labelIndex - which of label bytes is used (for CIFAR-100: 0 - coarse label, 1 - fine label).
+
+	Returns tensors 32x32x3 (Z = 0 is red, Z = 1 is green, Z = 2 is blue) with values in [0, 1] and labels.
+*/
+func ReadCIFAR(r io.Reader, labelBytes, labelIndex int) ([]*tensor.Tensor, []int, error) {
+	if labelIndex < 0 || labelIndex >= labelBytes {
+		return nil, nil, errors.New("label index should be less than number of label bytes")
+	}
+	record := make([]byte, labelBytes+cifarImageBytes)
+	var inputs []*tensor.Tensor
+	var labels []int
+	for {
+		_, err := io.ReadFull(r, record)
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, nil, fmt.Errorf("Can't read record #%d: %w", len(inputs), err)
+		}
+		t := tensor.NewTensor(cifarSide, cifarSide, 3)
+		// Record stores channels as separate row-major planes, as tensor does
+		for i, v := range record[labelBytes:] {
+			t.Data[i] = float64(v) / 255.0
+		}
+		inputs = append(inputs, t)
+		labels = append(labels, int(record[labelIndex]))
+	}
+	return inputs, labels, nil
+}
+
+// loadCIFAR - reads and joins several batch files
+func loadCIFAR(files []string, labelBytes, labelIndex int, classes []string) (*LabeledDataset, error) {
+	if len(files) == 0 {
+		return nil, errors.New("no batch files")
+	}
+	var inputs []*tensor.Tensor
+	var labels []int
+	for _, fname := range files {
+		f, err := openMaybeGzip(fname)
+		if err != nil {
+			return nil, err
+		}
+		batchInputs, batchLabels, err := ReadCIFAR(f, labelBytes, labelIndex)
+		f.Close()
+		if err != nil {
+			return nil, fmt.Errorf("Can't read '%s': %w", fname, err)
+		}
+		inputs = append(inputs, batchInputs...)
+		labels = append(labels, batchLabels...)
+	}
+	return newLabeledDataset(inputs, labels, classes)
+}
+
+// LoadCIFAR10 - loads CIFAR-10 binary batches, e.g. "data_batch_1.bin", ..., "data_batch_5.bin"
+func LoadCIFAR10(files ...string) (*LabeledDataset, error) {
+	return loadCIFAR(files, 1, 0, CIFAR10Classes)
+}
+
+// LoadCIFAR100 - loads CIFAR-100 binary batches ("train.bin" or "test.bin"). fine - use 100 fine labels, otherwise 20 coarse labels are used
+func LoadCIFAR100(fine bool, files ...string) (*LabeledDataset, error) {
+	if fine {
+		return loadCIFAR(files, 2, 1, indexClasses(100))
+	}
+	return loadCIFAR(files, 2, 0, indexClasses(20))
+}
@@ -0,0 +1,215 @@
+package datasets
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+
+	"github.com/LdDl/cnns/tensor"
+)
+
+// Types of elements in IDX format. See ref. http://yann.lecun.com/exdb/mnist/
+const (
+	IDXUint8   = 0x08
+	IDXInt8    = 0x09
+	IDXInt16   = 0x0B
+	IDXInt32   = 0x0C
+	IDXFloat32 = 0x0D
+	IDXFloat64 = 0x0E
+)
+
+// MaxIDXSize - max size of data of IDX array in bytes: larger (e.g. corrupted) headers are rejected
+var MaxIDXSize = math.MaxInt32
+
+var (
+	// MNISTClasses - class names of MNIST
+	MNISTClasses = indexClasses(10)
+	// FashionMNISTClasses - class names of Fashion-MNIST
+	FashionMNISTClasses = []string{"T-shirt/top", "Trouser", "Pullover", "Dress", "Coat", "Sandal", "Shirt", "Sneaker", "Bag", "Ankle boot"}
+)
+
+// IDXArray - multidimensional array stored in IDX format
+/*
+	Type - type of elements (IDXUint8 and etc.);
+	Dims - dimensions (first one is number of items);
+	Data - elements in row-major order.
+*/
+type IDXArray struct {
+	Type byte
+	Dims []int
+	Data []float64
+}
+
+// idxSize - size of element in bytes
+func idxSize(t byte) (int, error) {
+	switch t {
+	case IDXUint8, IDXInt8:
+		return 1, nil
+	case IDXInt16:
+		return 2, nil
+	case IDXInt32, IDXFloat32:
+		return 4, nil
+	case IDXFloat64:
+		return 8, nil
+	default:
+		return 0, fmt.Errorf("Unknown IDX type 0x%02x", t)
+	}
+}
+
+// ReadIDX - reads IDX array. Gzip compressed data is detected automatically
+func ReadIDX(r io.Reader) (*IDXArray, error) {
+	r, closer, err := maybeGzip(r)
+	if err != nil {
+		return nil, err
+	}
+	if closer != nil {
+		defer closer.Close()
+	}
+	header := make([]byte, 4)
+	_, err = io.ReadFull(r, header)
+	if err != nil {
+		return nil, err
+	}
+	if header[0] != 0 || header[1] != 0 {
+		return nil, errors.New("wrong magic number of IDX file")
+	}
+	arr := &IDXArray{
+		Type: header[2],
+		Dims: make([]int, header[3]),
+	}
+	size, err := idxSize(arr.Type)
+	if err != nil {
+		return nil, err
+	}
+	total := 1
+	for i := range arr.Dims {
+		var dim uint32
+		err = binary.Read(r, binary.BigEndian, &dim)
+		if err != nil {
+			return nil, err
+		}
+		arr.Dims[i] = int(dim)
+		// Check is done before multiplication, so product can not overflow
+		if dim != 0 && total > MaxIDXSize/size/int(dim) {
+			return nil, fmt.Errorf("IDX data exceeds %d bytes", MaxIDXSize)
+		}
+		total *= int(dim)
+	}
+	// Buffer grows while data is read, so truncated data does not allocate memory for declared size
+	buf := bytes.Buffer{}
+	_, err = io.CopyN(&buf, r, int64(total*size))
+	if err != nil {
+		if err == io.EOF {
+			err = io.ErrUnexpectedEOF
+		}
+		return nil, fmt.Errorf("Can't read IDX data: %w", err)
+	}
+	raw := buf.Bytes()
+	arr.Data = make([]float64, total)
+	for i := range arr.Data {
+		b := raw[i*size : (i+1)*size]
+		switch arr.Type {
+		case IDXUint8:
+			arr.Data[i] = float64(b[0])
+		case IDXInt8:
+			arr.Data[i] = float64(int8(b[0]))
+		case IDXInt16:
+			arr.Data[i] = float64(int16(binary.BigEndian.Uint16(b)))
+		case IDXInt32:
+			arr.Data[i] = float64(int32(binary.BigEndian.Uint32(b)))
+		case IDXFloat32:
+			arr.Data[i] = float64(math.Float32frombits(binary.BigEndian.Uint32(b)))
+		case IDXFloat64:
+			arr.Data[i] = math.Float64frombits(binary.BigEndian.Uint64(b))
+		}
+	}
+	return arr, nil
+}
+
+// ReadIDXFile - reads IDX array from file (".gz" files are supported)
+func ReadIDXFile(fname string) (*IDXArray, error) {
+	f, err := openMaybeGzip(fname)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	arr, err := ReadIDX(f)
+	if err != nil {
+		return nil, fmt.Errorf("Can't read '%s': %w", fname, err)
+	}
+	return arr, nil
+}
+
+// IDXImages - converts IDX array of shape [N, rows, cols] (or [N, rows, cols, channels]) to tensors with X = cols, Y = rows, Z = channels. Values are divided by scale (255 for MNIST pixels)
+func IDXImages(arr *IDXArray, scale float64) ([]*tensor.Tensor, error) {
+	if len(arr.Dims) != 3 && len(arr.Dims) != 4 {
+		return nil, fmt.Errorf("Images should have 3 or 4 dimensions, but got %d", len(arr.Dims))
+	}
+	rows, cols, channels := arr.Dims[1], arr.Dims[2], 1
+	if len(arr.Dims) == 4 {
+		channels = arr.Dims[3]
+	}
+	plane := rows * cols
+	ret := make([]*tensor.Tensor, arr.Dims[0])
+	for n := range ret {
+		t := tensor.NewTensor(cols, rows, channels)
+		offset := n * plane * channels
+		// IDX stores channels last, tensor stores them as separate planes
+		for p := 0; p < plane; p++ {
+			for c := 0; c < channels; c++ {
+				t.Data[c*plane+p] = arr.Data[offset+p*channels+c] / scale
+			}
+		}
+		ret[n] = t
+	}
+	return ret, nil
+}
+
+// IDXLabels - converts IDX array of shape [N] to class labels
+func IDXLabels(arr *IDXArray) ([]int, error) {
+	if len(arr.Dims) != 1 {
+		return nil, fmt.Errorf("Labels should have 1 dimension, but got %d", len(arr.Dims))
+	}
+	ret := make([]int, len(arr.Data))
+	for i, v := range arr.Data {
+		ret[i] = int(v)
+	}
+	return ret, nil
+}
+
+// LoadIDX - loads images and labels in IDX format (e.g. MNIST or Fashion-MNIST). Pixels are scaled to [0, 1].
+/*
+	imagesFile, labelsFile - paths to files, e.g. "train-images-idx3-ubyte.gz" and "train-labels-idx1-ubyte.gz";
+	classes - names of classes (see MNISTClasses and FashionMNISTClasses).
+*/
+func LoadIDX(imagesFile, labelsFile string, classes []string) (*LabeledDataset, error) {
+	imagesArr, err := ReadIDXFile(imagesFile)
+	if err != nil {
+		return nil, err
+	}
+	labelsArr, err := ReadIDXFile(labelsFile)
+	if err != nil {
+		return nil, err
+	}
+	scale := 1.0
+	if imagesArr.Type == IDXUint8 {
+		scale = 255.0
+	}
+	inputs, err := IDXImages(imagesArr, scale)
+	if err != nil {
+		return nil, err
+	}
+	labels, err := IDXLabels(labelsArr)
+	if err != nil {
+		return nil, err
+	}
+	return newLabeledDataset(inputs, labels, classes)
+}
+
+// LoadMNIST - loads MNIST dataset. See LoadIDX
+func LoadMNIST(imagesFile, labelsFile string) (*LabeledDataset, error) {
+	return LoadIDX(imagesFile, labelsFile, MNISTClasses)
+}
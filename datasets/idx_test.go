@@ -0,0 +1,154 @@
+package datasets
+
+import (
+	"bytes"
+	"compress/gzip"
+	"encoding/binary"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/LdDl/cnns"
+)
+
+var _ cnns.Dataset = (*LabeledDataset)(nil)
+
+// writeIDX - writes uint8 IDX file (gzip compressed if compress is set)
+func writeIDX(t *testing.T, fname string, dims []int, data []byte, compress bool) {
+	buf := &bytes.Buffer{}
+	buf.Write([]byte{0, 0, IDXUint8, byte(len(dims))})
+	for _, d := range dims {
+		binary.Write(buf, binary.BigEndian, uint32(d))
+	}
+	buf.Write(data)
+	content := buf.Bytes()
+	if compress {
+		gzBuf := &bytes.Buffer{}
+		gz := gzip.NewWriter(gzBuf)
+		gz.Write(content)
+		gz.Close()
+		content = gzBuf.Bytes()
+	}
+	if err := os.WriteFile(fname, content, 0644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestLoadIDX(t *testing.T) {
+	dir := t.TempDir()
+	// Two images 3x2 (cols x rows)
+	pixels := []byte{
+		0, 51, 102,
+		153, 204, 255,
+
+		255, 0, 0,
+		0, 0, 255,
+	}
+	for _, compress := range []bool{false, true} {
+		images := filepath.Join(dir, "images.idx3")
+		labels := filepath.Join(dir, "labels.idx1")
+		writeIDX(t, images, []int{2, 2, 3}, pixels, compress)
+		writeIDX(t, labels, []int{2}, []byte{7, 2}, compress)
+		ds, err := LoadMNIST(images, labels)
+		if err != nil {
+			t.Error(err)
+			return
+		}
+		if ds.Len() != 2 {
+			t.Errorf("Number of samples should be 2, but got %d", ds.Len())
+			return
+		}
+		input, target, err := ds.Get(0)
+		if err != nil {
+			t.Error(err)
+			return
+		}
+		if input.Size.X != 3 || input.Size.Y != 2 || input.Size.Z != 1 {
+			t.Errorf("Input size should be 3x2x1, but got %dx%dx%d", input.Size.X, input.Size.Y, input.Size.Z)
+		}
+		if input.Get(1, 0, 0) != 0.2 || input.Get(0, 1, 0) != 0.6 {
+			t.Errorf("Pixels should be 0.2 and 0.6, but got %f and %f", input.Get(1, 0, 0), input.Get(0, 1, 0))
+		}
+		if len(target.Data) != 10 || target.Data[7] != 1.0 {
+			t.Errorf("Target should be one-hot vector of class 7, but got %v", target.Data)
+		}
+		if ds.Labels[1] != 2 {
+			t.Errorf("Label of second sample should be 2, but got %d", ds.Labels[1])
+		}
+	}
+
+	labels := filepath.Join(dir, "bad-labels.idx1")
+	writeIDX(t, labels, []int{2}, []byte{7, 12}, false)
+	_, err := LoadMNIST(filepath.Join(dir, "images.idx3"), labels)
+	if err == nil {
+		t.Errorf("Label out of range should produce error")
+	}
+
+	// Corrupted headers: overflow of size, huge size, truncated data
+	for _, dims := range [][]int{{0x7FFFFFFF, 0x7FFFFFFF, 0x7FFFFFFF}, {0x7FFFFFFF, 2}, {2, 2, 3}} {
+		bad := filepath.Join(dir, "bad.idx3")
+		writeIDX(t, bad, dims, pixels[:5], false)
+		if _, err = ReadIDXFile(bad); err == nil {
+			t.Errorf("Corrupted header %v should produce error", dims)
+		}
+	}
+}
+
+func TestLoadCIFAR(t *testing.T) {
+	dir := t.TempDir()
+	buf := &bytes.Buffer{}
+	for n := 0; n < 3; n++ {
+		buf.Write([]byte{byte(n + 3), byte(n + 40)})
+		image := make([]byte, cifarImageBytes)
+		// red pixel (x = 1, y = 2) and blue pixel (x = 31, y = 0)
+		image[2*32+1] = 255
+		image[2*1024+31] = 51
+		buf.Write(image)
+	}
+	fname := filepath.Join(dir, "train.bin")
+	if err := os.WriteFile(fname, buf.Bytes(), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	ds, err := LoadCIFAR100(true, fname)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	if ds.Len() != 3 {
+		t.Errorf("Number of samples should be 3, but got %d", ds.Len())
+		return
+	}
+	input, target, err := ds.Get(2)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	if input.Size.X != 32 || input.Size.Y != 32 || input.Size.Z != 3 {
+		t.Errorf("Input size should be 32x32x3, but got %dx%dx%d", input.Size.X, input.Size.Y, input.Size.Z)
+	}
+	if input.Get(1, 2, 0) != 1.0 || input.Get(31, 0, 2) != 0.2 || input.Get(1, 2, 1) != 0.0 {
+		t.Errorf("Wrong pixels: %f %f %f", input.Get(1, 2, 0), input.Get(31, 0, 2), input.Get(1, 2, 1))
+	}
+	if len(target.Data) != 100 || target.Data[42] != 1.0 {
+		t.Errorf("Target should be one-hot vector of fine class 42")
+	}
+
+	coarse, err := LoadCIFAR100(false, fname)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	if len(coarse.Classes) != 20 || coarse.Labels[2] != 5 {
+		t.Errorf("Coarse label should be 5 of 20 classes, but got %d of %d", coarse.Labels[2], len(coarse.Classes))
+	}
+
+	// Truncated file
+	if err := os.WriteFile(fname, buf.Bytes()[:100], 0644); err != nil {
+		t.Fatal(err)
+	}
+	_, err = LoadCIFAR10(fname)
+	if err == nil {
+		t.Errorf("Truncated file should produce error")
+	}
+}
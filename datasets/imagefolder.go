@@ -0,0 +1,248 @@
+// Package datasets provides loaders of common dataset formats. Every dataset implements cnns.Dataset (Len and Get), so it can be passed to WholeNet.TrainOn and WholeNet.Evaluate directly.
+package datasets
+
+import (
+	"errors"
+	"fmt"
+	"image"
+	"io/ioutil"
+	"math/rand"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/LdDl/cnns/tensor"
+	"github.com/LdDl/cnns/utils/im"
+	"github.com/LdDl/cnns/utils/u"
+	"github.com/nfnt/resize"
+)
+
+// ColorMode - channels of image tensor (see im.ColorMode)
+type ColorMode = im.ColorMode
+
+const (
+	// Grayscale - single channel (luminance: 0.299*R + 0.587*G + 0.114*B)
+	Grayscale = im.Grayscale
+	// RGB - three channels (Z = 0 is red, Z = 1 is green, Z = 2 is blue)
+	RGB = im.RGB
+	// RGBA - four channels (RGB and alpha as Z = 3)
+	RGBA = im.RGBA
+)
+
+// ImageFolderConfig - parameters of ImageFolder
+/*
+	Width, Height - size of tensors (images are resized with bicubic interpolation);
+	ColorMode - see ColorMode;
+	Mean, Std - per-channel normalization (x - Mean) / Std applied after scaling pixels to [0, 1]. Not applied if empty;
+	Classes - names of subdirectories in order of class indices. All subdirectories sorted by name are used if empty;
+	Extensions - extensions of image files (".png", ".jpg", ".jpeg", ".bmp" if empty);
+	MaxPerClass - use at most N randomly chosen images per class (0 - no limit);
+	MinPerClass - repeat randomly chosen images of class until it has N samples (0 - no oversampling). It helps if some classes have a few images;
+	Seed - seed for choosing images for MaxPerClass and MinPerClass;
+	Cache - keep decoded tensors in memory after first access.
+*/
+type ImageFolderConfig struct {
+	Width       int
+	Height      int
+	ColorMode   ColorMode
+	Mean        []float64
+	Std         []float64
+	Classes     []string
+	Extensions  []string
+	MaxPerClass int
+	MinPerClass int
+	Seed        int64
+	Cache       bool
+}
+
+// ImageSample - image file and its class
+type ImageSample struct {
+	Path  string
+	Class int
+}
+
+// ImageFolder - dataset of images stored as root/<class name>/<image file>. Targets are one-hot vectors of size len(Classes)
+type ImageFolder struct {
+	Root       string
+	Classes    []string
+	ClassIndex map[string]int
+	Samples    []ImageSample
+	cfg        ImageFolderConfig
+	mu         sync.Mutex
+	cache      map[string]*tensor.Tensor
+}
+
+// NewImageFolder - constructor for ImageFolder. Only file names are read here, images are decoded on demand
+func NewImageFolder(root string, cfg ImageFolderConfig) (*ImageFolder, error) {
+	if cfg.Width < 1 || cfg.Height < 1 {
+		return nil, errors.New("width and height of images should be positive")
+	}
+	channels := cfg.ColorMode.Channels()
+	if (len(cfg.Mean) != 0 && len(cfg.Mean) != channels) || (len(cfg.Std) != 0 && len(cfg.Std) != channels) {
+		return nil, fmt.Errorf("Mean and Std should contain %d values", channels)
+	}
+	for _, s := range cfg.Std {
+		if s == 0 {
+			return nil, errors.New("Std can not contain zero values")
+		}
+	}
+	if len(cfg.Extensions) == 0 {
+		cfg.Extensions = []string{".png", ".jpg", ".jpeg", ".bmp"}
+	}
+	classes := cfg.Classes
+	if len(classes) == 0 {
+		entries, err := ioutil.ReadDir(root)
+		if err != nil {
+			return nil, err
+		}
+		for _, e := range entries {
+			if e.IsDir() {
+				classes = append(classes, e.Name())
+			}
+		}
+		sort.Strings(classes)
+	}
+	if len(classes) == 0 {
+		return nil, fmt.Errorf("No class subdirectories in '%s'", root)
+	}
+	folder := &ImageFolder{
+		Root:       root,
+		Classes:    classes,
+		ClassIndex: make(map[string]int, len(classes)),
+		cfg:        cfg,
+	}
+	if cfg.Cache {
+		folder.cache = make(map[string]*tensor.Tensor)
+	}
+	rnd := rand.New(rand.NewSource(cfg.Seed))
+	for idx, class := range classes {
+		folder.ClassIndex[class] = idx
+		entries, err := ioutil.ReadDir(filepath.Join(root, class))
+		if err != nil {
+			return nil, err
+		}
+		var files []string
+		for _, e := range entries {
+			if e.IsDir() || !hasExtension(e.Name(), cfg.Extensions) {
+				continue
+			}
+			files = append(files, filepath.Join(root, class, e.Name()))
+		}
+		if cfg.MaxPerClass > 0 && len(files) > cfg.MaxPerClass {
+			rnd.Shuffle(len(files), func(i, j int) {
+				files[i], files[j] = files[j], files[i]
+			})
+			files = files[:cfg.MaxPerClass]
+			sort.Strings(files)
+		}
+		if len(files) != 0 {
+			for n := len(files); n < cfg.MinPerClass; n++ {
+				files = append(files, files[rnd.Intn(n)])
+			}
+		}
+		for _, f := range files {
+			folder.Samples = append(folder.Samples, ImageSample{Path: f, Class: idx})
+		}
+	}
+	return folder, nil
+}
+
+// hasExtension - checks extension of file (case-insensitive)
+func hasExtension(fname string, extensions []string) bool {
+	ext := strings.ToLower(filepath.Ext(fname))
+	for _, e := range extensions {
+		if strings.ToLower(e) == ext {
+			return true
+		}
+	}
+	return false
+}
+
+// Len - number of samples
+func (f *ImageFolder) Len() int {
+	return len(f.Samples)
+}
+
+// Get - returns image tensor (Width x Height x channels) and one-hot target of i-th sample
+func (f *ImageFolder) Get(i int) (*tensor.Tensor, *tensor.Tensor, error) {
+	if i < 0 || i >= len(f.Samples) {
+		return nil, nil, fmt.Errorf("Sample index %d is out of range [0, %d)", i, len(f.Samples))
+	}
+	sample := f.Samples[i]
+	input, err := f.load(sample.Path)
+	if err != nil {
+		return nil, nil, err
+	}
+	target := tensor.NewTensor(len(f.Classes), 1, 1)
+	target.Data[sample.Class] = 1.0
+	return input, target, nil
+}
+
+// Load - decodes all images and returns inputs and targets
+func (f *ImageFolder) Load() ([]*tensor.Tensor, []*tensor.Tensor, error) {
+	inputs := make([]*tensor.Tensor, len(f.Samples))
+	targets := make([]*tensor.Tensor, len(f.Samples))
+	for i := range f.Samples {
+		input, target, err := f.Get(i)
+		if err != nil {
+			return nil, nil, err
+		}
+		inputs[i] = input
+		targets[i] = target
+	}
+	return inputs, targets, nil
+}
+
+// LabelMap - returns map from class index to class name
+func (f *ImageFolder) LabelMap() map[int]string {
+	ret := make(map[int]string, len(f.Classes))
+	for i, c := range f.Classes {
+		ret[i] = c
+	}
+	return ret
+}
+
+// ClassCounts - returns number of samples of every class
+func (f *ImageFolder) ClassCounts() []int {
+	ret := make([]int, len(f.Classes))
+	for _, s := range f.Samples {
+		ret[s.Class]++
+	}
+	return ret
+}
+
+// load - reads image file (or takes it from cache)
+func (f *ImageFolder) load(fname string) (*tensor.Tensor, error) {
+	if f.cache != nil {
+		f.mu.Lock()
+		cached, ok := f.cache[fname]
+		f.mu.Unlock()
+		if ok {
+			return cached, nil
+		}
+	}
+	img, err := u.ReadImage(fname)
+	if err != nil {
+		return nil, fmt.Errorf("Can't read '%s': %w", fname, err)
+	}
+	ret, err := imageToTensor(img, f.cfg)
+	if err != nil {
+		return nil, err
+	}
+	if f.cache != nil {
+		f.mu.Lock()
+		f.cache[fname] = ret
+		f.mu.Unlock()
+	}
+	return ret, nil
+}
+
+// imageToTensor - resizes image and converts it to tensor with values in [0, 1] (then normalized with Mean and Std)
+func imageToTensor(img image.Image, cfg ImageFolderConfig) (*tensor.Tensor, error) {
+	bounds := img.Bounds()
+	if bounds.Dx() != cfg.Width || bounds.Dy() != cfg.Height {
+		img = resize.Resize(uint(cfg.Width), uint(cfg.Height), img, resize.Bicubic)
+	}
+	return im.ToTensorNormalized(img, cfg.ColorMode, cfg.Mean, cfg.Std)
+}
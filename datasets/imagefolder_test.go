@@ -0,0 +1,106 @@
+package datasets
+
+import (
+	"image"
+	"image/color"
+	"image/png"
+	"math"
+	"os"
+	"path/filepath"
+	"strconv"
+	"testing"
+
+	"github.com/LdDl/cnns"
+)
+
+// Datasets should plug into training directly
+var _ cnns.Dataset = (*ImageFolder)(nil)
+
+func writePNG(t *testing.T, fname string, c color.Color) {
+	img := image.NewRGBA(image.Rect(0, 0, 4, 4))
+	for y := 0; y < 4; y++ {
+		for x := 0; x < 4; x++ {
+			img.Set(x, y, c)
+		}
+	}
+	f, err := os.Create(fname)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	err = png.Encode(f, img)
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestImageFolder(t *testing.T) {
+	root := t.TempDir()
+	colors := map[string]color.Color{
+		"red":   color.RGBA{255, 0, 0, 255},
+		"white": color.RGBA{255, 255, 255, 255},
+	}
+	counts := map[string]int{"red": 5, "white": 2}
+	for class, c := range colors {
+		err := os.Mkdir(filepath.Join(root, class), 0755)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for i := 0; i < counts[class]; i++ {
+			writePNG(t, filepath.Join(root, class, strconv.Itoa(i)+".png"), c)
+		}
+	}
+	// Not an image
+	err := os.WriteFile(filepath.Join(root, "red", "notes.txt"), []byte("skip me"), 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	folder, err := NewImageFolder(root, ImageFolderConfig{Width: 2, Height: 2, MaxPerClass: 3, MinPerClass: 3})
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	if len(folder.Classes) != 2 || folder.Classes[0] != "red" || folder.ClassIndex["white"] != 1 {
+		t.Errorf("Wrong classes: %v", folder.Classes)
+	}
+	classCounts := folder.ClassCounts()
+	if classCounts[0] != 3 || classCounts[1] != 3 {
+		t.Errorf("Every class should contain %d samples, but got %v", 3, classCounts)
+	}
+	input, target, err := folder.Get(0)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	if input.Size.X != 2 || input.Size.Y != 2 || input.Size.Z != 1 {
+		t.Errorf("Wrong size of input: %v", input.Size)
+	}
+	if math.Abs(input.Data[0]-0.299) > 1e-6 {
+		t.Errorf("Wrong luminance of red pixel. Expected value: %f. Got: %f", 0.299, input.Data[0])
+	}
+	if target.Data[0] != 1 || target.Data[1] != 0 {
+		t.Errorf("Wrong target: %v", target.Data)
+	}
+
+	rgb, err := NewImageFolder(root, ImageFolderConfig{Width: 4, Height: 4, ColorMode: RGB, Classes: []string{"white"}, Mean: []float64{0.5, 0.5, 0.5}, Std: []float64{0.5, 0.5, 0.5}, Cache: true})
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	inputs, targets, err := rgb.Load()
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	if len(inputs) != 2 || len(targets[0].Data) != 1 || inputs[0].Size.Z != 3 {
+		t.Errorf("Wrong RGB dataset: %d samples, input size %v", len(inputs), inputs[0].Size)
+		return
+	}
+	for i, v := range inputs[0].Data {
+		if math.Abs(v-1.0) > 1e-12 {
+			t.Errorf("Normalized white pixel at pos #%d is wrong. Expected value: %f. Got: %f", i, 1.0, v)
+			break
+		}
+	}
+}
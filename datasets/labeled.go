@@ -0,0 +1,121 @@
+package datasets
+
+import (
+	"bufio"
+	"compress/gzip"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+
+	"github.com/LdDl/cnns/tensor"
+)
+
+// LabeledDataset - in-memory dataset of inputs with class labels. Targets are one-hot vectors of size len(Classes)
+type LabeledDataset struct {
+	Inputs  []*tensor.Tensor
+	Targets []*tensor.Tensor
+	Labels  []int
+	Classes []string
+}
+
+// newLabeledDataset - builds one-hot targets for labels
+func newLabeledDataset(inputs []*tensor.Tensor, labels []int, classes []string) (*LabeledDataset, error) {
+	if len(inputs) != len(labels) {
+		return nil, fmt.Errorf("Number of inputs %d not equal to number of labels %d", len(inputs), len(labels))
+	}
+	ds := &LabeledDataset{
+		Inputs:  inputs,
+		Targets: make([]*tensor.Tensor, len(labels)),
+		Labels:  labels,
+		Classes: classes,
+	}
+	for i, label := range labels {
+		if label < 0 || label >= len(classes) {
+			return nil, fmt.Errorf("Label %d of sample #%d is out of range [0, %d)", label, i, len(classes))
+		}
+		ds.Targets[i] = tensor.NewTensor(len(classes), 1, 1)
+		ds.Targets[i].Data[label] = 1.0
+	}
+	return ds, nil
+}
+
+// Len - number of samples
+func (ds *LabeledDataset) Len() int {
+	return len(ds.Inputs)
+}
+
+// Get - returns input and one-hot target of i-th sample
+func (ds *LabeledDataset) Get(i int) (*tensor.Tensor, *tensor.Tensor, error) {
+	if i < 0 || i >= len(ds.Inputs) {
+		return nil, nil, fmt.Errorf("Sample index %d is out of range [0, %d)", i, len(ds.Inputs))
+	}
+	return ds.Inputs[i], ds.Targets[i], nil
+}
+
+// indexClasses - class names equal to class indices
+func indexClasses(n int) []string {
+	ret := make([]string, n)
+	for i := range ret {
+		ret[i] = strconv.Itoa(i)
+	}
+	return ret
+}
+
+// readCloser - file with optional gzip decompression
+type readCloser struct {
+	io.Reader
+	closers []io.Closer
+}
+
+// Close - closes decompressor and file
+func (rc *readCloser) Close() error {
+	var err error
+	for i := len(rc.closers) - 1; i >= 0; i-- {
+		if cerr := rc.closers[i].Close(); cerr != nil && err == nil {
+			err = cerr
+		}
+	}
+	return err
+}
+
+// maybeGzip - returns reader which decompresses data if it starts with gzip magic number
+func maybeGzip(r io.Reader) (io.Reader, io.Closer, error) {
+	buffered := bufio.NewReader(r)
+	magic, err := buffered.Peek(2)
+	if err == nil && magic[0] == 0x1f && magic[1] == 0x8b {
+		gz, err := gzip.NewReader(buffered)
+		if err != nil {
+			return nil, nil, err
+		}
+		return gz, gz, nil
+	}
+	return buffered, nil, nil
+}
+
+// openMaybeGzip - opens file which can be gzip compressed
+func openMaybeGzip(fname string) (io.ReadCloser, error) {
+	f, err := os.Open(fname)
+	if err != nil {
+		return nil, err
+	}
+	r, closer, err := maybeGzip(f)
+	if err != nil {
+		f.Close()
+		return nil, fmt.Errorf("Can't read '%s': %w", fname, err)
+	}
+	rc := &readCloser{Reader: r, closers: []io.Closer{f}}
+	if closer != nil {
+		rc.closers = append(rc.closers, closer)
+	}
+	return rc, nil
+}
+
+// LabelMap - returns map from class index to class name
+func (ds *LabeledDataset) LabelMap() map[int]string {
+	ret := make(map[int]string, len(ds.Classes))
+	for i, c := range ds.Classes {
+		ret[i] = c
+	}
+	return ret
+}
@@ -0,0 +1,639 @@
+package datasets
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/LdDl/cnns"
+	"github.com/LdDl/cnns/tensor"
+)
+
+// MissingStrategy - what to do with missing values of tabular data (see TabularConfig.MissingValues)
+type MissingStrategy int
+
+const (
+	// MissingError - missing value is an error
+	MissingError = MissingStrategy(iota)
+	// MissingDrop - rows with missing values are skipped (at inference missing value is an error)
+	MissingDrop
+	// MissingMean - numeric values are replaced with mean of column, categorical ones with the most frequent category
+	MissingMean
+	// MissingMedian - numeric values are replaced with median of column, categorical ones with the most frequent category
+	MissingMedian
+	// MissingMostFrequent - values are replaced with the most frequent value of column
+	MissingMostFrequent
+	// MissingZero - numeric values are replaced with zero, categorical ones are encoded as all-zero vector
+	MissingZero
+)
+
+// Scaling - scaling of numeric features
+type Scaling int
+
+const (
+	// ScaleNone - values are used as is
+	ScaleNone = Scaling(iota)
+	// ScaleStandard - (x - mean) / std
+	ScaleStandard
+	// ScaleMinMax - (x - min) / (max - min), so values of training data are in [0, 1]
+	ScaleMinMax
+)
+
+var (
+	defaultMissingValues = []string{"", "NA", "N/A", "NaN", "null", "?"}
+)
+
+// TabularConfig - parameters of tabular (CSV/TSV) data
+/*
+	Comma - fields delimiter (',' if not set; '\t' for files with ".tsv" extension);
+	NoHeader - file has no header row. Columns are named "0", "1", ... then;
+	Features - names of feature columns. All columns except Targets and Ignore are used if empty;
+	Targets - names of target columns;
+	Ignore - columns which are not used;
+	Categorical - columns which are one-hot encoded. Columns with non-numeric values are detected as categorical automatically;
+	Missing - see MissingStrategy. Rows with missing targets are skipped for MissingDrop and produce error otherwise;
+	MissingValues - values which are considered as missing (case-insensitive): "", "NA", "N/A", "NaN", "null", "?" if empty;
+	Scaling - scaling of numeric features (targets are not scaled).
+*/
+type TabularConfig struct {
+	Comma         rune
+	NoHeader      bool
+	Features      []string
+	Targets       []string
+	Ignore        []string
+	Categorical   []string
+	Missing       MissingStrategy
+	MissingValues []string
+	Scaling       Scaling
+}
+
+// TabularColumn - fitted parameters of column
+/*
+	Name - name of column;
+	Categories - sorted categories of categorical column (one-hot encoded). Unknown categories of features are encoded as all-zero vector;
+	Fill, FillCategory - replacement of missing value for numeric and categorical column;
+	Mean, Std - parameters of ScaleStandard;
+	Min, Max - parameters of ScaleMinMax.
+*/
+type TabularColumn struct {
+	Name         string   `json:"Name"`
+	Categorical  bool     `json:"Categorical"`
+	Categories   []string `json:"Categories,omitempty"`
+	Fill         float64  `json:"Fill"`
+	FillCategory string   `json:"FillCategory,omitempty"`
+	Mean         float64  `json:"Mean"`
+	Std          float64  `json:"Std"`
+	Min          float64  `json:"Min"`
+	Max          float64  `json:"Max"`
+}
+
+// width - number of values produced by column
+func (col *TabularColumn) width() int {
+	if col.Categorical {
+		return len(col.Categories)
+	}
+	return 1
+}
+
+// TabularPreprocessor - preprocessing fitted on training data. It should be saved with the model (see AttachPreprocessor), so inference applies identical preprocessing
+type TabularPreprocessor struct {
+	Comma         rune            `json:"Comma"`
+	NoHeader      bool            `json:"NoHeader"`
+	Features      []TabularColumn `json:"Features"`
+	Targets       []TabularColumn `json:"Targets"`
+	Missing       MissingStrategy `json:"Missing"`
+	MissingValues []string        `json:"MissingValues"`
+	Scaling       Scaling         `json:"Scaling"`
+}
+
+// ReadCSV - reads header and rows of CSV data. Header is nil if noHeader is set
+func ReadCSV(r io.Reader, comma rune, noHeader bool) ([]string, [][]string, error) {
+	reader := csv.NewReader(r)
+	if comma != 0 {
+		reader.Comma = comma
+	}
+	reader.TrimLeadingSpace = true
+	if reader.Comma == '\t' {
+		reader.LazyQuotes = true
+	}
+	rows, err := reader.ReadAll()
+	if err != nil {
+		return nil, nil, err
+	}
+	if noHeader {
+		return nil, rows, nil
+	}
+	if len(rows) == 0 {
+		return nil, nil, errors.New("no header row")
+	}
+	return rows[0], rows[1:], nil
+}
+
+// ReadCSVFile - reads CSV or TSV file (".gz" files are supported). Delimiter is '\t' for ".tsv" files if comma is not set
+func ReadCSVFile(fname string, comma rune, noHeader bool) ([]string, [][]string, error) {
+	if comma == 0 && strings.EqualFold(filepath.Ext(strings.TrimSuffix(fname, ".gz")), ".tsv") {
+		comma = '\t'
+	}
+	f, err := openMaybeGzip(fname)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer f.Close()
+	header, rows, err := ReadCSV(f, comma, noHeader)
+	if err != nil {
+		return nil, nil, fmt.Errorf("Can't read '%s': %w", fname, err)
+	}
+	return header, rows, nil
+}
+
+// FitTabular - fits preprocessing (categories, missing values' replacements, scaling) on rows of training data
+func FitTabular(header []string, rows [][]string, cfg TabularConfig) (*TabularPreprocessor, error) {
+	if len(cfg.Targets) == 0 {
+		return nil, errors.New("no target columns")
+	}
+	tp := &TabularPreprocessor{
+		Comma:         cfg.Comma,
+		NoHeader:      cfg.NoHeader,
+		Missing:       cfg.Missing,
+		MissingValues: cfg.MissingValues,
+		Scaling:       cfg.Scaling,
+	}
+	if len(tp.MissingValues) == 0 {
+		tp.MissingValues = defaultMissingValues
+	}
+	header = columnNames(header, rows)
+	features := cfg.Features
+	if len(features) == 0 {
+		for _, name := range header {
+			name = strings.TrimSpace(name)
+			if !contains(cfg.Targets, name) && !contains(cfg.Ignore, name) {
+				features = append(features, name)
+			}
+		}
+	}
+	if len(features) == 0 {
+		return nil, errors.New("no feature columns")
+	}
+	featureIdx, err := columnIndices(header, features)
+	if err != nil {
+		return nil, err
+	}
+	targetIdx, err := columnIndices(header, cfg.Targets)
+	if err != nil {
+		return nil, err
+	}
+	if tp.Missing == MissingDrop {
+		rows = tp.completeRows(rows, append(featureIdx, targetIdx...))
+	}
+	if len(rows) == 0 {
+		return nil, errors.New("no rows to fit")
+	}
+	for i, idx := range featureIdx {
+		col, err := tp.fitColumn(features[i], columnValues(rows, idx), contains(cfg.Categorical, features[i]), false)
+		if err != nil {
+			return nil, err
+		}
+		tp.Features = append(tp.Features, col)
+	}
+	for i, idx := range targetIdx {
+		col, err := tp.fitColumn(cfg.Targets[i], columnValues(rows, idx), contains(cfg.Categorical, cfg.Targets[i]), true)
+		if err != nil {
+			return nil, err
+		}
+		tp.Targets = append(tp.Targets, col)
+	}
+	return tp, nil
+}
+
+// fitColumn - calculates parameters of single column
+func (tp *TabularPreprocessor) fitColumn(name string, values []string, categorical, target bool) (TabularColumn, error) {
+	col := TabularColumn{Name: name, Std: 1, Categorical: categorical}
+	var present []string
+	for _, v := range values {
+		if tp.isMissing(v) {
+			if target || tp.Missing == MissingError {
+				return col, fmt.Errorf("Column '%s' has missing values", name)
+			}
+			continue
+		}
+		present = append(present, strings.TrimSpace(v))
+	}
+	if len(present) == 0 {
+		return col, fmt.Errorf("Column '%s' has no values", name)
+	}
+	numbers := make([]float64, 0, len(present))
+	for _, v := range present {
+		number, err := strconv.ParseFloat(v, 64)
+		if err != nil {
+			col.Categorical = true
+			break
+		}
+		numbers = append(numbers, number)
+	}
+
+	if col.Categorical {
+		counts := make(map[string]int)
+		for _, v := range present {
+			counts[v]++
+		}
+		col.Categories = make([]string, 0, len(counts))
+		for category := range counts {
+			col.Categories = append(col.Categories, category)
+		}
+		sort.Strings(col.Categories)
+		for _, category := range col.Categories {
+			if counts[category] > counts[col.FillCategory] || col.FillCategory == "" {
+				col.FillCategory = category
+			}
+		}
+		if tp.Missing == MissingZero {
+			col.FillCategory = ""
+		}
+		return col, nil
+	}
+
+	col.Min, col.Max = numbers[0], numbers[0]
+	sum := 0.0
+	for _, v := range numbers {
+		sum += v
+		col.Min = math.Min(col.Min, v)
+		col.Max = math.Max(col.Max, v)
+	}
+	col.Mean = sum / float64(len(numbers))
+	variance := 0.0
+	for _, v := range numbers {
+		variance += (v - col.Mean) * (v - col.Mean)
+	}
+	col.Std = math.Sqrt(variance / float64(len(numbers)))
+	if col.Std == 0 {
+		col.Std = 1
+	}
+	switch tp.Missing {
+	case MissingMean:
+		col.Fill = col.Mean
+	case MissingMedian:
+		col.Fill = median(numbers)
+	case MissingMostFrequent:
+		col.Fill = mostFrequent(numbers)
+	}
+	return col, nil
+}
+
+// InputSize - number of values in input tensor
+func (tp *TabularPreprocessor) InputSize() int {
+	size := 0
+	for i := range tp.Features {
+		size += tp.Features[i].width()
+	}
+	return size
+}
+
+// TargetSize - number of values in target tensor
+func (tp *TabularPreprocessor) TargetSize() int {
+	size := 0
+	for i := range tp.Targets {
+		size += tp.Targets[i].width()
+	}
+	return size
+}
+
+// FeatureNames - names of values in input tensor. One-hot encoded values are named "<column>=<category>"
+func (tp *TabularPreprocessor) FeatureNames() []string {
+	return expandedNames(tp.Features)
+}
+
+// TargetNames - names of values in target tensor. One-hot encoded values are named "<column>=<category>"
+func (tp *TabularPreprocessor) TargetNames() []string {
+	return expandedNames(tp.Targets)
+}
+
+// Classes - categories of single categorical target (nil otherwise). Index of category is index of class
+func (tp *TabularPreprocessor) Classes() []string {
+	if len(tp.Targets) != 1 || !tp.Targets[0].Categorical {
+		return nil
+	}
+	return tp.Targets[0].Categories
+}
+
+// Transform - converts row to input tensor (InputSize x 1 x 1) and target tensor (TargetSize x 1 x 1). Target is nil if header has no target columns (e.g. at inference)
+func (tp *TabularPreprocessor) Transform(header, row []string) (*tensor.Tensor, *tensor.Tensor, error) {
+	header = columnNames(header, [][]string{row})
+	record := make(map[string]string, len(header))
+	for i, name := range header {
+		if i < len(row) {
+			record[strings.TrimSpace(name)] = row[i]
+		}
+	}
+	input, err := tp.TransformRecord(record)
+	if err != nil {
+		return nil, nil, err
+	}
+	if _, ok := record[tp.Targets[0].Name]; !ok {
+		return input, nil, nil
+	}
+	target := tensor.NewTensor(tp.TargetSize(), 1, 1)
+	offset := 0
+	for i := range tp.Targets {
+		err = tp.encode(&tp.Targets[i], record, target.Data[offset:], false)
+		if err != nil {
+			return nil, nil, err
+		}
+		offset += tp.Targets[i].width()
+	}
+	return input, target, nil
+}
+
+// TransformRecord - converts values of feature columns (name of column => raw value) to input tensor (InputSize x 1 x 1)
+func (tp *TabularPreprocessor) TransformRecord(record map[string]string) (*tensor.Tensor, error) {
+	input := tensor.NewTensor(tp.InputSize(), 1, 1)
+	offset := 0
+	for i := range tp.Features {
+		err := tp.encode(&tp.Features[i], record, input.Data[offset:], true)
+		if err != nil {
+			return nil, err
+		}
+		offset += tp.Features[i].width()
+	}
+	return input, nil
+}
+
+// encode - writes encoded value of column to dst
+func (tp *TabularPreprocessor) encode(col *TabularColumn, record map[string]string, dst []float64, feature bool) error {
+	raw, ok := record[col.Name]
+	if !ok {
+		return fmt.Errorf("No value for column '%s'", col.Name)
+	}
+	missing := tp.isMissing(raw)
+	if missing && (!feature || tp.Missing == MissingError || tp.Missing == MissingDrop) {
+		return fmt.Errorf("Column '%s' has missing value", col.Name)
+	}
+	raw = strings.TrimSpace(raw)
+
+	if col.Categorical {
+		if missing {
+			raw = col.FillCategory
+		}
+		idx := sort.SearchStrings(col.Categories, raw)
+		if idx < len(col.Categories) && col.Categories[idx] == raw {
+			dst[idx] = 1.0
+		} else if !feature {
+			return fmt.Errorf("Unknown category '%s' of column '%s'", raw, col.Name)
+		}
+		return nil
+	}
+
+	value := col.Fill
+	if !missing {
+		var err error
+		value, err = strconv.ParseFloat(raw, 64)
+		if err != nil {
+			return fmt.Errorf("Column '%s' has non-numeric value '%s'", col.Name, raw)
+		}
+	}
+	if feature {
+		switch tp.Scaling {
+		case ScaleStandard:
+			value = (value - col.Mean) / col.Std
+		case ScaleMinMax:
+			if col.Max > col.Min {
+				value = (value - col.Min) / (col.Max - col.Min)
+			} else {
+				value = 0
+			}
+		}
+	}
+	dst[0] = value
+	return nil
+}
+
+// Apply - converts rows to dataset. Rows with missing values are skipped for MissingDrop
+func (tp *TabularPreprocessor) Apply(header []string, rows [][]string) (*TabularDataset, error) {
+	if tp.Missing == MissingDrop {
+		header = columnNames(header, rows)
+		used := make([]string, 0, len(tp.Features)+len(tp.Targets))
+		for _, cols := range [][]TabularColumn{tp.Features, tp.Targets} {
+			for i := range cols {
+				if contains(header, cols[i].Name) {
+					used = append(used, cols[i].Name)
+				}
+			}
+		}
+		indices, err := columnIndices(header, used)
+		if err != nil {
+			return nil, err
+		}
+		rows = tp.completeRows(rows, indices)
+	}
+	ds := &TabularDataset{
+		Inputs:       make([]*tensor.Tensor, len(rows)),
+		Targets:      make([]*tensor.Tensor, len(rows)),
+		Preprocessor: tp,
+	}
+	for i, row := range rows {
+		input, target, err := tp.Transform(header, row)
+		if err != nil {
+			return nil, fmt.Errorf("Row #%d: %w", i, err)
+		}
+		if target == nil {
+			return nil, errors.New("no target columns in data")
+		}
+		ds.Inputs[i] = input
+		ds.Targets[i] = target
+	}
+	return ds, nil
+}
+
+// Load - reads CSV/TSV file and applies preprocessing to it (e.g. for test data)
+func (tp *TabularPreprocessor) Load(fname string) (*TabularDataset, error) {
+	header, rows, err := ReadCSVFile(fname, tp.Comma, tp.NoHeader)
+	if err != nil {
+		return nil, err
+	}
+	return tp.Apply(header, rows)
+}
+
+// isMissing - checks if value is considered as missing
+func (tp *TabularPreprocessor) isMissing(v string) bool {
+	v = strings.TrimSpace(v)
+	for _, m := range tp.MissingValues {
+		if strings.EqualFold(v, m) {
+			return true
+		}
+	}
+	return false
+}
+
+// completeRows - returns rows which have no missing values in given columns
+func (tp *TabularPreprocessor) completeRows(rows [][]string, indices []int) [][]string {
+	ret := make([][]string, 0, len(rows))
+	for _, row := range rows {
+		complete := true
+		for _, idx := range indices {
+			if idx >= len(row) || tp.isMissing(row[idx]) {
+				complete = false
+				break
+			}
+		}
+		if complete {
+			ret = append(ret, row)
+		}
+	}
+	return ret
+}
+
+// TabularDataset - tabular data converted to tensors. Inputs have size InputSize x 1 x 1, so they can be passed to fully connected layer directly
+type TabularDataset struct {
+	Inputs       []*tensor.Tensor
+	Targets      []*tensor.Tensor
+	Preprocessor *TabularPreprocessor
+}
+
+// LoadTabular - reads CSV/TSV file, fits preprocessing on it and returns dataset. Use Preprocessor.Load for validation and test files
+func LoadTabular(fname string, cfg TabularConfig) (*TabularDataset, error) {
+	header, rows, err := ReadCSVFile(fname, cfg.Comma, cfg.NoHeader)
+	if err != nil {
+		return nil, err
+	}
+	tp, err := FitTabular(header, rows, cfg)
+	if err != nil {
+		return nil, err
+	}
+	return tp.Apply(header, rows)
+}
+
+// Len - number of samples
+func (ds *TabularDataset) Len() int {
+	return len(ds.Inputs)
+}
+
+// Get - returns input and target of i-th sample
+func (ds *TabularDataset) Get(i int) (*tensor.Tensor, *tensor.Tensor, error) {
+	if i < 0 || i >= len(ds.Inputs) {
+		return nil, nil, fmt.Errorf("Sample index %d is out of range [0, %d)", i, len(ds.Inputs))
+	}
+	return ds.Inputs[i], ds.Targets[i], nil
+}
+
+// AttachPreprocessor - stores preprocessing in the net, so it is exported with the net (see WholeNetOf.ExportToFile)
+func AttachPreprocessor[T tensor.Float](net *cnns.WholeNetOf[T], tp *TabularPreprocessor) error {
+	data, err := json.Marshal(tp)
+	if err != nil {
+		return err
+	}
+	net.Preprocessing = data
+	return nil
+}
+
+// NetPreprocessor - returns preprocessing stored in the net (e.g. after WholeNetOf.ImportFromFile)
+func NetPreprocessor[T tensor.Float](net *cnns.WholeNetOf[T]) (*TabularPreprocessor, error) {
+	if len(net.Preprocessing) == 0 {
+		return nil, errors.New("net has no preprocessing")
+	}
+	tp := &TabularPreprocessor{}
+	err := json.Unmarshal(net.Preprocessing, tp)
+	if err != nil {
+		return nil, err
+	}
+	return tp, nil
+}
+
+// columnNames - returns header or "0", "1", ... if there is no header
+func columnNames(header []string, rows [][]string) []string {
+	if header != nil {
+		return header
+	}
+	n := 0
+	for _, row := range rows {
+		if len(row) > n {
+			n = len(row)
+		}
+	}
+	return indexClasses(n)
+}
+
+// columnIndices - returns positions of columns in header
+func columnIndices(header, names []string) ([]int, error) {
+	ret := make([]int, len(names))
+	for i, name := range names {
+		ret[i] = -1
+		for j, h := range header {
+			if strings.TrimSpace(h) == name {
+				ret[i] = j
+				break
+			}
+		}
+		if ret[i] < 0 {
+			return nil, fmt.Errorf("No column '%s'", name)
+		}
+	}
+	return ret, nil
+}
+
+// columnValues - returns values of column
+func columnValues(rows [][]string, idx int) []string {
+	ret := make([]string, len(rows))
+	for i, row := range rows {
+		if idx < len(row) {
+			ret[i] = row[idx]
+		}
+	}
+	return ret
+}
+
+// expandedNames - names of encoded values of columns
+func expandedNames(cols []TabularColumn) []string {
+	var ret []string
+	for i := range cols {
+		if !cols[i].Categorical {
+			ret = append(ret, cols[i].Name)
+			continue
+		}
+		for _, category := range cols[i].Categories {
+			ret = append(ret, cols[i].Name+"="+category)
+		}
+	}
+	return ret
+}
+
+// contains - checks if slice contains value
+func contains(values []string, v string) bool {
+	for _, s := range values {
+		if s == v {
+			return true
+		}
+	}
+	return false
+}
+
+// median - median of values
+func median(values []float64) float64 {
+	sorted := append([]float64(nil), values...)
+	sort.Float64s(sorted)
+	n := len(sorted)
+	if n%2 == 1 {
+		return sorted[n/2]
+	}
+	return (sorted[n/2-1] + sorted[n/2]) / 2
+}
+
+// mostFrequent - the most frequent value (the smallest one if there are several)
+func mostFrequent(values []float64) float64 {
+	counts := make(map[float64]int)
+	for _, v := range values {
+		counts[v]++
+	}
+	best := math.Inf(1)
+	for v, c := range counts {
+		if c > counts[best] || (c == counts[best] && v < best) {
+			best = v
+		}
+	}
+	return best
+}
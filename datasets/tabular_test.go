@@ -0,0 +1,135 @@
+package datasets
+
+import (
+	"math"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/LdDl/cnns"
+	"github.com/LdDl/cnns/tensor"
+)
+
+var _ cnns.Dataset = (*TabularDataset)(nil)
+
+func TestLoadTabular(t *testing.T) {
+	dir := t.TempDir()
+	fname := filepath.Join(dir, "train.csv")
+	content := "id,age,color,score,label\n" +
+		"1,10,red,1.5,yes\n" +
+		"2,20,green,NA,no\n" +
+		"3,,red,2.5,yes\n" +
+		"4,30,blue,3.5,no\n"
+	if err := os.WriteFile(fname, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+	ds, err := LoadTabular(fname, TabularConfig{
+		Targets: []string{"label"},
+		Ignore:  []string{"id"},
+		Missing: MissingMean,
+		Scaling: ScaleMinMax,
+	})
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	tp := ds.Preprocessor
+	if ds.Len() != 4 || tp.InputSize() != 5 || tp.TargetSize() != 2 {
+		t.Errorf("Expected 4 samples with 5 inputs and 2 targets. Got: %d, %d, %d", ds.Len(), tp.InputSize(), tp.TargetSize())
+		return
+	}
+	names := tp.FeatureNames()
+	if names[1] != "color=blue" || names[4] != "score" {
+		t.Errorf("Wrong feature names: %v", names)
+	}
+	if classes := tp.Classes(); len(classes) != 2 || classes[0] != "no" {
+		t.Errorf("Classes should be [no yes], but got %v", classes)
+	}
+	// age is replaced with mean 20, score is replaced with mean 2.5. Min-max scaling: age in [10, 30], score in [1.5, 3.5]
+	expected := [][]float64{
+		{0, 0, 0, 1, 0},
+		{0.5, 0, 1, 0, 0.5},
+		{0.5, 0, 0, 1, 0.5},
+		{1, 1, 0, 0, 1},
+	}
+	for i := range expected {
+		input, target, _ := ds.Get(i)
+		for j := range expected[i] {
+			if math.Abs(input.Data[j]-expected[i][j]) > 1e-9 {
+				t.Errorf("Input #%d[%d] is wrong. Expected value: %f. Got: %f", i, j, expected[i][j], input.Data[j])
+			}
+		}
+		if target.Data[0]+target.Data[1] != 1.0 {
+			t.Errorf("Target #%d should be one-hot vector, but got %v", i, target.Data)
+		}
+	}
+
+	// Preprocessing is saved with the net and applied at inference
+	net := cnns.WholeNet{}
+	net.Layers = append(net.Layers, cnns.NewFullyConnectedLayer(&tensor.TDsize{X: tp.InputSize(), Y: 1, Z: 1}, tp.TargetSize()))
+	if err := AttachPreprocessor(&net, tp); err != nil {
+		t.Error(err)
+		return
+	}
+	netName := filepath.Join(dir, "net.json")
+	if err := net.ExportToFile(netName); err != nil {
+		t.Error(err)
+		return
+	}
+	imported := cnns.WholeNet{}
+	if err := imported.ImportFromFile(netName, false); err != nil {
+		t.Error(err)
+		return
+	}
+	restored, err := NetPreprocessor(&imported)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	input, err := restored.TransformRecord(map[string]string{"age": "25", "color": "purple", "score": "?"})
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	for j, v := range []float64{0.75, 0, 0, 0, 0.5} {
+		if math.Abs(input.Data[j]-v) > 1e-9 {
+			t.Errorf("Input[%d] after import is wrong. Expected value: %f. Got: %f", j, v, input.Data[j])
+		}
+	}
+}
+
+func TestLoadTabularTSV(t *testing.T) {
+	dir := t.TempDir()
+	train := filepath.Join(dir, "train.tsv")
+	if err := os.WriteFile(train, []byte("1\t2\t0.5\n3\tNA\t1.5\n5\t6\t2.5\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	ds, err := LoadTabular(train, TabularConfig{
+		NoHeader: true,
+		Targets:  []string{"2"},
+		Missing:  MissingDrop,
+		Scaling:  ScaleStandard,
+	})
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	if ds.Len() != 2 {
+		t.Errorf("Row with missing value should be dropped. Expected value: 2. Got: %d", ds.Len())
+		return
+	}
+	// Column "0": mean 3, std 2. Targets are not scaled
+	input, target, _ := ds.Get(1)
+	if input.Data[0] != 1.0 || input.Data[1] != 1.0 || target.Data[0] != 2.5 {
+		t.Errorf("Wrong sample: %v -> %v", input.Data, target.Data)
+	}
+
+	test := filepath.Join(dir, "test.tsv")
+	if err := os.WriteFile(test, []byte("3\t4\t1\nx\t4\t1\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	_, err = ds.Preprocessor.Load(test)
+	if err == nil {
+		t.Errorf("Non-numeric value of numeric column should produce error")
+	}
+}
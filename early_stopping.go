@@ -0,0 +1,99 @@
+package cnns
+
+import (
+	"fmt"
+	"strings"
+)
+
+// MonitorMode - direction of improvement of monitored metric
+type MonitorMode int
+
+const (
+	// MonitorAuto - MonitorMax for accuracy-like metrics (name contains "acc", "precision", "recall", "f1", "r2" or "explained_variance"), MonitorMin otherwise
+	MonitorAuto = MonitorMode(iota)
+	// MonitorMin - lower is better (losses, errors)
+	MonitorMin
+	// MonitorMax - higher is better (accuracy and etc.)
+	MonitorMax
+)
+
+// resolve - returns MonitorMin or MonitorMax for given metric
+func (mm MonitorMode) resolve(metric string) MonitorMode {
+	if mm != MonitorAuto {
+		return mm
+	}
+	for _, s := range []string{"acc", "precision", "recall", "f1", "r2", "explained_variance"} {
+		if strings.Contains(metric, s) {
+			return MonitorMax
+		}
+	}
+	return MonitorMin
+}
+
+// isBetter - checks if value is better than best by more than minDelta
+func (mm MonitorMode) isBetter(value, best, minDelta float64) bool {
+	if mm == MonitorMax {
+		return value > best+minDelta
+	}
+	return value < best-minDelta
+}
+
+// EarlyStopping - stops training when monitored metric has stopped improving. See TrainConfigOf
+/*
+	Monitor - name of metric in epoch metrics ("val_loss" if not set);
+	Patience - number of epochs without improvement after which training is stopped;
+	MinDelta - minimum change of metric which is considered as improvement;
+	Mode - see MonitorMode;
+	RestoreBest - restore weights of best epoch when training is done.
+
+	Best, BestEpoch, Wait, StoppedEpoch - state of early stopping, updated during training (BestEpoch is -1 if no epoch has been evaluated yet, StoppedEpoch is -1 if training has not been stopped).
+*/
+type EarlyStopping struct {
+	Monitor     string      `json:"Monitor"`
+	Patience    int         `json:"Patience"`
+	MinDelta    float64     `json:"MinDelta"`
+	Mode        MonitorMode `json:"Mode"`
+	RestoreBest bool        `json:"RestoreBest"`
+
+	Best         float64 `json:"Best"`
+	BestEpoch    int     `json:"BestEpoch"`
+	Wait         int     `json:"Wait"`
+	StoppedEpoch int     `json:"StoppedEpoch"`
+}
+
+// monitor - returns name of monitored metric
+func (es *EarlyStopping) monitor() string {
+	if es.Monitor == "" {
+		return "val_loss"
+	}
+	return es.Monitor
+}
+
+// reset - resets state before training
+func (es *EarlyStopping) reset() {
+	es.Best = 0
+	es.BestEpoch = -1
+	es.Wait = 0
+	es.StoppedEpoch = -1
+}
+
+// update - updates state with metrics of epoch. Returns true if metric has improved and true if training should be stopped
+func (es *EarlyStopping) update(epoch int, metrics map[string]float64) (bool, bool, error) {
+	name := es.monitor()
+	value, ok := metrics[name]
+	if !ok {
+		return false, false, fmt.Errorf("Early stopping: metric '%s' is not available. Available metrics: %s", name, strings.Join(sortedKeys(metrics), ", "))
+	}
+	if es.BestEpoch < 0 || es.Mode.resolve(name).isBetter(value, es.Best, es.MinDelta) {
+		es.Best = value
+		es.BestEpoch = epoch
+		es.Wait = 0
+		return true, false, nil
+	}
+	es.Wait++
+	if es.Wait >= es.Patience {
+		es.StoppedEpoch = epoch
+		return false, true, nil
+	}
+	return false, false, nil
+}
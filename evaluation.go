@@ -0,0 +1,137 @@
+package cnns
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"strings"
+
+	"github.com/LdDl/cnns/metrics"
+	"github.com/LdDl/cnns/tensor"
+)
+
+// EvaluationReport - result of evaluation of net on dataset
+/*
+	Samples - number of samples;
+	Metrics - mean loss ("loss", MSE) and values of requested metrics by their names.
+*/
+type EvaluationReport struct {
+	Samples int                `json:"Samples"`
+	Metrics map[string]float64 `json:"Metrics"`
+}
+
+// MetricComparison - difference of metric between two evaluations
+/*
+	Delta - Current - Baseline;
+	Improved - true if Current is better than Baseline (see MonitorAuto for direction of improvement).
+*/
+type MetricComparison struct {
+	Name     string  `json:"Name"`
+	Baseline float64 `json:"Baseline"`
+	Current  float64 `json:"Current"`
+	Delta    float64 `json:"Delta"`
+	Improved bool    `json:"Improved"`
+}
+
+// Evaluate - runs inference on dataset (weights are not updated) and evaluates mean loss and given metrics. See package metrics
+func (wh *WholeNetOf[T]) Evaluate(dataset DatasetOf[T], metricsList ...metrics.Metric) (*EvaluationReport, error) {
+	if len(wh.Layers) == 0 {
+		return nil, errors.New("network has no layers")
+	}
+	if dataset.Len() == 0 {
+		return nil, errors.New("dataset is empty")
+	}
+	values, err := wh.evaluate(dataset, LossMSE{}, metricsList)
+	if err != nil {
+		return nil, err
+	}
+	return &EvaluationReport{
+		Samples: dataset.Len(),
+		Metrics: values,
+	}, nil
+}
+
+// evaluate - mean loss ("loss") and given metrics of net over dataset
+func (wh *WholeNetOf[T]) evaluate(dataset DatasetOf[T], loss Loss, metricsList []metrics.Metric) (map[string]float64, error) {
+	total := 0.0
+	var outputs, targets [][]float64
+	for i := 0; i < dataset.Len(); i++ {
+		input, target, err := dataset.Get(i)
+		if err != nil {
+			return nil, err
+		}
+		wh.FeedForward(input)
+		sl, err := sampleLoss(loss, wh.GetOutput(), target)
+		if err != nil {
+			return nil, err
+		}
+		total += sl
+		if len(metricsList) != 0 {
+			outputs = append(outputs, tensor.ConvertData[float64](wh.GetOutput().Data))
+			targets = append(targets, tensor.ConvertData[float64](target.Data))
+		}
+	}
+	ret := map[string]float64{"loss": total / float64(dataset.Len())}
+	for _, m := range metricsList {
+		value, err := m.Compute(outputs, targets)
+		if err != nil {
+			return nil, fmt.Errorf("Metric '%s': %w", m.Name(), err)
+		}
+		ret[m.Name()] = value
+	}
+	return ret, nil
+}
+
+// Compare - compares metrics with baseline report. Only metrics present in both reports are compared, sorted by name.
+func (er *EvaluationReport) Compare(baseline *EvaluationReport) []MetricComparison {
+	var ret []MetricComparison
+	for _, name := range sortedKeys(er.Metrics) {
+		base, ok := baseline.Metrics[name]
+		if !ok {
+			continue
+		}
+		current := er.Metrics[name]
+		ret = append(ret, MetricComparison{
+			Name:     name,
+			Baseline: base,
+			Current:  current,
+			Delta:    current - base,
+			Improved: MonitorAuto.resolve(name).isBetter(current, base, 0),
+		})
+	}
+	return ret
+}
+
+// String - pretty print for report
+func (er *EvaluationReport) String() string {
+	var sb strings.Builder
+	sb.WriteString(fmt.Sprintf("Samples: %d\n", er.Samples))
+	for _, name := range sortedKeys(er.Metrics) {
+		sb.WriteString(fmt.Sprintf("%s: %.6f\n", name, er.Metrics[name]))
+	}
+	return sb.String()
+}
+
+// ExportToFile - saves report to JSON file
+func (er *EvaluationReport) ExportToFile(fname string) error {
+	fileContent, err := json.MarshalIndent(er, "", "    ")
+	if err != nil {
+		return err
+	}
+	return ioutil.WriteFile(fname, fileContent, 0644)
+}
+
+// ImportEvaluationReport - loads report from JSON file
+func ImportEvaluationReport(fname string) (*EvaluationReport, error) {
+	fileContent, err := ioutil.ReadFile(fname)
+	if err != nil {
+		return nil, err
+	}
+	er := &EvaluationReport{}
+	err = json.Unmarshal(fileContent, er)
+	if err != nil {
+		return nil, err
+	}
+	return er, nil
+}
@@ -0,0 +1,75 @@
+package cnns
+
+import (
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/LdDl/cnns/metrics"
+)
+
+func TestEvaluate(t *testing.T) {
+	inputs, desired := xorData()
+	net := newXORNet(9)
+	dataset, err := NewInMemoryDataset(inputs, desired)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	weights := append([]float64{}, net.Layers[0].(*FullyConnectedLayer).Weights.Data...)
+	before, err := net.Evaluate(dataset, metrics.RMSE{}, metrics.MAE{})
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	for i, w := range net.Layers[0].(*FullyConnectedLayer).Weights.Data {
+		if w != weights[i] {
+			t.Errorf("Evaluate should not update weights")
+			break
+		}
+	}
+	if before.Samples != 4 || len(before.Metrics) != 3 {
+		t.Errorf("Report should contain %d samples and %d metrics, but got %d and %d", 4, 3, before.Samples, len(before.Metrics))
+	}
+	mse := 0.0
+	for i := range inputs {
+		net.FeedForward(inputs[i])
+		diff := net.GetOutput().Data[0] - desired[i].Data[0]
+		mse += diff * diff
+	}
+	mse /= float64(len(inputs))
+	if before.Metrics["loss"] != mse {
+		t.Errorf("Loss is wrong. Expected value: %f. Got: %f", mse, before.Metrics["loss"])
+	}
+
+	fname := filepath.Join(t.TempDir(), "report.json")
+	err = before.ExportToFile(fname)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	imported, err := ImportEvaluationReport(fname)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	if !reflect.DeepEqual(imported, before) {
+		t.Errorf("Imported report is not equal to exported one. Expected: %+v. Got: %+v", before, imported)
+	}
+
+	_, _, err = net.Train(inputs, desired, TrainConfig{Epochs: 200, Optimizer: &LearningParams{LearningRate: 0.1, Momentum: 0.6}})
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	after, err := net.Evaluate(dataset, metrics.RMSE{}, metrics.MAE{})
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	for _, c := range after.Compare(imported) {
+		if c.Name == "loss" && !c.Improved {
+			t.Errorf("Loss should be improved after training: %+v", c)
+		}
+	}
+}
@@ -42,7 +42,14 @@ func CheckXOR() {
 
 	// Start traing process
 	numOfEpochs := 1
-	trainErr, testErr, err := net.Train(inputs, desired, inputsTests, desiredTests, numOfEpochs)
+	trainErr, testErr, err := net.Train(inputs, desired, cnns.TrainConfig{
+		Epochs:            numOfEpochs,
+		Shuffle:           true,
+		Seed:              time.Now().UnixNano(),
+		ValidationInputs:  inputsTests,
+		ValidationTargets: desiredTests,
+		Callbacks:         []cnns.Callback{&cnns.ProgressLogger{}},
+	})
 	if err != nil {
 		log.Fatalln(err)
 	}
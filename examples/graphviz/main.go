@@ -22,4 +22,16 @@ func main() {
 
 	graphvizText := net.GetGraphvizText()
 	fmt.Println(graphvizText)
+
+	// Layer-level diagram for convolutional net: every layer is drawn as record with its sizes and number of parameters
+	var convNet cnns.WholeNet
+	conv := cnns.NewConvLayer(1, 5, 4, tensor.TDsize{X: 28, Y: 28, Z: 1})
+	relu := cnns.NewReLULayer(conv.GetOutputSize())
+	maxpool := cnns.NewMaxPoolingLayer(2, 2, relu.GetOutputSize())
+	fullyconnected := cnns.NewFullyConnectedLayer(maxpool.GetOutputSize(), 512)
+	output := cnns.NewFullyConnectedLayer(fullyconnected.GetOutputSize(), 10)
+	convNet.Layers = append(convNet.Layers, conv, relu, maxpool, fullyconnected, output)
+
+	graphvizText = convNet.GetGraphvizTextWith(cnns.GraphvizOptions{Mode: cnns.GraphvizLayers})
+	fmt.Println(graphvizText)
 }
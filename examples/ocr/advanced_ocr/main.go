@@ -2,18 +2,16 @@ package main
 
 import (
 	"fmt"
-	"image/color"
-	"io/ioutil"
 	"log"
-	"math"
 	"math/rand"
 	"strconv"
 	"time"
 
 	"github.com/LdDl/cnns"
+	"github.com/LdDl/cnns/augment"
+	"github.com/LdDl/cnns/datasets"
+	"github.com/LdDl/cnns/metrics"
 	"github.com/LdDl/cnns/tensor"
-	"github.com/LdDl/cnns/utils/u"
-	"github.com/nfnt/resize"
 )
 
 var (
@@ -42,11 +40,13 @@ var (
 		20: "X",
 		21: "Y",
 	}
-	trainWidth          = 28
-	trainHeight         = 28
-	trainDepth          = 1
-	adjustAmountOfFiles = 2000 // see readMatsTrain func
-	doAdjust            = true // see readMatsTrain func
+	trainWidth  = 28
+	trainHeight = 28
+	trainDepth  = 1
+	// Repeat random images of class until it has this amount of images (needed if you have a few amount of images for some label),
+	// but, for a good training you have to provide a lot of unique data (not randomly repeated)
+	adjustAmountOfFiles = 2000
+	doAdjust            = true
 	trainImagesPath     = "../../datasets/ocr_symbols/"
 	testImagesPath      = "../../datasets/ocr_symbols_test/"
 )
@@ -81,366 +81,89 @@ func CheckOCR() {
 	net.Layers = append(net.Layers, fullyconnected2)
 	net.Layers = append(net.Layers, fullyconnected3)
 
-	// Paste your path for training data
-	trainFiles, err := readFileNames(trainImagesPath)
-	if err != nil {
-		log.Panicln(err)
+	// Class index is equal to name of subdirectory
+	classes := make([]string, len(chars))
+	for i := range classes {
+		classes[i] = strconv.Itoa(i)
+	}
+	cfg := datasets.ImageFolderConfig{
+		Width:   trainWidth,
+		Height:  trainHeight,
+		Classes: classes,
+		Seed:    time.Now().UnixNano(),
+		Cache:   true,
 	}
 
 	// Paste your path for test data
-	testFiles, err := readFileNames(testImagesPath)
+	testSet, err := datasets.NewImageFolder(testImagesPath, cfg)
 	if err != nil {
 		log.Panicln(err)
 	}
+	log.Println("Test data total:", testSet.Len())
 
-	trainMats, err := readMatsTrain(&trainFiles, adjustAmountOfFiles, doAdjust)
-	if err != nil {
-		log.Panicln(err)
+	// Paste your path for training data
+	if doAdjust {
+		cfg.MinPerClass = adjustAmountOfFiles
 	}
-
-	testMats, err := readMatsTests(&testFiles)
+	trainSet, err := datasets.NewImageFolder(trainImagesPath, cfg)
 	if err != nil {
 		log.Panicln(err)
 	}
-
-	train(&net, trainMats)
-	testTrained(&net, testMats)
-
-}
-
-func formTrainDataOCR() ([]*tensor.Tensor, []*tensor.Tensor) {
-	fileNames, err := readFileNames(trainImagesPath)
+	log.Println("Train data total:", trainSet.Len())
+
+	// Oversampled images are repeated, so every copy is distorted differently to reduce overfitting. Background of symbols is white
+	augmented := augment.NewAugmentedDataset(trainSet, time.Now().UnixNano(),
+		&augment.Rotation{MaxDegrees: 10, Fill: 1},
+		&augment.Translation{MaxX: 2, MaxY: 2, Fill: 1},
+		&augment.Scaling{Min: 0.9, Max: 1.1, Fill: 1},
+		&augment.RandomApply{P: 0.3, Transform: &augment.ElasticDistortion{Alpha: 1.5, Sigma: 3, Fill: 1}},
+		&augment.GaussianNoise{Std: 0.02, Clip: true},
+	)
+
+	_, _, err = net.TrainOn(augmented, cnns.TrainConfig{
+		Epochs:            15,
+		Shuffle:           true,
+		Seed:              time.Now().UnixNano(),
+		Workers:           2,
+		ValidationData:    testSet,
+		ValidationMetrics: []metrics.Metric{metrics.Accuracy{}},
+		Callbacks:         []cnns.Callback{&cnns.ProgressLogger{}},
+	})
 	if err != nil {
 		log.Panicln(err)
 	}
-	numExamples := 0
-	for _, v := range fileNames {
-		numExamples += len(v)
-	}
-	var inputs []*tensor.Tensor
-	var desired []*tensor.Tensor
-	tensorFiles := make(map[string][]*tensor.Tensor)
-	for k, v := range fileNames {
-		if _, ok := tensorFiles[k]; !ok {
-			tensorFiles[k] = []*tensor.Tensor{}
-		}
-		for _, j := range v {
-			img, err := u.ReadImage(j)
-			if err != nil {
-				log.Panicln(err)
-			}
-			img = resize.Resize(uint(trainWidth), uint(trainHeight), img, resize.Bicubic)
-			tmpTensor := tensor.NewTensor(trainWidth, trainHeight, 1)
-			for i := 0; i < trainHeight; i++ {
-				for j := 0; j < trainWidth; j++ {
-					r, g, b, _ := img.At(j, i).RGBA()
-					lum := 0.299*float64(r) + 0.587*float64(g) + 0.114*float64(b)
-					pixel := color.Gray{uint8(lum / 256)}
-					tmpTensor.Set(j, i, 0, float64(pixel.Y)/255.0)
-				}
-			}
-			tensorFiles[k] = append(tensorFiles[k], tmpTensor)
-		}
-	}
-	if doAdjust {
-		for k, v := range tensorFiles {
-			length := len(tensorFiles[k])
-			i := 0
-			for i < adjustAmountOfFiles && len(tensorFiles[k]) < adjustAmountOfFiles {
-				tensorFiles[k] = append(tensorFiles[k], v[u.RandomInt(0, length)])
-				i++
-			}
-		}
-	}
 
-	for k, v := range tensorFiles {
-		intK, err := strconv.Atoi(k)
-		if err != nil {
-			log.Panicln(err)
-		}
-		for mat := range v {
-			tmpDesired := tensor.NewTensor(22, 1, 1)
-			var target [][][]float64
-			target = [][][]float64{[][]float64{[]float64{0.0, 0.0, 0.0, 0.0, 0.0, 0.0, 0.0, 0.0, 0.0, 0.0, 0.0, 0.0, 0.0, 0.0, 0.0, 0.0, 0.0, 0.0, 0.0, 0.0, 0.0, 0.0}}}
-			target[0][0][intK] = 1.0
-			tmpDesired.SetData3D(target)
-			tmpInput := tensor.NewTensor(trainWidth, trainHeight, trainDepth)
-			tmpInput.SetData3D(v[mat].GetData3D())
-			inputs = append(inputs, tmpInput)
-			desired = append(desired, tmpDesired)
-		}
-	}
-
-	log.Println("Train data total:", len(inputs))
-	return inputs, desired
-}
-
-func formTestDataOCR() ([]*tensor.Tensor, []*tensor.Tensor) {
-
-	fileNames, err := readFileNames(testImagesPath)
+	err = testTrained(&net, testSet)
 	if err != nil {
 		log.Panicln(err)
 	}
-	numExamples := 0
-	for _, v := range fileNames {
-		numExamples += len(v)
-	}
-
-	var inputs []*tensor.Tensor
-	var desired []*tensor.Tensor
-
-	tensorFiles := make(map[string][]*tensor.Tensor)
-	for k, v := range fileNames {
-		if _, ok := tensorFiles[k]; !ok {
-			tensorFiles[k] = []*tensor.Tensor{}
-		}
-		for _, j := range v {
-			img, err := u.ReadImage(j)
-			if err != nil {
-				log.Panicln(err)
-			}
-			img = resize.Resize(uint(trainWidth), uint(trainHeight), img, resize.Bicubic)
-			tmpTensor := tensor.NewTensor(trainWidth, trainHeight, 1)
-			for i := 0; i < trainHeight; i++ {
-				for j := 0; j < trainWidth; j++ {
-					r, g, b, _ := img.At(j, i).RGBA()
-					lum := 0.299*float64(r) + 0.587*float64(g) + 0.114*float64(b)
-					pixel := color.Gray{uint8(lum / 256)}
-					tmpTensor.Set(j, i, 0, float64(pixel.Y)/255.0)
-				}
-			}
-			tensorFiles[k] = append(tensorFiles[k], tmpTensor)
-		}
-	}
-
-	for k, v := range tensorFiles {
-		intK, err := strconv.Atoi(k)
-		if err != nil {
-			log.Panicln(err)
-		}
-		for mat := range v {
-			tmpDesired := tensor.NewTensor(22, 1, 1)
-			var target [][][]float64
-			target = [][][]float64{[][]float64{[]float64{0.0, 0.0, 0.0, 0.0, 0.0, 0.0, 0.0, 0.0, 0.0, 0.0, 0.0, 0.0, 0.0, 0.0, 0.0, 0.0, 0.0, 0.0, 0.0, 0.0, 0.0, 0.0}}}
-			target[0][0][intK] = 1.0
-			tmpDesired.SetData3D(target)
-			tmpInput := tensor.NewTensor(trainWidth, trainHeight, trainDepth)
-			tmpInput.SetData3D(v[mat].GetData3D())
-			inputs = append(inputs, tmpInput)
-			desired = append(desired, tmpDesired)
-		}
-	}
-
-	log.Println("Test data total:", len(inputs))
-	return inputs, desired
-}
-
-// readFileNames - get filenames in directory
-func readFileNames(dir string) (map[string][]string, error) {
-	var err error
-	charMap := make(map[string][]string)
-	dirs, err := ioutil.ReadDir(dir)
-	if err != nil {
-		return charMap, err
-	}
-	for _, f := range dirs {
-		chardir := dir + f.Name()
-		charMap[f.Name()] = []string{}
-		subDir, err := ioutil.ReadDir(chardir)
-		if err != nil {
-			return charMap, err
-		}
-		for _, subf := range subDir {
-			charDirImage := chardir + "/" + subf.Name()
-			charMap[f.Name()] = append(charMap[f.Name()], charDirImage)
-		}
-	}
-
-	return charMap, err
-}
-
-// train - train network
-func train(net *cnns.WholeNet, data map[string][]*tensor.Tensor) error {
-	st := time.Now()
-	var err error
-	rand.Seed(time.Now().UnixNano())
-	var trainers []Trainer
-	for k, v := range data {
-		intK, err := strconv.Atoi(k)
-		if err != nil {
-			return err
-		}
-		for mat := range v {
-			var temp Trainer
-			temp.Desired = tensor.NewTensor(22, 1, 1)
-			var target [][][]float64
-			target = [][][]float64{[][]float64{[]float64{0.0, 0.0, 0.0, 0.0, 0.0, 0.0, 0.0, 0.0, 0.0, 0.0, 0.0, 0.0, 0.0, 0.0, 0.0, 0.0, 0.0, 0.0, 0.0, 0.0, 0.0, 0.0}}}
-			target[0][0][intK] = 1.0
-			temp.Desired.SetData3D(target)
-			temp.Image = tensor.NewTensor(trainWidth, trainHeight, trainDepth)
-			temp.Image.SetData3D(v[mat].GetData3D())
-			temp.LabelStr = chars[intK]
-			temp.LabelInt = intK
-			trainers = append(trainers, temp)
-		}
-	}
-
-	// rand.Seed(time.Now().UTC().UnixNano())
-	trainers = SuffleTrainers(trainers)
-	lentrainer := len(trainers)
-	log.Println("Number of train data", lentrainer)
-
-	numEpochs := 15
-	for e := 0; e < numEpochs; e++ {
-		st := time.Now()
-		trainers = SuffleTrainers(trainers)
-		for _, t := range trainers {
-			// Feedforward
-			net.FeedForward(t.Image)
-			// Backward
-			err = net.Backpropagate(t.Desired)
-			if err != nil {
-				log.Printf("Backpropagate caused error: %s", err.Error())
-				return err
-			}
-		}
-		fmt.Printf("Epoch #%v in %v\n", e, time.Since(st))
-	}
-	log.Println("Elapsed to train:", time.Since(st), "Num of epochs:", numEpochs)
-	return err
 }
 
 // testTrained - test network
-func testTrained(net *cnns.WholeNet, data map[string][]*tensor.Tensor) error {
-	var err error
-	var testers []Trainer
-	for k, v := range data {
-		intK, err := strconv.Atoi(k)
+func testTrained(net *cnns.WholeNet, data cnns.Dataset) error {
+	outputs := make([][]float64, 0, data.Len())
+	targets := make([][]float64, 0, data.Len())
+	for i := 0; i < data.Len(); i++ {
+		input, target, err := data.Get(i)
 		if err != nil {
 			return err
 		}
-		for mat := range v {
-			var target [][][]float64
-			target = [][][]float64{[][]float64{[]float64{0.0, 0.0, 0.0, 0.0, 0.0, 0.0, 0.0, 0.0, 0.0, 0.0, 0.0, 0.0, 0.0, 0.0, 0.0, 0.0, 0.0, 0.0, 0.0, 0.0, 0.0, 0.0}}}
-			target[0][0][intK] = 1.0
-			var temp Trainer
-			temp.Desired = tensor.NewTensor(22, 1, 1)
-			temp.Desired.SetData3D(target)
-			temp.Image = tensor.NewTensor(trainWidth, trainHeight, trainDepth)
-			temp.LabelStr = chars[intK]
-			temp.LabelInt = intK
-			temp.Image.SetData3D(v[mat].GetData3D())
-			testers = append(testers, temp)
-		}
-	}
-	testers = SuffleTrainers(testers)
-	for _, t := range testers {
 		// Feedforward
-		net.FeedForward(t.Image)
-		max := -math.MaxFloat64 // net.Layers[len(net.Layers)-1].GetOutput().Data[0]
-		maxidx := -math.MaxInt64
-		for i, value := range net.Layers[len(net.Layers)-1].GetOutput().Data {
-			if value > max {
-				max = value
-				maxidx = i
-			}
-		}
-		log.Println(t.LabelStr, max, maxidx, chars[maxidx])
-		// t.Image.Print()
-		// net.PrintOutput()
-		// fmt.Println("Should be:")
-		// t.Desired.Print()
-	}
-	return err
-}
-
-// Trainer - struct for training. Contains Image, Desired output
-type Trainer struct {
-	Image    *tensor.Tensor
-	Desired  *tensor.Tensor
-	LabelStr string
-	LabelInt int
-}
-
-// SuffleTrainers - randomly shuffles a slice
-func SuffleTrainers(data []Trainer) []Trainer {
-	for i := range data {
-		j := rand.Intn(i + 1)
-		data[i], data[j] = data[j], data[i]
-	}
-	return data
-}
-
-// readMatsTrain - fill map[string][]tensor.Tensor with data (for training)
-// adjust - parameter to fill data with same amount of images for each label (needed if you have a few amount of images for some label)
-// but, for a good training you have to provide a lot of unique data (not randomly repeated)
-func readMatsTrain(data *map[string][]string, adjust int, doAdjust bool) (map[string][]*tensor.Tensor, error) {
-	var err error
-	var ret map[string][]*tensor.Tensor
-	ret = make(map[string][]*tensor.Tensor)
-	for k, v := range *data {
-		if _, ok := ret[k]; !ok {
-			ret[k] = []*tensor.Tensor{}
-		}
-		for _, j := range v {
-			img, err := u.ReadImage(j)
-			if err != nil {
-				return nil, err
-			}
-			img = resize.Resize(uint(trainWidth), uint(trainHeight), img, resize.Bicubic)
-			tmpTensor := tensor.NewTensor(trainWidth, trainHeight, 1)
-			for i := 0; i < trainHeight; i++ {
-				for j := 0; j < trainWidth; j++ {
-					r, g, b, _ := img.At(j, i).RGBA()
-					lum := 0.299*float64(r) + 0.587*float64(g) + 0.114*float64(b)
-					pixel := color.Gray{uint8(lum / 256)}
-					tmpTensor.Set(j, i, 0, float64(pixel.Y)/255.0)
-				}
-			}
-			ret[k] = append(ret[k], tmpTensor)
-		}
-	}
-
-	if doAdjust {
-		for k, v := range ret {
-			length := len(ret[k])
-			i := 0
-			for i < adjust && len(ret[k]) < adjust {
-				ret[k] = append(ret[k], v[u.RandomInt(0, length)])
-				i++
-			}
-		}
-	}
-
-	return ret, err
-}
-
-// readMatsTests - fill map[string][]tensor.Tensor with data (for testing)
-func readMatsTests(data *map[string][]string) (map[string][]*tensor.Tensor, error) {
-	var err error
-	var ret map[string][]*tensor.Tensor
-	ret = make(map[string][]*tensor.Tensor)
-	for k, v := range *data {
-		if _, ok := ret[k]; !ok {
-			ret[k] = []*tensor.Tensor{}
-		}
-		for _, j := range v {
-			img, err := u.ReadImage(j)
-			if err != nil {
-				return nil, err
-			}
-			img = resize.Resize(uint(trainWidth), uint(trainHeight), img, resize.Bicubic)
-			tmpTensor := tensor.NewTensor(trainWidth, trainHeight, 1)
-			for i := 0; i < trainHeight; i++ {
-				for j := 0; j < trainWidth; j++ {
-					r, g, b, _ := img.At(j, i).RGBA()
-					lum := 0.299*float64(r) + 0.587*float64(g) + 0.114*float64(b)
-					pixel := color.Gray{uint8(lum / 256)}
-					tmpTensor.Set(j, i, 0, float64(pixel.Y)/255.0)
-				}
-			}
-			ret[k] = append(ret[k], tmpTensor)
-		}
+		net.FeedForward(input)
+		output := net.GetOutput().Data
+		outputs = append(outputs, append([]float64{}, output...))
+		targets = append(targets, target.Data)
+		predicted := metrics.Argmax(output)
+		log.Println(chars[metrics.Argmax(target.Data)], output[predicted], predicted, chars[predicted])
+	}
+	labels := make([]string, len(chars))
+	for i := range labels {
+		labels[i] = chars[i]
+	}
+	report, err := metrics.NewClassificationReport(outputs, targets, labels)
+	if err != nil {
+		return err
 	}
-	return ret, err
+	fmt.Println(report)
+	return nil
 }
@@ -7,6 +7,7 @@ import (
 	"time"
 
 	"github.com/LdDl/cnns"
+	"github.com/LdDl/cnns/metrics"
 	"github.com/LdDl/cnns/tensor"
 
 	"github.com/LdDl/cnns/utils/u"
@@ -40,7 +41,22 @@ func CheckXTO() {
 
 	// Start traing process
 	numOfEpochs := 50
-	trainErr, testErr, err := net.Train(inputs, desired, inputsTests, desiredTests, numOfEpochs)
+	trainErr, testErr, err := net.Train(inputs, desired, cnns.TrainConfig{
+		Epochs:            numOfEpochs,
+		Shuffle:           true,
+		Seed:              time.Now().UnixNano(),
+		ValidationInputs:  inputsTests,
+		ValidationTargets: desiredTests,
+		ValidationMetrics: []metrics.Metric{metrics.Accuracy{}, metrics.F1{Average: metrics.AverageMacro}},
+		Callbacks:         []cnns.Callback{&cnns.ProgressLogger{}},
+		// Stop when validation loss has not improved for 5 epochs and keep best weights
+		EarlyStopping: &cnns.EarlyStopping{
+			Monitor:     "val_loss",
+			Patience:    5,
+			MinDelta:    1e-5,
+			RestoreBest: true,
+		},
+	})
 	if err != nil {
 		log.Fatalln(err)
 	}
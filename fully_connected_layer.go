@@ -7,7 +7,7 @@ import (
 	"github.com/LdDl/cnns/tensor"
 )
 
-// FullyConnectedLayer is simple layer structure (so this layer can be used for simple neural networks like XOR problem)
+// FullyConnectedLayerOf is simple layer structure (so this layer can be used for simple neural networks like XOR problem)
 /*
 	In - O{j}, activated output from previous layer for j-th neuron
 	Out - O{k}, activated output from current layer for k-th node
@@ -16,27 +16,41 @@ import (
 	NextDeltaWeightSum - SUM(δ{k}*w{j,k}), summation component for evaluating δ{j} for previous layer for j-th neuron
 	Weights - w{j,k}, weight from j-th node of previous layer to k-th node of current layer
 	PreviousIterationWeights - Δw{j, k}, delta-weight value for calibrating weight w{j,k}
+	WeightsMask - 0 for pruned weights and 1 for others (nil if layer has not been pruned)
+	WeightsGradients - SUM(δ{k}*O{j}) over mini-batch (nil until mini-batch training is used)
+	GradientsIn - copy of In of last backpropagated sample, so gradients of weights δ{k}*O{j} survive later feed forward passes, e.g. validation (nil until first backpropagation). See WeightGradients
 */
-type FullyConnectedLayer struct {
-	In                       *tensor.Tensor
-	Out                      *tensor.Tensor
-	NextDeltaWeightSum       *tensor.Tensor
-	Weights                  *tensor.Tensor
-	PreviousIterationWeights *tensor.Tensor
+type FullyConnectedLayerOf[T tensor.Float] struct {
+	In                       *tensor.TensorOf[T]
+	Out                      *tensor.TensorOf[T]
+	NextDeltaWeightSum       *tensor.TensorOf[T]
+	Weights                  *tensor.TensorOf[T]
+	PreviousIterationWeights *tensor.TensorOf[T]
+	WeightsMask              *tensor.TensorOf[T]
+	WeightsGradients         *tensor.TensorOf[T]
+	GradientsIn              *tensor.TensorOf[T]
 	LocalDelta               []Gradient
 	Input                    []float64
 	ActivationFunc           func(v float64) float64
 	ActivationDerivative     func(v float64) float64
 }
 
+// FullyConnectedLayer - fully connected layer of float64 precision
+type FullyConnectedLayer = FullyConnectedLayerOf[float64]
+
 // NewFullyConnectedLayer - constructor for new fully connected layer. You need to specify input size and output size
 func NewFullyConnectedLayer(inSize *tensor.TDsize, outSize int) Layer {
-	newLayer := &FullyConnectedLayer{
-		In:                       tensor.NewTensor(inSize.X, inSize.Y, inSize.Z),
-		Out:                      tensor.NewTensor(outSize, 1, 1),
-		NextDeltaWeightSum:       tensor.NewTensor(inSize.X, inSize.Y, inSize.Z),
-		Weights:                  tensor.NewTensor(inSize.Total(), outSize, 1),
-		PreviousIterationWeights: tensor.NewTensor(inSize.Total(), outSize, 1),
+	return NewFullyConnectedLayerOf[float64](inSize, outSize)
+}
+
+// NewFullyConnectedLayerOf - constructor for new fully connected layer of given precision (float32 or float64). See NewFullyConnectedLayer.
+func NewFullyConnectedLayerOf[T tensor.Float](inSize *tensor.TDsize, outSize int) LayerOf[T] {
+	newLayer := &FullyConnectedLayerOf[T]{
+		In:                       tensor.NewTensorOf[T](inSize.X, inSize.Y, inSize.Z),
+		Out:                      tensor.NewTensorOf[T](outSize, 1, 1),
+		NextDeltaWeightSum:       tensor.NewTensorOf[T](inSize.X, inSize.Y, inSize.Z),
+		Weights:                  tensor.NewTensorOf[T](inSize.Total(), outSize, 1),
+		PreviousIterationWeights: tensor.NewTensorOf[T](inSize.Total(), outSize, 1),
 		Input:                    make([]float64, outSize),
 		LocalDelta:               make([]Gradient, outSize),
 		ActivationFunc:           ActivationTanh,           // Default Activation function is TanH
@@ -44,14 +58,14 @@ func NewFullyConnectedLayer(inSize *tensor.TDsize, outSize int) Layer {
 	}
 	for i := 0; i < outSize; i++ {
 		for h := 0; h < inSize.Total(); h++ {
-			newLayer.Weights.Set(h, i, 0, rand.Float64()-0.5)
+			newLayer.Weights.Set(h, i, 0, T(rand.Float64()-0.5))
 		}
 	}
 	return newLayer
 }
 
 // SetCustomWeights - set user's weights (make it carefully)
-func (fc *FullyConnectedLayer) SetCustomWeights(t []*tensor.Tensor) {
+func (fc *FullyConnectedLayerOf[T]) SetCustomWeights(t []*tensor.TensorOf[T]) {
 	if len(t) != 1 {
 		fmt.Println("You can provide array of length 1 only (for fully-connected layer)")
 		return
@@ -64,50 +78,63 @@ func (fc *FullyConnectedLayer) SetCustomWeights(t []*tensor.Tensor) {
 }
 
 // GetOutputSize - returns output size (dimensions)
-func (fc *FullyConnectedLayer) GetOutputSize() *tensor.TDsize {
+func (fc *FullyConnectedLayerOf[T]) GetOutputSize() *tensor.TDsize {
 	return fc.Out.Size
 }
 
 // GetInputSize - returns input size (dimensions)
-func (fc *FullyConnectedLayer) GetInputSize() *tensor.TDsize {
+func (fc *FullyConnectedLayerOf[T]) GetInputSize() *tensor.TDsize {
 	return fc.In.Size
 }
 
 // GetOutput - returns fully connected layer's output
-func (fc *FullyConnectedLayer) GetOutput() *tensor.Tensor {
+func (fc *FullyConnectedLayerOf[T]) GetOutput() *tensor.TensorOf[T] {
 	return fc.Out // Here we outputing ACTIVATED values
 }
 
 // GetWeights - returns convolutional layer's weights.
-func (fc *FullyConnectedLayer) GetWeights() []*tensor.Tensor {
-	return []*tensor.Tensor{fc.Weights}
+func (fc *FullyConnectedLayerOf[T]) GetWeights() []*tensor.TensorOf[T] {
+	return []*tensor.TensorOf[T]{fc.Weights}
 }
 
 // GetGradients - returns SUM(next layer grad * weights) as gradients
-func (fc *FullyConnectedLayer) GetGradients() *tensor.Tensor {
+func (fc *FullyConnectedLayerOf[T]) GetGradients() *tensor.TensorOf[T] {
 	return fc.NextDeltaWeightSum
 }
 
 // FeedForward - feed data to fully connected layer
-func (fc *FullyConnectedLayer) FeedForward(t *tensor.Tensor) {
+func (fc *FullyConnectedLayerOf[T]) FeedForward(t *tensor.TensorOf[T]) {
 	fc.In = t
 	fc.DoActivation()
 }
 
 // DoActivation - fully connected layer's output activation
-func (fc *FullyConnectedLayer) DoActivation() {
-	for n := 0; n < fc.Out.Size.X; n++ {
-		inputv := 0.0
-		for i := 0; i < fc.In.Size.X; i++ {
-			for j := 0; j < fc.In.Size.Y; j++ {
-				for z := 0; z < fc.In.Size.Z; z++ {
-					m := fc.mapToInput(i, j, z)
-					inputv += fc.In.Get(i, j, z) * fc.Weights.Get(m, n, 0)
+func (fc *FullyConnectedLayerOf[T]) DoActivation() {
+	fc.feedForward(fc.In, fc.Out, fc.Input)
+}
+
+// feedForwardInto - activate input and store result in provided output. Layer's state is not touched.
+func (fc *FullyConnectedLayerOf[T]) feedForwardInto(in, out *tensor.TensorOf[T]) {
+	fc.feedForward(in, out, nil)
+}
+
+// feedForward - evaluate summation input and activated output for every neuron.
+// If input is nil, then summation input is not stored.
+func (fc *FullyConnectedLayerOf[T]) feedForward(in, out *tensor.TensorOf[T], input []float64) {
+	for n := 0; n < out.Size.X; n++ {
+		var inputv T
+		for i := 0; i < in.Size.X; i++ {
+			for j := 0; j < in.Size.Y; j++ {
+				for z := 0; z < in.Size.Z; z++ {
+					m := z*in.Size.X*in.Size.Y + j*in.Size.X + i
+					inputv += in.Get(i, j, z) * fc.Weights.Get(m, n, 0)
 				}
 			}
 		}
-		fc.Input[n] = inputv
-		fc.Out.Set(n, 0, 0, fc.ActivationFunc(inputv))
+		if input != nil {
+			input[n] = float64(inputv)
+		}
+		out.Set(n, 0, 0, T(fc.ActivationFunc(float64(inputv))))
 	}
 }
 
@@ -136,17 +163,21 @@ func (fc *FullyConnectedLayer) DoActivation() {
 				= [sum(LocalDelta{n} * w{n, i}), for n=0 to len(num of neurons on k+1 layer))]
 
 */
-func (fc *FullyConnectedLayer) CalculateGradients(nextLayerGradients *tensor.Tensor) {
+func (fc *FullyConnectedLayerOf[T]) CalculateGradients(nextLayerGradients *tensor.TensorOf[T]) {
 	for i := 0; i < fc.NextDeltaWeightSum.Size.Total(); i++ {
 		fc.NextDeltaWeightSum.Data[i] = 0.0
 	}
+	if fc.GradientsIn == nil {
+		fc.GradientsIn = tensor.NewTensorOf[T](fc.In.Size.X, fc.In.Size.Y, fc.In.Size.Z)
+	}
+	copy(fc.GradientsIn.Data, fc.In.Data)
 	for n := 0; n < fc.Out.Size.X; n++ {
-		fc.LocalDelta[n].Grad = (*nextLayerGradients).Get(n, 0, 0) * fc.ActivationDerivative(fc.Input[n])
+		fc.LocalDelta[n].Grad = float64((*nextLayerGradients).Get(n, 0, 0)) * fc.ActivationDerivative(fc.Input[n])
 		for i := 0; i < fc.In.Size.X; i++ {
 			for j := 0; j < fc.In.Size.Y; j++ {
 				for z := 0; z < fc.In.Size.Z; z++ {
 					m := fc.mapToInput(i, j, z)
-					v := fc.LocalDelta[n].Grad * fc.Weights.Get(m, n, 0)
+					v := T(fc.LocalDelta[n].Grad) * fc.Weights.Get(m, n, 0)
 					fc.NextDeltaWeightSum.SetAdd(i, j, z, v)
 				}
 			}
@@ -164,7 +195,7 @@ func (fc *FullyConnectedLayer) CalculateGradients(nextLayerGradients *tensor.Ten
 
 	Δw{n, i} =  -(η * ΔE/Δw{n, i}) = -(η)*δ{i}*input{n}
 */
-func (fc *FullyConnectedLayer) UpdateWeights() {
+func (fc *FullyConnectedLayerOf[T]) UpdateWeights() {
 	for n := 0; n < fc.Out.Size.X; n++ {
 		grad := fc.LocalDelta[n]
 		// log.Println("G:", grad)
@@ -181,65 +212,112 @@ func (fc *FullyConnectedLayer) UpdateWeights() {
 						With inertia (notice, that η has to be < 0 and we are multiplying η by -1.0)
 						See reference: https://en.wikipedia.org/wiki/Backpropagation#Inertia
 					*/
-					dw := (1.0-lp.Momentum)*(-1.0*(lp.LearningRate*grad.Grad*fc.In.Get(i, j, z))) +
-						lp.Momentum*fc.PreviousIterationWeights.Get(m, n, 0)
+					dw := (1.0-lp.Momentum)*(-1.0*(lp.LearningRate*grad.Grad*float64(fc.In.Get(i, j, z)))) +
+						lp.Momentum*float64(fc.PreviousIterationWeights.Get(m, n, 0))
 
 					// Decay of weights (L2 regularization)
 					// decay := fc.Weights.Get(m, n, 0) * (1 - lp.WeightDecay)
 
-					fc.PreviousIterationWeights.Set(m, n, 0, dw)
+					fc.PreviousIterationWeights.Set(m, n, 0, T(dw))
 
 					// w{n,i} = w{n,i} + Δw{n, i}
-					fc.Weights.SetAdd(m, n, 0, dw)
+					fc.Weights.SetAdd(m, n, 0, T(dw))
+				}
+			}
+		}
+	}
+	fc.applyMask()
+}
+
+// accumulateGradients - add gradients of weights for current sample (δ{n}*input{i}) to the mini-batch sum
+func (fc *FullyConnectedLayerOf[T]) accumulateGradients() {
+	if fc.WeightsGradients == nil {
+		fc.WeightsGradients = tensor.NewTensorOf[T](fc.Weights.Size.X, fc.Weights.Size.Y, fc.Weights.Size.Z)
+	}
+	for n := 0; n < fc.Out.Size.X; n++ {
+		grad := fc.LocalDelta[n]
+		for i := 0; i < fc.In.Size.X; i++ {
+			for j := 0; j < fc.In.Size.Y; j++ {
+				for z := 0; z < fc.In.Size.Z; z++ {
+					m := fc.mapToInput(i, j, z)
+					fc.WeightsGradients.SetAdd(m, n, 0, T(grad.Grad*float64(fc.In.Get(i, j, z))))
 				}
 			}
 		}
 	}
 }
 
+// applyGradients - update weights with mean gradients of mini-batch (same inertia rule as in UpdateWeights) and reset the sum
+func (fc *FullyConnectedLayerOf[T]) applyGradients(batchSize int) {
+	if fc.WeightsGradients == nil {
+		return
+	}
+	for i := range fc.Weights.Data {
+		grad := float64(fc.WeightsGradients.Data[i]) / float64(batchSize)
+		dw := (1.0-lp.Momentum)*(-1.0*(lp.LearningRate*grad)) + lp.Momentum*float64(fc.PreviousIterationWeights.Data[i])
+		fc.PreviousIterationWeights.Data[i] = T(dw)
+		fc.Weights.Data[i] += T(dw)
+		fc.WeightsGradients.Data[i] = 0
+	}
+	fc.applyMask()
+}
+
+// applyMask - keep pruned weights (and their momentum) at zero. See WholeNetOf.Prune
+func (fc *FullyConnectedLayerOf[T]) applyMask() {
+	if fc.WeightsMask == nil {
+		return
+	}
+	for i, m := range fc.WeightsMask.Data {
+		if m == 0 {
+			fc.Weights.Data[i] = 0
+			fc.PreviousIterationWeights.Data[i] = 0
+		}
+	}
+}
+
 // PrintOutput - print fully connected layer's output
-func (fc *FullyConnectedLayer) PrintOutput() {
+func (fc *FullyConnectedLayerOf[T]) PrintOutput() {
 	fmt.Println("Printing Fully Connected Layer output...")
 	fc.Out.Print()
 }
 
 // PrintWeights - print fully connected layer's weights
-func (fc *FullyConnectedLayer) PrintWeights() {
+func (fc *FullyConnectedLayerOf[T]) PrintWeights() {
 	fmt.Println("Printing Fully Connected Layer weights...")
 	fc.Weights.Print()
 }
 
 // PrintGradients - print fully connected layer's gradients
-func (fc *FullyConnectedLayer) PrintGradients() {
+func (fc *FullyConnectedLayerOf[T]) PrintGradients() {
 	fmt.Println("Printing Fully Connected Layer gradients-weights...")
 	fc.NextDeltaWeightSum.Print()
 }
 
 // SetActivationFunc sets activation function for fully connected layer. You need to specify function: func(v float64) float64
-func (fc *FullyConnectedLayer) SetActivationFunc(f func(v float64) float64) {
+func (fc *FullyConnectedLayerOf[T]) SetActivationFunc(f func(v float64) float64) {
 	fc.ActivationFunc = f
 }
 
 // SetActivationDerivativeFunc sets derivative of activation function for fully connected layer. You need to specify function: func(v float64) float64
-func (fc *FullyConnectedLayer) SetActivationDerivativeFunc(f func(v float64) float64) {
+func (fc *FullyConnectedLayerOf[T]) SetActivationDerivativeFunc(f func(v float64) float64) {
 	fc.ActivationDerivative = f
 }
 
 // GetStride - get stride of layer
-func (fc *FullyConnectedLayer) GetStride() int {
+func (fc *FullyConnectedLayerOf[T]) GetStride() int {
 	return 0
 }
 
 // GetKernelSize - return "conv" as layer's type
-func (fc *FullyConnectedLayer) GetKernelSize() int {
+func (fc *FullyConnectedLayerOf[T]) GetKernelSize() int {
 	return 0
 }
 
 // GetType - return "fc" as layer's type
-func (fc *FullyConnectedLayer) GetType() string {
+func (fc *FullyConnectedLayerOf[T]) GetType() string {
 	return "fc"
 }
 
-func (fc *FullyConnectedLayer) mapToInput(i, j, k int) int {
+func (fc *FullyConnectedLayerOf[T]) mapToInput(i, j, k int) int {
 	return k*fc.In.Size.X*fc.In.Size.Y + j*fc.In.Size.X + i
 }
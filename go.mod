@@ -1,9 +1,9 @@
 module github.com/LdDl/cnns
 
-go 1.14
+go 1.18
 
 require (
-	github.com/ajstarks/svgo v0.0.0-20200725142600-7a3c8b57fecb // indirect
+	github.com/ajstarks/svgo v0.0.0-20200725142600-7a3c8b57fecb
 	github.com/nfnt/resize v0.0.0-20180221191011-83c6a9932646
 	golang.org/x/image v0.0.0-20200801110659-972c09e46d76
 	gonum.org/v1/gonum v0.8.1
@@ -75,3 +75,31 @@ func (t1 *TensorGradient) Print() {
 		}
 	}
 }
+
+// WeightGradients - returns gradients of loss with respect to weights of convolutional or fully connected layer (same sizes as GetWeights) for the last backpropagated sample. Returns nil for other layers
+func WeightGradients[T tensor.Float](layer LayerOf[T]) []*tensor.TensorOf[T] {
+	switch typed := layer.(type) {
+	case *ConvLayerOf[T]:
+		ret := make([]*tensor.TensorOf[T], len(typed.Kernels))
+		for k := range typed.Kernels {
+			ret[k] = tensor.NewTensorOf[T](typed.Kernels[k].Size.X, typed.Kernels[k].Size.Y, typed.Kernels[k].Size.Z)
+			for i := range ret[k].Data {
+				ret[k].Data[i] = T(typed.LocalDeltas[k].Data[i].Grad)
+			}
+		}
+		return ret
+	case *FullyConnectedLayerOf[T]:
+		ret := tensor.NewTensorOf[T](typed.Weights.Size.X, typed.Weights.Size.Y, typed.Weights.Size.Z)
+		// Input saved by backpropagation is used, since In may be overwritten by feed forward passes since then (e.g. by validation)
+		if typed.GradientsIn == nil {
+			return []*tensor.TensorOf[T]{ret}
+		}
+		for n := range typed.LocalDelta {
+			for m := range typed.GradientsIn.Data {
+				ret.Data[n*typed.Weights.Size.X+m] = T(typed.LocalDelta[n].Grad) * typed.GradientsIn.Data[m]
+			}
+		}
+		return []*tensor.TensorOf[T]{ret}
+	}
+	return nil
+}
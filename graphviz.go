@@ -0,0 +1,230 @@
+package cnns
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/LdDl/cnns/tensor"
+)
+
+// GraphvizMode - level of details of Graphviz diagram
+type GraphvizMode int
+
+const (
+	// GraphvizNeurons - every neuron (Size.X of layer's output) is drawn as circle. Suitable for small MLPs
+	GraphvizNeurons = GraphvizMode(iota)
+	// GraphvizLayers - every layer is drawn as record with type, input and output sizes, kernel, stride and number of parameters
+	GraphvizLayers
+)
+
+// GraphvizOptions - parameters of Graphviz diagram
+/*
+	Mode - see GraphvizMode;
+	MaxNeurons - number of neurons drawn for layer (or listed in record of fully connected layer) before the rest is collapsed into "… N more" (8 if not set, negative value - never collapse).
+*/
+type GraphvizOptions struct {
+	Mode       GraphvizMode
+	MaxNeurons int
+}
+
+// GetGraphvizText Returns Graphviz text-based output (neuron-level, see GraphvizNeurons)
+func (wh *WholeNetOf[T]) GetGraphvizText() string {
+	return wh.graphvizNeurons(-1)
+}
+
+// GetGraphvizTextWith Returns Graphviz text-based output with given level of details
+func (wh *WholeNetOf[T]) GetGraphvizTextWith(options GraphvizOptions) string {
+	maxNeurons := options.MaxNeurons
+	if maxNeurons == 0 {
+		maxNeurons = 8
+	}
+	if options.Mode == GraphvizLayers {
+		return wh.graphvizLayers(maxNeurons)
+	}
+	return wh.graphvizNeurons(maxNeurons)
+}
+
+// collapsedCount - number of neurons which are drawn and number of collapsed ones
+func collapsedCount(size, maxNeurons int) (int, int) {
+	if maxNeurons < 0 || size <= maxNeurons {
+		return size, 0
+	}
+	return maxNeurons, size - maxNeurons
+}
+
+// graphvizNeurons - neuron-level diagram. Layers wider than maxNeurons are collapsed (negative maxNeurons - never collapse)
+func (wh *WholeNetOf[T]) graphvizNeurons(maxNeurons int) string {
+	graph := "digraph G {rankdir = LR;splines=false;edge[style=invis];ranksep= 1.4;"
+
+	if len(wh.Layers) == 0 {
+		return ""
+	}
+	inputSize := wh.Layers[0].GetInputSize()
+	inputVertices := []string{}
+	inputVerticesLabels := []string{}
+	inputNodeProperties := "node [shape=circle, color=chartreuse, style=filled, fillcolor=chartreuse]"
+	shown, more := collapsedCount(inputSize.X, maxNeurons)
+	for x := 0; x < shown; x++ {
+		vertex := fmt.Sprintf("x%[1]d [label=<x<sub>%[1]d</sub><sup>(0)</sup>>]", x)
+		inputVerticesLabels = append(inputVerticesLabels, vertex)
+		inputVertices = append(inputVertices, fmt.Sprintf("x%[1]d", x))
+	}
+	if more > 0 {
+		inputVerticesLabels = append(inputVerticesLabels, fmt.Sprintf("x_more [shape=plaintext, style=\"\", label=\"… %d more\"]", more))
+		inputVertices = append(inputVertices, "x_more")
+	}
+	inputLayerVertices := fmt.Sprintf("{%s}", strings.Join(inputVertices, ";"))
+	inputNodeProperties = fmt.Sprintf("{%s;%s;}", inputNodeProperties, strings.Join(inputVerticesLabels, ";"))
+	inputRankProperties := fmt.Sprintf("{rank=same;%s;}", strings.Join(inputVertices, "->"))
+	inputLayerProperties := fmt.Sprintf("l_input [shape=plaintext, label=\"Input layer\"];")
+	inputLayerRankProperties := fmt.Sprintf("{rank=same; l_input;%[1]s};", inputVertices[0])
+	graph += inputNodeProperties
+	graph += inputRankProperties
+	graph += inputLayerProperties
+	graph += inputLayerRankProperties
+
+	layersVertices := []string{}
+
+	for l := range wh.Layers {
+		nodeProperties := ""
+
+		vertices := []string{}
+		verticesLabels := []string{}
+		layerType := ""
+
+		size := wh.Layers[l].GetOutput().Size
+		shown, more := collapsedCount(size.X, maxNeurons)
+		moreVertex := fmt.Sprintf("h_more%d", l)
+		switch l {
+		case len(wh.Layers) - 1:
+			nodeProperties = "node [shape=circle, color=coral1, style=filled, fillcolor=coral1]"
+			for x := 0; x < shown; x++ {
+				vertex := fmt.Sprintf("O%[1]d [label=<o<sub>%[1]d</sub><sup>(%[2]d)</sup>>]", x, l)
+				verticesLabels = append(verticesLabels, vertex)
+				vertices = append(vertices, fmt.Sprintf("O%[1]d", x))
+			}
+			moreVertex = "O_more"
+			layerType = "output"
+			break
+		default:
+			nodeProperties = "node [shape=circle, color=dodgerblue, style=filled, fillcolor=dodgerblue]"
+			for x := 0; x < shown; x++ {
+				vertex := fmt.Sprintf("h%[1]d%[2]d [label=<h<sub>%[1]d</sub><sup>(%[2]d)</sup>>]", x, l)
+				verticesLabels = append(verticesLabels, vertex)
+				vertices = append(vertices, fmt.Sprintf("h%[1]d%[2]d", x, l))
+			}
+			layerType = "hidden"
+			break
+		}
+		if more > 0 {
+			verticesLabels = append(verticesLabels, fmt.Sprintf("%s [shape=plaintext, style=\"\", label=\"… %d more\"]", moreVertex, more))
+			vertices = append(vertices, moreVertex)
+		}
+
+		nodeProperties = fmt.Sprintf("{%s;%s;}", nodeProperties, strings.Join(verticesLabels, ";"))
+		rankProperties := fmt.Sprintf("{rank=same;%s;}", strings.Join(vertices, "->"))
+		layerProperties := fmt.Sprintf("l%[1]d [shape=plaintext, label=\"layer %[1]d (%[2]s layer)\"];", l, layerType)
+		layerRankProperties := fmt.Sprintf("{rank=same; l%[1]d;%[2]s};", l, vertices[0])
+
+		layerVertices := fmt.Sprintf("{%s}", strings.Join(vertices, ";"))
+		layersVertices = append(layersVertices, layerVertices)
+
+		graph += nodeProperties
+		graph += rankProperties
+		graph += layerProperties
+		graph += layerRankProperties
+	}
+
+	edgesStyle := "edge[style=solid, tailport=e, headport=w];"
+	graph += edgesStyle
+	inputEdges := fmt.Sprintf("%[1]s -> %[2]s;", inputLayerVertices, layersVertices[0])
+	graph += inputEdges
+	for l := 1; l < len(layersVertices); l++ {
+		edges := fmt.Sprintf("%[1]s -> %[2]s;", layersVertices[l-1], layersVertices[l])
+		graph += edges
+	}
+
+	graph += "}"
+
+	return graph
+}
+
+// graphvizLayerColors - fill colors of records for every layer type
+var graphvizLayerColors = map[string]string{
+	"conv":       "lightskyblue",
+	"pool":       "plum",
+	"relu":       "khaki",
+	"leaky_relu": "khaki",
+	"fc":         "dodgerblue",
+}
+
+// formatSize - "X×Y×Z"
+func formatSize(size *tensor.TDsize) string {
+	return fmt.Sprintf("%d×%d×%d", size.X, size.Y, size.Z)
+}
+
+// layerParameters - number of trainable parameters of layer
+func layerParameters[T tensor.Float](layer LayerOf[T]) int {
+	switch typed := layer.(type) {
+	case *ConvLayerOf[T]:
+		total := 0
+		for _, k := range typed.Kernels {
+			total += len(k.Data)
+		}
+		return total
+	case *FullyConnectedLayerOf[T]:
+		return len(typed.Weights.Data)
+	}
+	return 0
+}
+
+// graphvizLayers - layer-level diagram: every layer is record node
+func (wh *WholeNetOf[T]) graphvizLayers(maxNeurons int) string {
+	if len(wh.Layers) == 0 {
+		return ""
+	}
+	var sb strings.Builder
+	sb.WriteString("digraph G {rankdir = TB;node [shape=record, style=filled, fontname=\"Helvetica\"];")
+	sb.WriteString(fmt.Sprintf("l_input [label=\"{Input|%s}\", fillcolor=chartreuse];", formatSize(wh.Layers[0].GetInputSize())))
+	total := 0
+	for l, layer := range wh.Layers {
+		layerType := layer.GetType()
+		fields := []string{
+			fmt.Sprintf("#%d %s", l, layerType),
+			"in: " + formatSize(layer.GetInputSize()),
+			"out: " + formatSize(layer.GetOutputSize()),
+		}
+		if layerType == "conv" || layerType == "pool" {
+			fields = append(fields, fmt.Sprintf("kernel: %[1]d×%[1]d, stride: %[2]d", layer.GetKernelSize(), layer.GetStride()))
+		}
+		params := layerParameters(layer)
+		total += params
+		fields = append(fields, fmt.Sprintf("params: %d", params))
+		if layerType == "fc" {
+			// Neurons of fully connected layer are listed as separate fields
+			outSize := layer.GetOutputSize().X
+			shown, more := collapsedCount(outSize, maxNeurons)
+			neurons := make([]string, 0, shown+1)
+			for n := 0; n < shown; n++ {
+				neurons = append(neurons, fmt.Sprintf("n%d", n))
+			}
+			if more > 0 {
+				neurons = append(neurons, fmt.Sprintf("… %d more", more))
+			}
+			fields = append(fields, "{"+strings.Join(neurons, "|")+"}")
+		}
+		color, ok := graphvizLayerColors[layerType]
+		if !ok {
+			color = "lightgray"
+		}
+		sb.WriteString(fmt.Sprintf("l%d [label=\"{%s}\", fillcolor=%s];", l, strings.Join(fields, "|"), color))
+	}
+	sb.WriteString(fmt.Sprintf("l_total [shape=plaintext, style=\"\", label=\"Total params: %d\"];", total))
+	sb.WriteString("l_input -> l0;")
+	for l := 1; l < len(wh.Layers); l++ {
+		sb.WriteString(fmt.Sprintf("l%d -> l%d;", l-1, l))
+	}
+	sb.WriteString(fmt.Sprintf("l%d -> l_total [style=invis];", len(wh.Layers)-1))
+	sb.WriteString("}")
+	return sb.String()
+}
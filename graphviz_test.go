@@ -0,0 +1,37 @@
+package cnns
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/LdDl/cnns/tensor"
+)
+
+func TestGraphvizLayers(t *testing.T) {
+	conv := NewConvLayer(1, 5, 4, tensor.TDsize{X: 28, Y: 28, Z: 1})
+	relu := NewReLULayer(conv.GetOutputSize())
+	pool := NewMaxPoolingLayer(2, 2, relu.GetOutputSize())
+	fc := NewFullyConnectedLayer(pool.GetOutputSize(), 510)
+	net := WholeNet{Layers: []Layer{conv, relu, pool, fc}}
+
+	text := net.GetGraphvizTextWith(GraphvizOptions{Mode: GraphvizLayers, MaxNeurons: 10})
+	expected := []string{
+		"l_input [label=\"{Input|28×28×1}\"",
+		"l0 [label=\"{#0 conv|in: 28×28×1|out: 24×24×4|kernel: 5×5, stride: 1|params: 100}\"",
+		"l2 [label=\"{#2 pool|in: 24×24×4|out: 12×12×4|kernel: 2×2, stride: 2|params: 0}\"",
+		"params: 293760|{n0|n1|n2|n3|n4|n5|n6|n7|n8|n9|… 500 more}}\"",
+		"Total params: 293860",
+		"l2 -> l3;",
+	}
+	for _, e := range expected {
+		if !strings.Contains(text, e) {
+			t.Errorf("Diagram should contain %s. Got: %s", e, text)
+		}
+	}
+
+	// Neuron-level mode collapses wide layers
+	text = net.GetGraphvizTextWith(GraphvizOptions{Mode: GraphvizNeurons, MaxNeurons: 10})
+	if !strings.Contains(text, "O_more [shape=plaintext, style=\"\", label=\"… 500 more\"]") || strings.Contains(text, "O10 ") {
+		t.Errorf("Output layer should be collapsed. Got: %s", text)
+	}
+}
@@ -4,8 +4,8 @@ import (
 	"github.com/LdDl/cnns/tensor"
 )
 
-// Layer - interface for all layer types
-type Layer interface {
+// LayerOf - interface for all layer types
+type LayerOf[T tensor.Float] interface {
 	// OutSize - returns output size (dimensions)
 	GetOutputSize() *tensor.TDsize
 
@@ -13,19 +13,19 @@ type Layer interface {
 	GetInputSize() *tensor.TDsize
 
 	// GetOutput - returns layer's output
-	GetOutput() *tensor.Tensor
+	GetOutput() *tensor.TensorOf[T]
 
 	// GetWeights - returns layer's weights
-	GetWeights() []*tensor.Tensor
+	GetWeights() []*tensor.TensorOf[T]
 
 	// GetGradients - returns layer's gradients
-	GetGradients() *tensor.Tensor
+	GetGradients() *tensor.TensorOf[T]
 
 	// FeedForward - feed data to layer
-	FeedForward(t *tensor.Tensor)
+	FeedForward(t *tensor.TensorOf[T])
 
 	// CalculateGradients - calculate layers' gradients
-	CalculateGradients(nextLayerGradients *tensor.Tensor)
+	CalculateGradients(nextLayerGradients *tensor.TensorOf[T])
 
 	// UpdateWeights - update layer's weights
 	UpdateWeights()
@@ -50,5 +50,8 @@ type Layer interface {
 
 	SetActivationFunc(f func(v float64) float64)
 	SetActivationDerivativeFunc(f func(v float64) float64)
-	SetCustomWeights(t []*tensor.Tensor)
+	SetCustomWeights(t []*tensor.TensorOf[T])
 }
+
+// Layer - interface for all layer types (float64 precision)
+type Layer = LayerOf[float64]
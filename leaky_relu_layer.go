@@ -6,7 +6,7 @@ import (
 	"github.com/LdDl/cnns/tensor"
 )
 
-// LeakyReLULayer - Rectified Linear Unit layer with leak.
+// LeakyReLULayerOf - Rectified Linear Unit layer with leak.
 /*
 	In - Input data;
 	Out - Output data;
@@ -14,87 +14,100 @@ import (
 	alpha - In simple ReLU you have f(x) = max(x,0) as activation function,
 	but in Leaky ReLU it is: f(x) = alpha*x (for x < 0) and f(x) = x (for x >= 0).
 */
-type LeakyReLULayer struct {
-	In                    *tensor.Tensor
-	Out                   *tensor.Tensor
-	InputGradientsWeights *tensor.Tensor
+type LeakyReLULayerOf[T tensor.Float] struct {
+	In                    *tensor.TensorOf[T]
+	Out                   *tensor.TensorOf[T]
+	InputGradientsWeights *tensor.TensorOf[T]
 	alpha                 float64
 }
 
+// LeakyReLULayer - Leaky ReLU layer of float64 precision
+type LeakyReLULayer = LeakyReLULayerOf[float64]
+
 // NewLeakyReLULayer - Constructor for new Leaky ReLU layer. You need to specify input size
 /*
 	inSize - Input layer's size;
 	alpha - Coefficient in activation function. Should small (for example 0.01).
 */
 func NewLeakyReLULayer(inSize *tensor.TDsize, alpha float64) Layer {
-	newLayer := &LeakyReLULayer{
-		InputGradientsWeights: tensor.NewTensor(inSize.X, inSize.Y, inSize.Z),
-		In:                    tensor.NewTensor(inSize.X, inSize.Y, inSize.Z),
-		Out:                   tensor.NewTensor(inSize.X, inSize.Y, inSize.Z),
+	return NewLeakyReLULayerOf[float64](inSize, alpha)
+}
+
+// NewLeakyReLULayerOf - constructor for new Leaky ReLU layer of given precision (float32 or float64). See NewLeakyReLULayer.
+func NewLeakyReLULayerOf[T tensor.Float](inSize *tensor.TDsize, alpha float64) LayerOf[T] {
+	newLayer := &LeakyReLULayerOf[T]{
+		InputGradientsWeights: tensor.NewTensorOf[T](inSize.X, inSize.Y, inSize.Z),
+		In:                    tensor.NewTensorOf[T](inSize.X, inSize.Y, inSize.Z),
+		Out:                   tensor.NewTensorOf[T](inSize.X, inSize.Y, inSize.Z),
 		alpha:                 alpha,
 	}
 	return newLayer
 }
 
 // SetCustomWeights - Set user's weights (make it carefully)
-func (lrelu *LeakyReLULayer) SetCustomWeights(t []*tensor.Tensor) {
+func (lrelu *LeakyReLULayerOf[T]) SetCustomWeights(t []*tensor.TensorOf[T]) {
 	fmt.Println("There are no weights for ReLU layer")
 }
 
 // GetOutputSize - Return output size (dimensions)
-func (lrelu *LeakyReLULayer) GetOutputSize() *tensor.TDsize {
+func (lrelu *LeakyReLULayerOf[T]) GetOutputSize() *tensor.TDsize {
 	return lrelu.Out.Size
 }
 
 // GetInputSize - Return input size (dimensions)
-func (lrelu *LeakyReLULayer) GetInputSize() *tensor.TDsize {
+func (lrelu *LeakyReLULayerOf[T]) GetInputSize() *tensor.TDsize {
 	return lrelu.In.Size
 }
 
 // GetOutput - Return Leaky ReLU layer's output
-func (lrelu *LeakyReLULayer) GetOutput() *tensor.Tensor {
+func (lrelu *LeakyReLULayerOf[T]) GetOutput() *tensor.TensorOf[T] {
 	return lrelu.Out
 }
 
 // GetWeights - Return Leaky ReLU layer's weights
-func (lrelu *LeakyReLULayer) GetWeights() []*tensor.Tensor {
+func (lrelu *LeakyReLULayerOf[T]) GetWeights() []*tensor.TensorOf[T] {
 	fmt.Println("There are no weights for ReLU layer")
-	return []*tensor.Tensor{}
+	return []*tensor.TensorOf[T]{}
 }
 
 // GetGradients - Return Leaky ReLU layer's gradients
-func (lrelu *LeakyReLULayer) GetGradients() *tensor.Tensor {
+func (lrelu *LeakyReLULayerOf[T]) GetGradients() *tensor.TensorOf[T] {
 	return lrelu.InputGradientsWeights
 }
 
 // FeedForward - Feed data to Leaky ReLU layer
-func (lrelu *LeakyReLULayer) FeedForward(t *tensor.Tensor) {
+func (lrelu *LeakyReLULayerOf[T]) FeedForward(t *tensor.TensorOf[T]) {
 	lrelu.In = t
 	lrelu.DoActivation()
 }
 
 // DoActivation - Leaky ReLU layer's output activation
-func (lrelu *LeakyReLULayer) DoActivation() {
-	for i := 0; i < lrelu.In.Size.X; i++ {
-		for j := 0; j < lrelu.In.Size.Y; j++ {
-			for z := 0; z < lrelu.In.Size.Z; z++ {
-				v := lrelu.In.Get(i, j, z)
+func (lrelu *LeakyReLULayerOf[T]) DoActivation() {
+	lrelu.feedForwardInto(lrelu.In, lrelu.Out)
+}
+
+// feedForwardInto - Activate input and store result in provided output. Layer's state is not touched.
+func (lrelu *LeakyReLULayerOf[T]) feedForwardInto(in, out *tensor.TensorOf[T]) {
+	for i := 0; i < in.Size.X; i++ {
+		for j := 0; j < in.Size.Y; j++ {
+			for z := 0; z < in.Size.Z; z++ {
+				v := in.Get(i, j, z)
 				if v < 0 {
-					v = lrelu.alpha * v
+					v = T(lrelu.alpha) * v
 				}
-				lrelu.Out.Set(i, j, z, v)
+				out.Set(i, j, z, v)
 			}
 		}
 	}
 }
 
 // CalculateGradients - Calculate Leaky ReLU layer's gradients
-func (lrelu *LeakyReLULayer) CalculateGradients(nextLayerGrad *tensor.Tensor) {
+func (lrelu *LeakyReLULayerOf[T]) CalculateGradients(nextLayerGrad *tensor.TensorOf[T]) {
 	for i := 0; i < lrelu.In.Size.X; i++ {
 		for j := 0; j < lrelu.In.Size.Y; j++ {
 			for z := 0; z < lrelu.In.Size.Z; z++ {
 				if lrelu.In.Get(i, j, z) < 0 {
-					lrelu.InputGradientsWeights.Set(i, j, z, lrelu.alpha)
+					lrelu.InputGradientsWeights.Set(i, j, z, T(lrelu.alpha))
 				} else {
 					lrelu.InputGradientsWeights.Set(i, j, z, 1.0*nextLayerGrad.Get(i, j, z))
 				}
@@ -104,7 +117,7 @@ func (lrelu *LeakyReLULayer) CalculateGradients(nextLayerGrad *tensor.Tensor) {
 }
 
 // UpdateWeights - Just to point, that Leaky ReLU layer does NOT updating weights
-func (lrelu *LeakyReLULayer) UpdateWeights() {
+func (lrelu *LeakyReLULayerOf[T]) UpdateWeights() {
 	/*
 		Empty
 		Need for layer interface.
@@ -112,45 +125,45 @@ func (lrelu *LeakyReLULayer) UpdateWeights() {
 }
 
 // PrintOutput - Pretty print Leaky ReLU layer's output
-func (lrelu *LeakyReLULayer) PrintOutput() {
+func (lrelu *LeakyReLULayerOf[T]) PrintOutput() {
 	fmt.Println("Printing Leaky ReLU Layer output...")
 	lrelu.Out.Print()
 }
 
 // PrintWeights - Just to point, that Leaky ReLU layer has not weights
-func (lrelu *LeakyReLULayer) PrintWeights() {
+func (lrelu *LeakyReLULayerOf[T]) PrintWeights() {
 	fmt.Println("There are no weights for Leaky ReLU layer")
 }
 
 // PrintGradients - Print Leaky ReLU layer's local gradients
-func (lrelu *LeakyReLULayer) PrintGradients() {
+func (lrelu *LeakyReLULayerOf[T]) PrintGradients() {
 	fmt.Println("Printing Leaky ReLU Layer gradients...")
 	lrelu.InputGradientsWeights.Print()
 }
 
 // SetActivationFunc - Set activation function for layer
-func (lrelu *LeakyReLULayer) SetActivationFunc(f func(v float64) float64) {
+func (lrelu *LeakyReLULayerOf[T]) SetActivationFunc(f func(v float64) float64) {
 	// Nothing here. Just for interface.
 	fmt.Println("You can not set activation function for Leaky ReLU layer")
 }
 
 // SetActivationDerivativeFunc - Set derivative of activation function
-func (lrelu *LeakyReLULayer) SetActivationDerivativeFunc(f func(v float64) float64) {
+func (lrelu *LeakyReLULayerOf[T]) SetActivationDerivativeFunc(f func(v float64) float64) {
 	// Nothing here. Just for interface.
 	fmt.Println("You can not set derivative of activation function for Leaky ReLU layer")
 }
 
 // GetStride - Return stride of layer
-func (lrelu *LeakyReLULayer) GetStride() int {
+func (lrelu *LeakyReLULayerOf[T]) GetStride() int {
 	return 0
 }
 
 // GetKernelSize - Return kernel size
-func (lrelu *LeakyReLULayer) GetKernelSize() int {
+func (lrelu *LeakyReLULayerOf[T]) GetKernelSize() int {
 	return 0
 }
 
 // GetType - Return "leaky_relu" as layer's type
-func (lrelu *LeakyReLULayer) GetType() string {
+func (lrelu *LeakyReLULayerOf[T]) GetType() string {
 	return "leaky_relu"
 }
@@ -0,0 +1,64 @@
+package cnns
+
+import "math"
+
+// Loss - element-wise loss function. Loss of sample is mean of Value over output's elements.
+type Loss interface {
+	// Name - short name of loss (used for reporting)
+	Name() string
+	// Value - loss for single output element
+	Value(output, target float64) float64
+	// Derivative - dE/d(output) for single output element. This is gradient which is passed to the last layer
+	Derivative(output, target float64) float64
+}
+
+// LossMSE - mean square error. Derivative is (output - target) as it has always been used in Backpropagate (gradient of 0.5*(output - target)^2).
+type LossMSE struct{}
+
+// Name - returns "mse"
+func (LossMSE) Name() string {
+	return "mse"
+}
+
+// Value - (output - target)^2
+func (LossMSE) Value(output, target float64) float64 {
+	return (output - target) * (output - target)
+}
+
+// Derivative - output - target
+func (LossMSE) Derivative(output, target float64) float64 {
+	return output - target
+}
+
+// LossBinaryCrossEntropy - binary cross-entropy. Outputs are expected to be in range (0, 1), e.g. after ActivationSygmoid
+/*
+	Epsilon - outputs are clipped to [Epsilon, 1 - Epsilon] to avoid log(0). 1e-12 is used if not set.
+*/
+type LossBinaryCrossEntropy struct {
+	Epsilon float64
+}
+
+// Name - returns "binary_crossentropy"
+func (LossBinaryCrossEntropy) Name() string {
+	return "binary_crossentropy"
+}
+
+func (l LossBinaryCrossEntropy) clip(output float64) float64 {
+	eps := l.Epsilon
+	if eps <= 0 {
+		eps = 1e-12
+	}
+	return math.Min(math.Max(output, eps), 1-eps)
+}
+
+// Value - -(target*ln(output) + (1 - target)*ln(1 - output))
+func (l LossBinaryCrossEntropy) Value(output, target float64) float64 {
+	o := l.clip(output)
+	return -(target*math.Log(o) + (1-target)*math.Log(1-o))
+}
+
+// Derivative - (output - target) / (output*(1 - output))
+func (l LossBinaryCrossEntropy) Derivative(output, target float64) float64 {
+	o := l.clip(output)
+	return (o - target) / (o * (1 - o))
+}
@@ -0,0 +1,313 @@
+package metrics
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+// ConfusionMatrix - counts of samples for every pair of actual (row) and predicted (column) classes
+/*
+	Labels - names of classes (optional, indices are used if not set);
+	Counts - Counts[actual][predicted].
+*/
+type ConfusionMatrix struct {
+	Labels []string `json:"Labels,omitempty"`
+	Counts [][]int  `json:"Counts"`
+}
+
+// NewConfusionMatrix - builds confusion matrix from outputs of network and targets.
+// numClasses - number of classes (size of output is used if zero, 2 for single-output networks).
+func NewConfusionMatrix(outputs, targets [][]float64, numClasses int) (*ConfusionMatrix, error) {
+	actual, predicted, err := Classes(outputs, targets)
+	if err != nil {
+		return nil, err
+	}
+	if numClasses == 0 {
+		numClasses = len(outputs[0])
+		if numClasses == 1 {
+			numClasses = 2
+		}
+	}
+	cm := NewEmptyConfusionMatrix(numClasses)
+	for i := range actual {
+		err = cm.Add(actual[i], predicted[i])
+		if err != nil {
+			return nil, err
+		}
+	}
+	return cm, nil
+}
+
+// NewEmptyConfusionMatrix - constructor for confusion matrix without samples
+func NewEmptyConfusionMatrix(numClasses int) *ConfusionMatrix {
+	cm := &ConfusionMatrix{
+		Counts: make([][]int, numClasses),
+	}
+	for i := range cm.Counts {
+		cm.Counts[i] = make([]int, numClasses)
+	}
+	return cm
+}
+
+// Add - adds single sample
+func (cm *ConfusionMatrix) Add(actual, predicted int) error {
+	if actual < 0 || actual >= len(cm.Counts) || predicted < 0 || predicted >= len(cm.Counts) {
+		return fmt.Errorf("class out of range [0, %d): actual %d, predicted %d", len(cm.Counts), actual, predicted)
+	}
+	cm.Counts[actual][predicted]++
+	return nil
+}
+
+// NumClasses - returns number of classes
+func (cm *ConfusionMatrix) NumClasses() int {
+	return len(cm.Counts)
+}
+
+// Total - returns number of samples
+func (cm *ConfusionMatrix) Total() int {
+	total := 0
+	for i := range cm.Counts {
+		for j := range cm.Counts[i] {
+			total += cm.Counts[i][j]
+		}
+	}
+	return total
+}
+
+// Accuracy - fraction of correctly classified samples
+func (cm *ConfusionMatrix) Accuracy() float64 {
+	total := cm.Total()
+	if total == 0 {
+		return 0.0
+	}
+	correct := 0
+	for i := range cm.Counts {
+		correct += cm.Counts[i][i]
+	}
+	return float64(correct) / float64(total)
+}
+
+// label - name of class
+func (cm *ConfusionMatrix) label(class int) string {
+	if class < len(cm.Labels) {
+		return cm.Labels[class]
+	}
+	return fmt.Sprint(class)
+}
+
+// ClassMetrics - metrics of single class
+/*
+	Class - index of class;
+	Label - name of class;
+	TP, FP, FN - true positives, false positives and false negatives;
+	Support - number of samples of class (TP + FN).
+*/
+type ClassMetrics struct {
+	Class     int     `json:"Class"`
+	Label     string  `json:"Label"`
+	TP        int     `json:"TP"`
+	FP        int     `json:"FP"`
+	FN        int     `json:"FN"`
+	Support   int     `json:"Support"`
+	Precision float64 `json:"Precision"`
+	Recall    float64 `json:"Recall"`
+	F1        float64 `json:"F1"`
+}
+
+// AverageMetrics - averaged precision, recall and F1
+type AverageMetrics struct {
+	Average   string  `json:"Average"`
+	Precision float64 `json:"Precision"`
+	Recall    float64 `json:"Recall"`
+	F1        float64 `json:"F1"`
+}
+
+// ratio - a / b or zero if b is zero
+func ratio(a, b int) float64 {
+	if b == 0 {
+		return 0.0
+	}
+	return float64(a) / float64(b)
+}
+
+// f1 - harmonic mean of precision and recall
+func f1(precision, recall float64) float64 {
+	if precision+recall == 0 {
+		return 0.0
+	}
+	return 2 * precision * recall / (precision + recall)
+}
+
+// PerClass - returns metrics of every class
+func (cm *ConfusionMatrix) PerClass() []ClassMetrics {
+	ret := make([]ClassMetrics, len(cm.Counts))
+	for c := range cm.Counts {
+		m := ClassMetrics{
+			Class: c,
+			Label: cm.label(c),
+			TP:    cm.Counts[c][c],
+		}
+		for o := range cm.Counts {
+			if o == c {
+				continue
+			}
+			m.FN += cm.Counts[c][o]
+			m.FP += cm.Counts[o][c]
+		}
+		m.Support = m.TP + m.FN
+		m.Precision = ratio(m.TP, m.TP+m.FP)
+		m.Recall = ratio(m.TP, m.TP+m.FN)
+		m.F1 = f1(m.Precision, m.Recall)
+		ret[c] = m
+	}
+	return ret
+}
+
+// Averages - returns averaged precision, recall and F1. See Average
+func (cm *ConfusionMatrix) Averages(average Average) AverageMetrics {
+	perClass := cm.PerClass()
+	ret := AverageMetrics{
+		Average: average.String(),
+	}
+	switch average {
+	case AverageMicro:
+		tp, fp, fn := 0, 0, 0
+		for _, m := range perClass {
+			tp += m.TP
+			fp += m.FP
+			fn += m.FN
+		}
+		ret.Precision = ratio(tp, tp+fp)
+		ret.Recall = ratio(tp, tp+fn)
+		ret.F1 = f1(ret.Precision, ret.Recall)
+	case AverageWeighted:
+		total := 0
+		for _, m := range perClass {
+			total += m.Support
+			ret.Precision += m.Precision * float64(m.Support)
+			ret.Recall += m.Recall * float64(m.Support)
+			ret.F1 += m.F1 * float64(m.Support)
+		}
+		if total != 0 {
+			ret.Precision /= float64(total)
+			ret.Recall /= float64(total)
+			ret.F1 /= float64(total)
+		}
+	default:
+		if len(perClass) == 0 {
+			return ret
+		}
+		for _, m := range perClass {
+			ret.Precision += m.Precision
+			ret.Recall += m.Recall
+			ret.F1 += m.F1
+		}
+		ret.Precision /= float64(len(perClass))
+		ret.Recall /= float64(len(perClass))
+		ret.F1 /= float64(len(perClass))
+	}
+	return ret
+}
+
+// String - pretty print of matrix (rows - actual classes, columns - predicted ones)
+func (cm *ConfusionMatrix) String() string {
+	cells := make([][]string, len(cm.Counts)+1)
+	cells[0] = append(cells[0], "actual\\predicted")
+	for c := range cm.Counts {
+		cells[0] = append(cells[0], cm.label(c))
+	}
+	for c := range cm.Counts {
+		row := []string{cm.label(c)}
+		for _, v := range cm.Counts[c] {
+			row = append(row, fmt.Sprint(v))
+		}
+		cells[c+1] = row
+	}
+	return renderTable(cells)
+}
+
+// ClassificationReport - summary of classification quality
+type ClassificationReport struct {
+	Samples   int              `json:"Samples"`
+	Accuracy  float64          `json:"Accuracy"`
+	Classes   []ClassMetrics   `json:"Classes"`
+	Averages  []AverageMetrics `json:"Averages"`
+	Confusion *ConfusionMatrix `json:"Confusion"`
+}
+
+// Report - returns classification report (per-class metrics, macro/micro/weighted averages and confusion matrix)
+func (cm *ConfusionMatrix) Report() *ClassificationReport {
+	return &ClassificationReport{
+		Samples:  cm.Total(),
+		Accuracy: cm.Accuracy(),
+		Classes:  cm.PerClass(),
+		Averages: []AverageMetrics{
+			cm.Averages(AverageMacro),
+			cm.Averages(AverageMicro),
+			cm.Averages(AverageWeighted),
+		},
+		Confusion: cm,
+	}
+}
+
+// NewClassificationReport - builds classification report from outputs of network and targets. See NewConfusionMatrix
+func NewClassificationReport(outputs, targets [][]float64, labels []string) (*ClassificationReport, error) {
+	cm, err := NewConfusionMatrix(outputs, targets, len(labels))
+	if err != nil {
+		return nil, err
+	}
+	cm.Labels = labels
+	return cm.Report(), nil
+}
+
+// JSON - returns report as indented JSON
+func (cr *ClassificationReport) JSON() ([]byte, error) {
+	return json.MarshalIndent(cr, "", "    ")
+}
+
+// String - pretty print of report: per-class table, averages, accuracy and confusion matrix
+func (cr *ClassificationReport) String() string {
+	cells := [][]string{{"class", "precision", "recall", "f1", "support"}}
+	for _, m := range cr.Classes {
+		cells = append(cells, []string{m.Label, fmt.Sprintf("%.4f", m.Precision), fmt.Sprintf("%.4f", m.Recall), fmt.Sprintf("%.4f", m.F1), fmt.Sprint(m.Support)})
+	}
+	for _, a := range cr.Averages {
+		cells = append(cells, []string{a.Average + " avg", fmt.Sprintf("%.4f", a.Precision), fmt.Sprintf("%.4f", a.Recall), fmt.Sprintf("%.4f", a.F1), fmt.Sprint(cr.Samples)})
+	}
+	var sb strings.Builder
+	sb.WriteString(renderTable(cells))
+	sb.WriteString(fmt.Sprintf("accuracy: %.4f (%d samples)\n", cr.Accuracy, cr.Samples))
+	if cr.Confusion != nil {
+		sb.WriteString("confusion matrix:\n")
+		sb.WriteString(cr.Confusion.String())
+	}
+	return sb.String()
+}
+
+// renderTable - aligns cells by columns (first column to the left, others to the right)
+func renderTable(cells [][]string) string {
+	widths := []int{}
+	for _, row := range cells {
+		for i, cell := range row {
+			if i >= len(widths) {
+				widths = append(widths, 0)
+			}
+			if len(cell) > widths[i] {
+				widths[i] = len(cell)
+			}
+		}
+	}
+	var sb strings.Builder
+	for _, row := range cells {
+		for i, cell := range row {
+			if i == 0 {
+				sb.WriteString(fmt.Sprintf("%-*s", widths[i], cell))
+				continue
+			}
+			sb.WriteString(fmt.Sprintf("  %*s", widths[i], cell))
+		}
+		sb.WriteString("\n")
+	}
+	return sb.String()
+}
@@ -0,0 +1,245 @@
+// Package metrics provides classification metrics (accuracy, top-k accuracy, precision, recall, F1, confusion matrix) for outputs of network.
+//
+// Outputs and targets are passed as [][]float64 (one row per sample). Target can be one-hot vector (same length as output) or single class index.
+// Single-output networks are treated as binary classifiers with threshold 0.5.
+package metrics
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+)
+
+var (
+	// ErrSizesNotFit - number of outputs is not equal to number of targets
+	ErrSizesNotFit = errors.New("number of outputs not equal to number of targets")
+	// ErrEmpty - no samples
+	ErrEmpty = errors.New("no samples")
+)
+
+// Metric - metric evaluated over whole set of samples
+type Metric interface {
+	// Name - short name of metric (used as key in training metrics, e.g. "val_accuracy")
+	Name() string
+	// Compute - evaluates metric for given outputs of network and targets
+	Compute(outputs, targets [][]float64) (float64, error)
+}
+
+// Argmax - returns index of maximum value (first one if there are several)
+func Argmax(values []float64) int {
+	idx := 0
+	for i := range values {
+		if values[i] > values[idx] {
+			idx = i
+		}
+	}
+	return idx
+}
+
+// TopK - returns indices of k maximum values in descending order of values
+func TopK(values []float64, k int) []int {
+	idx := make([]int, len(values))
+	for i := range idx {
+		idx[i] = i
+	}
+	sort.SliceStable(idx, func(i, j int) bool {
+		return values[idx[i]] > values[idx[j]]
+	})
+	if k < len(idx) {
+		idx = idx[:k]
+	}
+	return idx
+}
+
+// PredictedClass - class predicted by output: index of maximum value or 0/1 (threshold 0.5) for single output
+func PredictedClass(output []float64) int {
+	if len(output) == 1 {
+		if output[0] >= 0.5 {
+			return 1
+		}
+		return 0
+	}
+	return Argmax(output)
+}
+
+// TargetClass - class of target: index of maximum value for one-hot vector, value itself for single class index (or 0/1 with threshold 0.5 for binary classifier)
+func TargetClass(output, target []float64) (int, error) {
+	if len(target) == 0 {
+		return 0, errors.New("empty target")
+	}
+	if len(target) == 1 {
+		if len(output) == 1 {
+			if target[0] >= 0.5 {
+				return 1, nil
+			}
+			return 0, nil
+		}
+		idx := int(target[0])
+		if float64(idx) != target[0] || idx < 0 || idx >= len(output) {
+			return 0, fmt.Errorf("target %v is not a valid class index for output of size %d", target[0], len(output))
+		}
+		return idx, nil
+	}
+	if len(target) != len(output) {
+		return 0, fmt.Errorf("size of target %d does not fit size of output %d", len(target), len(output))
+	}
+	return Argmax(target), nil
+}
+
+// Classes - returns actual and predicted classes of samples
+func Classes(outputs, targets [][]float64) ([]int, []int, error) {
+	if len(outputs) != len(targets) {
+		return nil, nil, ErrSizesNotFit
+	}
+	if len(outputs) == 0 {
+		return nil, nil, ErrEmpty
+	}
+	actual := make([]int, len(outputs))
+	predicted := make([]int, len(outputs))
+	for i := range outputs {
+		class, err := TargetClass(outputs[i], targets[i])
+		if err != nil {
+			return nil, nil, fmt.Errorf("sample #%d: %w", i, err)
+		}
+		actual[i] = class
+		predicted[i] = PredictedClass(outputs[i])
+	}
+	return actual, predicted, nil
+}
+
+// Accuracy - fraction of samples with correctly predicted class
+type Accuracy struct{}
+
+// Name - returns "accuracy"
+func (Accuracy) Name() string {
+	return "accuracy"
+}
+
+// Compute - see Metric
+func (Accuracy) Compute(outputs, targets [][]float64) (float64, error) {
+	actual, predicted, err := Classes(outputs, targets)
+	if err != nil {
+		return 0.0, err
+	}
+	correct := 0
+	for i := range actual {
+		if actual[i] == predicted[i] {
+			correct++
+		}
+	}
+	return float64(correct) / float64(len(actual)), nil
+}
+
+// TopKAccuracy - fraction of samples which class is among K classes with highest outputs
+type TopKAccuracy struct {
+	K int
+}
+
+// Name - returns "top_<K>_accuracy"
+func (tk TopKAccuracy) Name() string {
+	return fmt.Sprintf("top_%d_accuracy", tk.K)
+}
+
+// Compute - see Metric
+func (tk TopKAccuracy) Compute(outputs, targets [][]float64) (float64, error) {
+	if tk.K < 1 {
+		return 0.0, errors.New("K should be positive")
+	}
+	actual, _, err := Classes(outputs, targets)
+	if err != nil {
+		return 0.0, err
+	}
+	correct := 0
+	for i := range actual {
+		for _, class := range TopK(outputs[i], tk.K) {
+			if class == actual[i] {
+				correct++
+				break
+			}
+		}
+	}
+	return float64(correct) / float64(len(actual)), nil
+}
+
+// Average - how per-class values are averaged
+type Average int
+
+const (
+	// AverageMacro - unweighted mean of per-class values
+	AverageMacro = Average(iota)
+	// AverageMicro - value evaluated from total true positives, false positives and false negatives
+	AverageMicro
+	// AverageWeighted - mean of per-class values weighted by support (number of samples of class)
+	AverageWeighted
+)
+
+// String - returns "macro", "micro" or "weighted"
+func (a Average) String() string {
+	switch a {
+	case AverageMacro:
+		return "macro"
+	case AverageMicro:
+		return "micro"
+	case AverageWeighted:
+		return "weighted"
+	default:
+		return fmt.Sprintf("Average(%d)", int(a))
+	}
+}
+
+// Precision - averaged precision TP / (TP + FP)
+type Precision struct {
+	Average Average
+}
+
+// Name - returns "precision_<average>"
+func (p Precision) Name() string {
+	return "precision_" + p.Average.String()
+}
+
+// Compute - see Metric
+func (p Precision) Compute(outputs, targets [][]float64) (float64, error) {
+	cm, err := NewConfusionMatrix(outputs, targets, 0)
+	if err != nil {
+		return 0.0, err
+	}
+	return cm.Averages(p.Average).Precision, nil
+}
+
+// Recall - averaged recall TP / (TP + FN)
+type Recall struct {
+	Average Average
+}
+
+// Name - returns "recall_<average>"
+func (r Recall) Name() string {
+	return "recall_" + r.Average.String()
+}
+
+// Compute - see Metric
+func (r Recall) Compute(outputs, targets [][]float64) (float64, error) {
+	cm, err := NewConfusionMatrix(outputs, targets, 0)
+	if err != nil {
+		return 0.0, err
+	}
+	return cm.Averages(r.Average).Recall, nil
+}
+
+// F1 - averaged F1 score (harmonic mean of precision and recall)
+type F1 struct {
+	Average Average
+}
+
+// Name - returns "f1_<average>"
+func (f F1) Name() string {
+	return "f1_" + f.Average.String()
+}
+
+// Compute - see Metric
+func (f F1) Compute(outputs, targets [][]float64) (float64, error) {
+	cm, err := NewConfusionMatrix(outputs, targets, 0)
+	if err != nil {
+		return 0.0, err
+	}
+	return cm.Averages(f.Average).F1, nil
+}
@@ -0,0 +1,113 @@
+package metrics
+
+import (
+	"encoding/json"
+	"math"
+	"strings"
+	"testing"
+)
+
+var (
+	testOutputs = [][]float64{
+		{0.8, 0.1, 0.1},
+		{0.2, 0.7, 0.1},
+		{0.1, 0.5, 0.4},
+		{0.3, 0.3, 0.4},
+		{0.6, 0.3, 0.1},
+		{0.1, 0.2, 0.7},
+	}
+	// Index targets
+	testTargets = [][]float64{{0}, {1}, {2}, {2}, {1}, {2}}
+)
+
+func TestAccuracy(t *testing.T) {
+	accuracy, err := Accuracy{}.Compute(testOutputs, testTargets)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	if math.Abs(accuracy-4.0/6.0) > 1e-12 {
+		t.Errorf("Accuracy is wrong. Expected value: %f. Got: %f", 4.0/6.0, accuracy)
+	}
+	// One-hot targets should give same result
+	oneHot := make([][]float64, len(testTargets))
+	for i := range testTargets {
+		oneHot[i] = make([]float64, 3)
+		oneHot[i][int(testTargets[i][0])] = 1
+	}
+	accuracyOneHot, err := Accuracy{}.Compute(testOutputs, oneHot)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	if accuracyOneHot != accuracy {
+		t.Errorf("Accuracy for one-hot targets is wrong. Expected value: %f. Got: %f", accuracy, accuracyOneHot)
+	}
+	top2, err := TopKAccuracy{K: 2}.Compute(testOutputs, testTargets)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	if math.Abs(top2-1.0) > 1e-12 {
+		t.Errorf("Top-2 accuracy is wrong. Expected value: %f. Got: %f", 1.0, top2)
+	}
+}
+
+func TestConfusionMatrix(t *testing.T) {
+	cm, err := NewConfusionMatrix(testOutputs, testTargets, 0)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	correct := [][]int{
+		{1, 0, 0},
+		{1, 1, 0},
+		{0, 1, 2},
+	}
+	for i := range correct {
+		for j := range correct[i] {
+			if cm.Counts[i][j] != correct[i][j] {
+				t.Errorf("Count [%d][%d] is wrong. Expected value: %d. Got: %d", i, j, correct[i][j], cm.Counts[i][j])
+			}
+		}
+	}
+	perClass := cm.PerClass()
+	// Class 0: TP = 1, FP = 1, FN = 0
+	if perClass[0].Precision != 0.5 || perClass[0].Recall != 1.0 || math.Abs(perClass[0].F1-2.0/3.0) > 1e-12 {
+		t.Errorf("Metrics of class 0 are wrong: %+v", perClass[0])
+	}
+	macro := cm.Averages(AverageMacro)
+	expectedMacroPrecision := (0.5 + 0.5 + 1.0) / 3.0
+	if math.Abs(macro.Precision-expectedMacroPrecision) > 1e-12 {
+		t.Errorf("Macro precision is wrong. Expected value: %f. Got: %f", expectedMacroPrecision, macro.Precision)
+	}
+	// Micro precision is equal to accuracy for single-label classification
+	micro := cm.Averages(AverageMicro)
+	if math.Abs(micro.Precision-cm.Accuracy()) > 1e-12 || math.Abs(micro.F1-cm.Accuracy()) > 1e-12 {
+		t.Errorf("Micro averages are wrong. Expected value: %f. Got: %+v", cm.Accuracy(), micro)
+	}
+
+	report, err := NewClassificationReport(testOutputs, testTargets, []string{"X", "T", "O"})
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	text := report.String()
+	if !strings.Contains(text, "macro avg") || !strings.Contains(text, "accuracy: 0.6667") {
+		t.Errorf("Text report is wrong:\n%s", text)
+	}
+	data, err := report.JSON()
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	decoded := ClassificationReport{}
+	err = json.Unmarshal(data, &decoded)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	if decoded.Samples != 6 || decoded.Classes[2].Label != "O" || decoded.Confusion.Counts[2][2] != 2 {
+		t.Errorf("Decoded report is wrong: %+v", decoded)
+	}
+}
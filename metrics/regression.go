@@ -0,0 +1,166 @@
+package metrics
+
+import (
+	"fmt"
+	"math"
+)
+
+// flatten - checks sizes and returns pairs of output and target elements of all samples
+func flatten(outputs, targets [][]float64) ([]float64, []float64, error) {
+	if len(outputs) != len(targets) {
+		return nil, nil, ErrSizesNotFit
+	}
+	var o, t []float64
+	for i := range outputs {
+		if len(outputs[i]) != len(targets[i]) {
+			return nil, nil, fmt.Errorf("sample #%d: size of target %d does not fit size of output %d", i, len(targets[i]), len(outputs[i]))
+		}
+		o = append(o, outputs[i]...)
+		t = append(t, targets[i]...)
+	}
+	if len(o) == 0 {
+		return nil, nil, ErrEmpty
+	}
+	return o, t, nil
+}
+
+// mean - mean of values
+func mean(values []float64) float64 {
+	sum := 0.0
+	for _, v := range values {
+		sum += v
+	}
+	return sum / float64(len(values))
+}
+
+// variance - population variance of values
+func variance(values []float64) float64 {
+	m := mean(values)
+	sum := 0.0
+	for _, v := range values {
+		sum += (v - m) * (v - m)
+	}
+	return sum / float64(len(values))
+}
+
+// RMSE - root mean square error over all output elements
+type RMSE struct{}
+
+// Name - returns "rmse"
+func (RMSE) Name() string {
+	return "rmse"
+}
+
+// Compute - see Metric
+func (RMSE) Compute(outputs, targets [][]float64) (float64, error) {
+	o, t, err := flatten(outputs, targets)
+	if err != nil {
+		return 0.0, err
+	}
+	sum := 0.0
+	for i := range o {
+		sum += (o[i] - t[i]) * (o[i] - t[i])
+	}
+	return math.Sqrt(sum / float64(len(o))), nil
+}
+
+// MAE - mean absolute error over all output elements
+type MAE struct{}
+
+// Name - returns "mae"
+func (MAE) Name() string {
+	return "mae"
+}
+
+// Compute - see Metric
+func (MAE) Compute(outputs, targets [][]float64) (float64, error) {
+	o, t, err := flatten(outputs, targets)
+	if err != nil {
+		return 0.0, err
+	}
+	sum := 0.0
+	for i := range o {
+		sum += math.Abs(o[i] - t[i])
+	}
+	return sum / float64(len(o)), nil
+}
+
+// R2 - coefficient of determination: 1 - SS_res / SS_tot
+type R2 struct{}
+
+// Name - returns "r2"
+func (R2) Name() string {
+	return "r2"
+}
+
+// Compute - see Metric. Returns error if variance of targets is zero
+func (R2) Compute(outputs, targets [][]float64) (float64, error) {
+	o, t, err := flatten(outputs, targets)
+	if err != nil {
+		return 0.0, err
+	}
+	m := mean(t)
+	ssRes, ssTot := 0.0, 0.0
+	for i := range o {
+		ssRes += (t[i] - o[i]) * (t[i] - o[i])
+		ssTot += (t[i] - m) * (t[i] - m)
+	}
+	if ssTot == 0 {
+		return 0.0, fmt.Errorf("R2 is undefined for constant targets")
+	}
+	return 1 - ssRes/ssTot, nil
+}
+
+// MAPE - mean absolute percentage error: mean(|target - output| / |target|) * 100. Elements with zero target are skipped
+type MAPE struct{}
+
+// Name - returns "mape"
+func (MAPE) Name() string {
+	return "mape"
+}
+
+// Compute - see Metric. Returns error if all targets are zero
+func (MAPE) Compute(outputs, targets [][]float64) (float64, error) {
+	o, t, err := flatten(outputs, targets)
+	if err != nil {
+		return 0.0, err
+	}
+	sum := 0.0
+	n := 0
+	for i := range o {
+		if t[i] == 0 {
+			continue
+		}
+		sum += math.Abs((t[i] - o[i]) / t[i])
+		n++
+	}
+	if n == 0 {
+		return 0.0, fmt.Errorf("MAPE is undefined for zero targets")
+	}
+	return sum / float64(n) * 100, nil
+}
+
+// ExplainedVariance - explained variance score: 1 - Var(target - output) / Var(target)
+type ExplainedVariance struct{}
+
+// Name - returns "explained_variance"
+func (ExplainedVariance) Name() string {
+	return "explained_variance"
+}
+
+// Compute - see Metric. Returns error if variance of targets is zero
+func (ExplainedVariance) Compute(outputs, targets [][]float64) (float64, error) {
+	o, t, err := flatten(outputs, targets)
+	if err != nil {
+		return 0.0, err
+	}
+	varTarget := variance(t)
+	if varTarget == 0 {
+		return 0.0, fmt.Errorf("Explained variance is undefined for constant targets")
+	}
+	residuals := make([]float64, len(o))
+	for i := range o {
+		residuals[i] = t[i] - o[i]
+	}
+	return 1 - variance(residuals)/varTarget, nil
+}
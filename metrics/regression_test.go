@@ -0,0 +1,36 @@
+package metrics
+
+import (
+	"math"
+	"testing"
+)
+
+func TestRegressionMetrics(t *testing.T) {
+	outputs := [][]float64{{2.5}, {0.0}, {2.0}, {8.0}}
+	targets := [][]float64{{3.0}, {-0.5}, {2.0}, {7.0}}
+	// Reference values are taken from scikit-learn
+	correct := []struct {
+		metric   Metric
+		expected float64
+	}{
+		{RMSE{}, math.Sqrt(0.375)},
+		{MAE{}, 0.5},
+		{R2{}, 0.9486081370449679},
+		{MAPE{}, 32.73809523809524},
+		{ExplainedVariance{}, 0.9571734475374732},
+	}
+	for _, c := range correct {
+		got, err := c.metric.Compute(outputs, targets)
+		if err != nil {
+			t.Error(err)
+			continue
+		}
+		if math.Abs(got-c.expected) > 1e-12 {
+			t.Errorf("%s is wrong. Expected value: %f. Got: %f", c.metric.Name(), c.expected, got)
+		}
+	}
+	_, err := R2{}.Compute(outputs, [][]float64{{1}, {1}, {1}, {1}})
+	if err == nil {
+		t.Errorf("R2 should fail for constant targets")
+	}
+}
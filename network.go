@@ -5,19 +5,25 @@ import (
 	"errors"
 	"fmt"
 	"io/ioutil"
-	"strings"
 
 	"github.com/LdDl/cnns/tensor"
 )
 
-// WholeNet - net itself (array of layers)
-type WholeNet struct {
-	Layers []Layer
+// WholeNetOf - net itself (array of layers). T is precision of layers: float32 or float64
+type WholeNetOf[T tensor.Float] struct {
+	Layers []LayerOf[T]
 	LP     LearningParams
+	// Preprocessing - fitted preprocessing of raw inputs (e.g. see datasets.TabularPreprocessor). It is stored in exported file as is, so inference can apply the same transformation
+	Preprocessing json.RawMessage
+	// Preallocated buffers for training. See Backpropagate.
+	arena *ArenaOf[T]
 }
 
+// WholeNet - net of float64 precision
+type WholeNet = WholeNetOf[float64]
+
 // FeedForward - forward pass through the net
-func (wh *WholeNet) FeedForward(t *tensor.Tensor) {
+func (wh *WholeNetOf[T]) FeedForward(t *tensor.TensorOf[T]) {
 	wh.Layers[0].FeedForward(t)
 	for l := 1; l < len(wh.Layers); l++ {
 		out := wh.Layers[l-1].GetOutput()
@@ -26,34 +32,121 @@ func (wh *WholeNet) FeedForward(t *tensor.Tensor) {
 }
 
 // Backpropagate - backward pass through the net (training)
-func (wh *WholeNet) Backpropagate(target *tensor.Tensor) error {
-	lastLayer := wh.Layers[len(wh.Layers)-1].GetOutput()
-
-	difference, err := lastLayer.Sub(target)
+func (wh *WholeNetOf[T]) Backpropagate(target *tensor.TensorOf[T]) error {
+	err := wh.calculateGradients(target, nil)
 	if err != nil {
 		return err
 	}
-	wh.Layers[len(wh.Layers)-1].CalculateGradients(difference)
+	for i := range wh.Layers {
+		wh.Layers[i].UpdateWeights()
+	}
+	return nil
+}
+
+// calculateGradients - backward pass through the net without updating weights. Gradient of MSE is used if loss is nil.
+func (wh *WholeNetOf[T]) calculateGradients(target *tensor.TensorOf[T], loss Loss) error {
+	lastLayer := wh.Layers[len(wh.Layers)-1].GetOutput()
+
+	if wh.arena == nil || !wh.arena.Difference.IsEqualDims(lastLayer) {
+		wh.arena = NewArena(wh.Layers)
+	}
+	difference := wh.arena.Difference
+	if loss == nil {
+		err := tensor.SubInto(difference, lastLayer, target)
+		if err != nil {
+			return err
+		}
+	} else {
+		if !lastLayer.IsEqualDims(target) {
+			return tensor.ErrDimensionsNotFit
+		}
+		for i := range difference.Data {
+			difference.Data[i] = T(loss.Derivative(float64(lastLayer.Data[i]), float64(target.Data[i])))
+		}
+	}
+	wh.backward(difference)
+	return nil
+}
+
+// backward - passes gradient of net's output through every layer
+func (wh *WholeNetOf[T]) backward(outputGradient *tensor.TensorOf[T]) {
+	wh.Layers[len(wh.Layers)-1].CalculateGradients(outputGradient)
 	for i := len(wh.Layers) - 2; i >= 0; i-- {
 		grad := wh.Layers[i+1].GetGradients()
 		wh.Layers[i].CalculateGradients(grad)
 	}
-	for i := range wh.Layers {
-		wh.Layers[i].UpdateWeights()
+}
+
+// BackpropagateGradient - backward pass of arbitrary gradient of net's output without updating weights (e.g. for saliency maps). Net should be fed with input before.
+/*
+	outputGradient - gradient with respect to output of the last layer (same size as output).
+	Returns gradient with respect to input of the net. Gradient with respect to output of layer i is GetGradients() of layer i+1.
+*/
+func (wh *WholeNetOf[T]) BackpropagateGradient(outputGradient *tensor.TensorOf[T]) (*tensor.TensorOf[T], error) {
+	if len(wh.Layers) == 0 {
+		return nil, errors.New("network has no layers")
 	}
-	return nil
+	if !wh.GetOutput().IsEqualDims(outputGradient) {
+		return nil, tensor.ErrDimensionsNotFit
+	}
+	wh.backward(outputGradient)
+	return wh.Layers[0].GetGradients(), nil
 }
 
 // PrintOutput - prints net's output (last layer output)
-func (wh *WholeNet) PrintOutput() {
+func (wh *WholeNetOf[T]) PrintOutput() {
 	wh.Layers[len(wh.Layers)-1].PrintOutput()
 }
 
 // GetOutput - returns net's output (last layer output)
-func (wh *WholeNet) GetOutput() *tensor.Tensor {
+func (wh *WholeNetOf[T]) GetOutput() *tensor.TensorOf[T] {
 	return wh.Layers[len(wh.Layers)-1].GetOutput()
 }
 
+// weightTensors - returns trainable weights of convolutional and fully connected layers in order of layers
+func (wh *WholeNetOf[T]) weightTensors() []*tensor.TensorOf[T] {
+	var ret []*tensor.TensorOf[T]
+	for l := range wh.Layers {
+		switch typed := wh.Layers[l].(type) {
+		case *ConvLayerOf[T]:
+			ret = append(ret, typed.Kernels...)
+		case *FullyConnectedLayerOf[T]:
+			ret = append(ret, typed.Weights)
+		}
+	}
+	return ret
+}
+
+// snapshotWeights - copies trainable weights into dst (allocated if it does not fit) and returns it
+func (wh *WholeNetOf[T]) snapshotWeights(dst [][]T) [][]T {
+	weights := wh.weightTensors()
+	if len(dst) != len(weights) {
+		dst = make([][]T, len(weights))
+	}
+	for i := range weights {
+		if len(dst[i]) != len(weights[i].Data) {
+			dst[i] = make([]T, len(weights[i].Data))
+		}
+		copy(dst[i], weights[i].Data)
+	}
+	return dst
+}
+
+// restoreWeights - copies snapshot made by snapshotWeights back into layers
+func (wh *WholeNetOf[T]) restoreWeights(src [][]T) error {
+	weights := wh.weightTensors()
+	if len(src) != len(weights) {
+		return fmt.Errorf("Snapshot contains %d weight tensors, but net has %d", len(src), len(weights))
+	}
+	for i := range weights {
+		if len(src[i]) != len(weights[i].Data) {
+			return fmt.Errorf("Snapshot of weights #%d has size %d, but layer has %d", i, len(src[i]), len(weights[i].Data))
+		}
+		copy(weights[i].Data, src[i])
+	}
+	return nil
+}
+
 // ImportFromFile load network to file
 /*
 	fname - filename,
@@ -61,7 +154,7 @@ func (wh *WholeNet) GetOutput() *tensor.Tensor {
 		true: random weights for new network
 		false: weights from files for using network (or continue training))
 */
-func (wh *WholeNet) ImportFromFile(fname string, randomWeights bool) error {
+func (wh *WholeNetOf[T]) ImportFromFile(fname string, randomWeights bool) error {
 	var err error
 	fileBytes, err := ioutil.ReadFile(fname)
 	if err != nil {
@@ -72,6 +165,15 @@ func (wh *WholeNet) ImportFromFile(fname string, randomWeights bool) error {
 	if err != nil {
 		return err
 	}
+	return wh.fromJSON(&data, randomWeights)
+}
+
+// fromJSON - append layers described by JSON representation to the net. Weights are converted to precision of the net.
+func (wh *WholeNetOf[T]) fromJSON(data *NetJSON, randomWeights bool) error {
+	var err error
+	if data.Precision == PrecisionInt8 && !randomWeights {
+		return errors.New("Network is quantized, use ImportQuantizedFromFile to load it")
+	}
 	for i := range data.Network.Layers {
 		switch data.Network.Layers[i].LayerType {
 		case "conv":
@@ -81,12 +183,12 @@ func (wh *WholeNet) ImportFromFile(fname string, randomWeights bool) error {
 			x := data.Network.Layers[i].InputSize.X
 			y := data.Network.Layers[i].InputSize.Y
 			z := data.Network.Layers[i].InputSize.Z
-			conv := NewConvLayer(stride, kernelSize, numOfFilters, tensor.TDsize{X: x, Y: y, Z: z})
+			conv := NewConvLayerOf[T](stride, kernelSize, numOfFilters, tensor.TDsize{X: x, Y: y, Z: z})
 			if randomWeights == false {
-				var weights = make([]*tensor.Tensor, numOfFilters)
+				var weights = make([]*tensor.TensorOf[T], numOfFilters)
 				for w := 0; w < numOfFilters; w++ {
-					weights[w] = tensor.NewTensor(kernelSize, kernelSize, 1)
-					weights[w].SetData3D(data.Network.Layers[i].Weights[w].Data)
+					weights[w] = tensor.NewTensorOf[T](kernelSize, kernelSize, 1)
+					weights[w].SetData3D(tensor.ConvertData3D[T](data.Network.Layers[i].Weights[w].Data))
 				}
 				conv.SetCustomWeights(weights)
 			}
@@ -96,7 +198,7 @@ func (wh *WholeNet) ImportFromFile(fname string, randomWeights bool) error {
 			x := data.Network.Layers[i].InputSize.X
 			y := data.Network.Layers[i].InputSize.Y
 			z := data.Network.Layers[i].InputSize.Z
-			relu := NewReLULayer(&tensor.TDsize{X: x, Y: y, Z: z})
+			relu := NewReLULayerOf[T](&tensor.TDsize{X: x, Y: y, Z: z})
 			wh.Layers = append(wh.Layers, relu)
 			break
 		case "pool":
@@ -105,7 +207,7 @@ func (wh *WholeNet) ImportFromFile(fname string, randomWeights bool) error {
 			x := data.Network.Layers[i].InputSize.X
 			y := data.Network.Layers[i].InputSize.Y
 			z := data.Network.Layers[i].InputSize.Z
-			pool := NewMaxPoolingLayer(stride, kernelSize, &tensor.TDsize{X: x, Y: y, Z: z})
+			pool := NewMaxPoolingLayerOf[T](stride, kernelSize, &tensor.TDsize{X: x, Y: y, Z: z})
 			wh.Layers = append(wh.Layers, pool)
 			break
 		case "fc":
@@ -113,12 +215,12 @@ func (wh *WholeNet) ImportFromFile(fname string, randomWeights bool) error {
 			y := data.Network.Layers[i].InputSize.Y
 			z := data.Network.Layers[i].InputSize.Z
 			outSize := data.Network.Layers[i].OutputSize.X
-			fullyconnected := NewFullyConnectedLayer(&tensor.TDsize{X: x, Y: y, Z: z}, outSize)
+			fullyconnected := NewFullyConnectedLayerOf[T](&tensor.TDsize{X: x, Y: y, Z: z}, outSize)
 			if randomWeights == false {
-				var weights *tensor.Tensor
-				weights = tensor.NewTensor(x*y*z, outSize, 1)
-				weights.SetData3D(data.Network.Layers[i].Weights[0].Data)
-				fullyconnected.SetCustomWeights([]*tensor.Tensor{weights})
+				var weights *tensor.TensorOf[T]
+				weights = tensor.NewTensorOf[T](x*y*z, outSize, 1)
+				weights.SetData3D(tensor.ConvertData3D[T](data.Network.Layers[i].Weights[0].Data))
+				fullyconnected.SetCustomWeights([]*tensor.TensorOf[T]{weights})
 			}
 			wh.Layers = append(wh.Layers, fullyconnected)
 			break
@@ -131,13 +233,37 @@ func (wh *WholeNet) ImportFromFile(fname string, randomWeights bool) error {
 	wh.LP.LearningRate = data.Parameters.LearningRate
 	wh.LP.Momentum = data.Parameters.Momentum
 	wh.LP.WeightDecay = data.Parameters.WeightDecay
+	wh.Preprocessing = data.Preprocessing
 	return err
 }
 
 // ExportToFile saves network to file
-func (wh *WholeNet) ExportToFile(fname string) error {
+func (wh *WholeNetOf[T]) ExportToFile(fname string) error {
+	var err error
+	save, err := wh.toJSON()
+	if err != nil {
+		return err
+	}
+
+	saveJSON, err := json.Marshal(save)
+	if err != nil {
+		return err
+	}
+
+	err = ioutil.WriteFile(fname, saveJSON, 0644)
+	if err != nil {
+		return err
+	}
+
+	return err
+}
+
+// toJSON - returns JSON representation of the net. Weights are stored as float64 regardless of net's precision, precision itself is stored as tag.
+func (wh *WholeNetOf[T]) toJSON() (*NetJSON, error) {
 	var err error
 	var save NetJSON
+	save.Precision = tensor.Precision[T]()
+	save.Preprocessing = wh.Preprocessing
 
 	for i := 0; i < len(wh.Layers); i++ {
 		switch wh.Layers[i].GetType() {
@@ -151,7 +277,7 @@ func (wh *WholeNet) ExportToFile(fname string) error {
 			newLayer.Weights = make([]TensorJSON, len(kernels))
 			for k := range kernels {
 				newLayer.Weights[k].TDSize = kernels[k].Size
-				newLayer.Weights[k].Data = kernels[k].GetData3D()
+				newLayer.Weights[k].Data = tensor.ConvertData3D[float64](kernels[k].GetData3D())
 			}
 			save.Network.Layers = append(save.Network.Layers, newLayer)
 			break
@@ -178,16 +304,16 @@ func (wh *WholeNet) ExportToFile(fname string) error {
 			kernels := wh.Layers[i].GetWeights()
 			if len(kernels) != 1 {
 				err = fmt.Errorf("Fully connected layer can have only 1 'kernel'")
-				return err
+				return nil, err
 			}
 			newLayer.Weights[0].TDSize = kernels[0].Size
-			newLayer.Weights[0].Data = kernels[0].GetData3D()
+			newLayer.Weights[0].Data = tensor.ConvertData3D[float64](kernels[0].GetData3D())
 
 			save.Network.Layers = append(save.Network.Layers, newLayer)
 			break
 		default:
 			err = fmt.Errorf("Unrecognized layer type: %v", wh.Layers[i].GetType())
-			return err
+			return nil, err
 		}
 	}
 
@@ -196,23 +322,48 @@ func (wh *WholeNet) ExportToFile(fname string) error {
 	save.Parameters.Momentum = 0.6
 	save.Parameters.WeightDecay = 0.001
 
-	saveJSON, err := json.Marshal(save)
+	return &save, nil
+}
+
+// ConvertNet - returns copy of the net with weights converted to another precision. Usage: cnns.ConvertNet[float32](&net)
+/*
+	Conversion goes through the same representation as ExportToFile/ImportFromFile does,
+	activation functions of fully connected layers are copied also.
+*/
+func ConvertNet[To, From tensor.Float](wh *WholeNetOf[From]) (*WholeNetOf[To], error) {
+	data, err := wh.toJSON()
 	if err != nil {
-		return err
+		return nil, err
 	}
-
-	err = ioutil.WriteFile(fname, saveJSON, 0644)
+	ret := &WholeNetOf[To]{}
+	err = ret.fromJSON(data, false)
 	if err != nil {
-		return err
+		return nil, err
 	}
-
-	return err
+	ret.LP = wh.LP
+	for i := range wh.Layers {
+		fc, ok := wh.Layers[i].(*FullyConnectedLayerOf[From])
+		if !ok {
+			continue
+		}
+		ret.Layers[i].SetActivationFunc(fc.ActivationFunc)
+		ret.Layers[i].SetActivationDerivativeFunc(fc.ActivationDerivative)
+	}
+	return ret, nil
 }
 
 // NetJSON - json representation of network structure (for import and export)
+/*
+	Precision - precision of the net which has been exported ("float32", "float64" or "int8"). Weights are stored as float64 always for float nets;
+	Quantized - int8 weights and scales for quantized net (see QuantizedNet.ExportToFile);
+	Preprocessing - fitted preprocessing of inputs (see WholeNetOf.Preprocessing).
+*/
 type NetJSON struct {
-	Network    NetworkJSON    `json:"Network"`
-	Parameters LearningParams `json:"Parameters"`
+	Network       NetworkJSON     `json:"Network"`
+	Parameters    LearningParams  `json:"Parameters"`
+	Precision     string          `json:"Precision,omitempty"`
+	Quantized     *QuantizedNet   `json:"Quantized,omitempty"`
+	Preprocessing json.RawMessage `json:"Preprocessing,omitempty"`
 }
 
 // TensorJSON ...
@@ -242,88 +393,3 @@ type NetLayerJSON struct {
 type NetworkJSON struct {
 	Layers []NetLayerJSON `json:"Layers"`
 }
-
-// GetGraphvizText Returns Graphviz text-based output
-func (wh *WholeNet) GetGraphvizText() string {
-	graph := "digraph G {rankdir = LR;splines=false;edge[style=invis];ranksep= 1.4;"
-
-	if len(wh.Layers) == 0 {
-		return ""
-	}
-	inputSize := wh.Layers[0].GetInputSize()
-	inputVertices := []string{}
-	inputVerticesLabels := []string{}
-	inputNodeProperties := "node [shape=circle, color=chartreuse, style=filled, fillcolor=chartreuse]"
-	for x := 0; x < inputSize.X; x++ {
-		vertex := fmt.Sprintf("x%[1]d [label=<x<sub>%[1]d</sub><sup>(0)</sup>>]", x)
-		inputVerticesLabels = append(inputVerticesLabels, vertex)
-		inputVertices = append(inputVertices, fmt.Sprintf("x%[1]d", x))
-	}
-	inputLayerVertices := fmt.Sprintf("{%s}", strings.Join(inputVertices, ";"))
-	inputNodeProperties = fmt.Sprintf("{%s;%s;}", inputNodeProperties, strings.Join(inputVerticesLabels, ";"))
-	inputRankProperties := fmt.Sprintf("{rank=same;%s;}", strings.Join(inputVertices, "->"))
-	inputLayerProperties := fmt.Sprintf("l_input [shape=plaintext, label=\"Input layer\"];")
-	inputLayerRankProperties := fmt.Sprintf("{rank=same; l_input;%[1]s};", inputVertices[0])
-	graph += inputNodeProperties
-	graph += inputRankProperties
-	graph += inputLayerProperties
-	graph += inputLayerRankProperties
-
-	layersVertices := []string{}
-
-	for l := range wh.Layers {
-		nodeProperties := ""
-
-		vertices := []string{}
-		verticesLabels := []string{}
-		layerType := ""
-
-		size := wh.Layers[l].GetOutput().Size
-		switch l {
-		case len(wh.Layers) - 1:
-			nodeProperties = "node [shape=circle, color=coral1, style=filled, fillcolor=coral1]"
-			for x := 0; x < size.X; x++ {
-				vertex := fmt.Sprintf("O%[1]d [label=<o<sub>%[1]d</sub><sup>(%[2]d)</sup>>]", x, l)
-				verticesLabels = append(verticesLabels, vertex)
-				vertices = append(vertices, fmt.Sprintf("O%[1]d", x))
-			}
-			layerType = "output"
-			break
-		default:
-			nodeProperties = "node [shape=circle, color=dodgerblue, style=filled, fillcolor=dodgerblue]"
-			for x := 0; x < size.X; x++ {
-				vertex := fmt.Sprintf("h%[1]d%[2]d [label=<h<sub>%[1]d</sub><sup>(%[2]d)</sup>>]", x, l)
-				verticesLabels = append(verticesLabels, vertex)
-				vertices = append(vertices, fmt.Sprintf("h%[1]d%[2]d", x, l))
-			}
-			layerType = "hidden"
-			break
-		}
-
-		nodeProperties = fmt.Sprintf("{%s;%s;}", nodeProperties, strings.Join(verticesLabels, ";"))
-		rankProperties := fmt.Sprintf("{rank=same;%s;}", strings.Join(vertices, "->"))
-		layerProperties := fmt.Sprintf("l%[1]d [shape=plaintext, label=\"layer %[1]d (%[2]s layer)\"];", l, layerType)
-		layerRankProperties := fmt.Sprintf("{rank=same; l%[1]d;%[2]s};", l, vertices[0])
-
-		layerVertices := fmt.Sprintf("{%s}", strings.Join(vertices, ";"))
-		layersVertices = append(layersVertices, layerVertices)
-
-		graph += nodeProperties
-		graph += rankProperties
-		graph += layerProperties
-		graph += layerRankProperties
-	}
-
-	edgesStyle := "edge[style=solid, tailport=e, headport=w];"
-	graph += edgesStyle
-	inputEdges := fmt.Sprintf("%[1]s -> %[2]s;", inputLayerVertices, layersVertices[0])
-	graph += inputEdges
-	for l := 1; l < len(layersVertices); l++ {
-		edges := fmt.Sprintf("%[1]s -> %[2]s;", layersVertices[l-1], layersVertices[l])
-		graph += edges
-	}
-
-	graph += "}"
-
-	return graph
-}
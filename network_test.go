@@ -0,0 +1,87 @@
+package cnns
+
+import (
+	"math"
+	"math/rand"
+	"testing"
+
+	"github.com/LdDl/cnns/tensor"
+)
+
+func TestBackpropagateGradient(t *testing.T) {
+	rand.Seed(3)
+	conv := NewConvLayer(1, 3, 2, tensor.TDsize{X: 5, Y: 5, Z: 1})
+	relu := NewReLULayer(conv.GetOutputSize())
+	fc := NewFullyConnectedLayer(relu.GetOutputSize(), 3)
+	net := WholeNet{Layers: []Layer{conv, relu, fc}}
+
+	input := tensor.NewTensor(5, 5, 1)
+	for i := range input.Data {
+		input.Data[i] = rand.Float64()
+	}
+	weights := net.snapshotWeights(nil)
+
+	class := 1
+	outputGrad := tensor.NewTensor(3, 1, 1)
+	outputGrad.Data[class] = 1
+	net.FeedForward(input)
+	grad, err := net.BackpropagateGradient(outputGrad)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	weightGrads := [][]*tensor.Tensor{WeightGradients(conv), WeightGradients(relu), WeightGradients(fc)}
+	if weightGrads[1] != nil {
+		t.Errorf("ReLU layer should have no weight gradients")
+	}
+	after := net.snapshotWeights(nil)
+	for i := range weights {
+		for j := range weights[i] {
+			if weights[i][j] != after[i][j] {
+				t.Errorf("Weights should not be changed")
+				return
+			}
+		}
+	}
+
+	// Compare with numerical gradient
+	eps := 1e-6
+	for i := range input.Data {
+		orig := input.Data[i]
+		input.Data[i] = orig + eps
+		net.FeedForward(input)
+		plus := net.GetOutput().Data[class]
+		input.Data[i] = orig - eps
+		net.FeedForward(input)
+		minus := net.GetOutput().Data[class]
+		input.Data[i] = orig
+		numerical := (plus - minus) / (2 * eps)
+		if math.Abs(numerical-grad.Data[i]) > 1e-5 {
+			t.Errorf("Gradient #%d is wrong. Expected value: %f. Got: %f", i, numerical, grad.Data[i])
+		}
+	}
+
+	for _, l := range []int{0, 2} {
+		w := net.Layers[l].GetWeights()
+		for k := range w {
+			for i := range w[k].Data {
+				orig := w[k].Data[i]
+				w[k].Data[i] = orig + eps
+				net.FeedForward(input)
+				plus := net.GetOutput().Data[class]
+				w[k].Data[i] = orig - eps
+				net.FeedForward(input)
+				minus := net.GetOutput().Data[class]
+				w[k].Data[i] = orig
+				numerical := (plus - minus) / (2 * eps)
+				if math.Abs(numerical-weightGrads[l][k].Data[i]) > 1e-5 {
+					t.Errorf("Gradient of weight #%d of layer #%d is wrong. Expected value: %f. Got: %f", i, l, numerical, weightGrads[l][k].Data[i])
+				}
+			}
+		}
+	}
+
+	if _, err = net.BackpropagateGradient(tensor.NewTensor(2, 1, 1)); err == nil {
+		t.Errorf("Gradient of wrong size should produce error")
+	}
+}
@@ -48,3 +48,8 @@ func SetL2Decay(v float64) error {
 	lp.Momentum = v
 	return nil
 }
+
+// GetLearningParams - returns current parameters for training (see SetEta, SetMomentum and SetL2Decay)
+func GetLearningParams() LearningParams {
+	return lp
+}
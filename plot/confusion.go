@@ -0,0 +1,105 @@
+package plot
+
+import (
+	"fmt"
+	"io"
+	"math"
+	"strconv"
+
+	"github.com/LdDl/cnns/metrics"
+	svg "github.com/ajstarks/svgo"
+)
+
+// cmLabel - name of class of confusion matrix
+func cmLabel(cm *metrics.ConfusionMatrix, class int) string {
+	if class < len(cm.Labels) {
+		return cm.Labels[class]
+	}
+	return strconv.Itoa(class)
+}
+
+// ConfusionMatrix - writes SVG heatmap of confusion matrix: rows are actual classes, columns are predicted ones.
+/*
+	Cells are colored by fraction of samples of actual class (so recall of class is on diagonal) and contain counts.
+	Size of SVG depends on number of classes (cfg.Width, cfg.Height, cfg.LogScale are ignored). Title is "Confusion matrix (accuracy <value>)" if cfg.Title is not set.
+*/
+func ConfusionMatrix(w io.Writer, cm *metrics.ConfusionMatrix, cfg Config) error {
+	n := cm.NumClasses()
+	if n == 0 {
+		return ErrNoValues
+	}
+	if cfg.Title == "" {
+		cfg.Title = fmt.Sprintf("Confusion matrix (accuracy %.4f)", cm.Accuracy())
+	}
+	if cfg.XLabel == "" {
+		cfg.XLabel = "predicted"
+	}
+	if cfg.YLabel == "" {
+		cfg.YLabel = "actual"
+	}
+	longest := 0
+	for i := 0; i < n; i++ {
+		longest = maxInt(longest, len(cmLabel(cm, i)))
+	}
+	cell := 40
+	labelSpace := 12 + 7*longest
+	left, top := 28+labelSpace, 48
+	width := maxInt(left+n*cell+16, 7*len(cfg.Title)+32)
+	height := top + n*cell + labelSpace + 28
+
+	ew := &errWriter{w: w}
+	s := svg.New(ew)
+	s.Start(width, height)
+	s.Rect(0, 0, width, height, "fill:white")
+	s.Text(width/2, 20, cfg.Title, titleStyle+";text-anchor:middle")
+	for i := 0; i < n; i++ {
+		total := 0
+		for j := 0; j < n; j++ {
+			total += cm.Counts[i][j]
+		}
+		for j := 0; j < n; j++ {
+			fraction := 0.0
+			if total > 0 {
+				fraction = float64(cm.Counts[i][j]) / float64(total)
+			}
+			x, y := left+j*cell, top+i*cell
+			s.Rect(x, y, cell, cell, fmt.Sprintf("fill:%s;stroke:white", blues(fraction)))
+			textColor := "#333333"
+			if fraction > 0.5 {
+				textColor = "white"
+			}
+			s.Text(x+cell/2, y+cell/2+4, strconv.Itoa(cm.Counts[i][j]), fmt.Sprintf("font-family:sans-serif;font-size:12px;fill:%s;text-anchor:middle", textColor))
+		}
+		s.Text(left-6, top+i*cell+cell/2+4, cmLabel(cm, i), fontStyle+";text-anchor:end")
+		x, y := left+i*cell+cell/2+4, top+n*cell+6
+		s.Text(x, y, cmLabel(cm, i), fontStyle+";text-anchor:end", fmt.Sprintf(`transform="rotate(-90 %d %d)"`, x, y))
+	}
+	s.Text(left+n*cell/2, height-8, cfg.XLabel, fontStyle+";text-anchor:middle")
+	py := top + n*cell/2
+	s.Text(16, py, cfg.YLabel, fontStyle+";text-anchor:middle", fmt.Sprintf(`transform="rotate(-90 16 %d)"`, py))
+	s.End()
+	return ew.err
+}
+
+// blues - color from white (0) to dark blue (1)
+func blues(v float64) string {
+	v = math.Min(math.Max(v, 0), 1)
+	r := int(math.Round(247 - v*(247-8)))
+	g := int(math.Round(251 - v*(251-48)))
+	b := int(math.Round(255 - v*(255-107)))
+	return fmt.Sprintf("rgb(%d,%d,%d)", r, g, b)
+}
+
+// SaveConfusionMatrix - writes SVG heatmap of confusion matrix to file. See ConfusionMatrix
+func SaveConfusionMatrix(fname string, cm *metrics.ConfusionMatrix, cfg Config) error {
+	return Save(fname, func(w io.Writer) error {
+		return ConfusionMatrix(w, cm, cfg)
+	})
+}
+
+func maxInt(a, b int) int {
+	if a > b {
+		return a
+	}
+	return b
+}
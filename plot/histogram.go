@@ -0,0 +1,134 @@
+package plot
+
+import (
+	"fmt"
+	"io"
+	"math"
+
+	"github.com/LdDl/cnns"
+	"github.com/LdDl/cnns/tensor"
+	svg "github.com/ajstarks/svgo"
+)
+
+// Histogram - writes SVG histogram of values (NaN and infinite values are skipped). Number of bins is 30 if not set. cfg.LogScale makes count axis logarithmic
+func Histogram(w io.Writer, values []float64, bins int, cfg Config) error {
+	if bins <= 0 {
+		bins = 30
+	}
+	min, max, err := valueRange(values, false)
+	if err != nil {
+		return err
+	}
+	if max == min {
+		min, max = min-0.5, max+0.5
+	}
+	counts := make([]float64, bins)
+	n := 0
+	sum, sumSquares := 0.0, 0.0
+	for _, v := range values {
+		if math.IsNaN(v) || math.IsInf(v, 0) {
+			continue
+		}
+		bin := int((v - min) / (max - min) * float64(bins))
+		if bin == bins {
+			bin--
+		}
+		counts[bin]++
+		n++
+		sum += v
+		sumSquares += v * v
+	}
+	mean := sum / float64(n)
+	std := math.Sqrt(math.Max(sumSquares/float64(n)-mean*mean, 0))
+
+	if cfg.YLabel == "" {
+		cfg.YLabel = "count"
+	}
+	left, top, right, bottom := margins(cfg)
+	xAxis := newAxis(min, max, left, right, 8, false, false)
+	maxCount := 0.0
+	for _, c := range counts {
+		maxCount = math.Max(maxCount, c)
+	}
+	var yAxis *axis
+	if cfg.LogScale {
+		yAxis = newAxis(1, maxCount, bottom, top, 6, true, true)
+	} else {
+		yAxis = newAxis(0, maxCount, bottom, top, 6, false, true)
+	}
+
+	ew := &errWriter{w: w}
+	c := start(svg.New(ew), cfg, xAxis, yAxis)
+	c.Gstyle(fmt.Sprintf("fill:%s;fill-opacity:0.8;stroke:white;stroke-width:0.5", Palette[0]))
+	step := (max - min) / float64(bins)
+	for i, count := range counts {
+		if !yAxis.valid(count) {
+			continue
+		}
+		x0 := xAxis.pixel(min + float64(i)*step)
+		x1 := xAxis.pixel(min + float64(i+1)*step)
+		y := yAxis.pixel(count)
+		base := c.bottom
+		if y >= base {
+			continue
+		}
+		c.Rect(x0, y, maxInt(x1-x0, 1), base-y)
+	}
+	c.Gend()
+	c.Text(c.right-6, c.top+16, fmt.Sprintf("n=%d mean=%.4g std=%.4g", n, mean, std), fontStyle+";text-anchor:end")
+	c.End()
+	return ew.err
+}
+
+// layerWeights - weights of convolutional and fully connected layers (nil for other layers)
+func layerWeights[T tensor.Float](layer cnns.LayerOf[T]) []float64 {
+	var data []T
+	switch typed := layer.(type) {
+	case *cnns.ConvLayerOf[T]:
+		for _, kernel := range typed.Kernels {
+			data = append(data, kernel.Data...)
+		}
+	case *cnns.FullyConnectedLayerOf[T]:
+		data = typed.Weights.Data
+	}
+	ret := make([]float64, len(data))
+	for i := range data {
+		ret[i] = float64(data[i])
+	}
+	return ret
+}
+
+// WeightHistogram - writes SVG histogram of weights of layer with index layerIndex (all convolutional and fully connected layers if layerIndex is negative). See Histogram
+func WeightHistogram[T tensor.Float](w io.Writer, net *cnns.WholeNetOf[T], layerIndex, bins int, cfg Config) error {
+	var values []float64
+	title := "Weights of all layers"
+	if layerIndex >= 0 {
+		if layerIndex >= len(net.Layers) {
+			return fmt.Errorf("Layer index %d is out of range [0, %d)", layerIndex, len(net.Layers))
+		}
+		layer := net.Layers[layerIndex]
+		values = layerWeights(layer)
+		if len(values) == 0 {
+			return fmt.Errorf("Layer #%d of type '%s' has no weights", layerIndex, layer.GetType())
+		}
+		title = fmt.Sprintf("Weights of layer #%d (%s)", layerIndex, layer.GetType())
+	} else {
+		for _, layer := range net.Layers {
+			values = append(values, layerWeights(layer)...)
+		}
+	}
+	if cfg.Title == "" {
+		cfg.Title = title
+	}
+	if cfg.XLabel == "" {
+		cfg.XLabel = "weight"
+	}
+	return Histogram(w, values, bins, cfg)
+}
+
+// SaveWeightHistogram - writes SVG histogram of weights to file. See WeightHistogram
+func SaveWeightHistogram[T tensor.Float](fname string, net *cnns.WholeNetOf[T], layerIndex, bins int, cfg Config) error {
+	return Save(fname, func(w io.Writer) error {
+		return WeightHistogram(w, net, layerIndex, bins, cfg)
+	})
+}
@@ -0,0 +1,63 @@
+package plot
+
+import (
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/LdDl/cnns"
+)
+
+// HistorySeries - series of recorded metrics. Validation counterpart "val_<name>" of every metric is added (if recorded) as dashed line of the same color
+func HistorySeries(history *cnns.History, names ...string) ([]Series, error) {
+	if len(names) == 0 {
+		names = []string{"loss"}
+	}
+	recorded := make(map[string]bool)
+	for _, name := range history.MetricNames() {
+		recorded[name] = true
+	}
+	listed := make(map[string]bool)
+	for _, name := range names {
+		listed[name] = true
+	}
+	var ret []Series
+	for i, name := range names {
+		if !recorded[name] {
+			return nil, fmt.Errorf("Metric '%s' is not recorded", name)
+		}
+		color := Palette[i%len(Palette)]
+		ret = append(ret, Series{Name: name, Values: history.Metric(name), Color: color, Dashed: strings.HasPrefix(name, "val_")})
+		val := "val_" + name
+		if recorded[val] && !listed[val] {
+			ret = append(ret, Series{Name: val, Values: history.Metric(val), Color: color, Dashed: true})
+		}
+	}
+	return ret, nil
+}
+
+// History - writes SVG chart of recorded metrics over epochs ("loss" and "val_loss" if names are not set). See HistorySeries
+func History(w io.Writer, history *cnns.History, cfg Config, names ...string) error {
+	series, err := HistorySeries(history, names...)
+	if err != nil {
+		return err
+	}
+	epochs := make([]float64, len(history.Epochs))
+	for i := range history.Epochs {
+		epochs[i] = float64(history.Epochs[i].Epoch)
+	}
+	if cfg.XLabel == "" {
+		cfg.XLabel = "epoch"
+	}
+	if cfg.YLabel == "" && len(names) == 1 {
+		cfg.YLabel = names[0]
+	}
+	return LineChart(w, epochs, series, cfg)
+}
+
+// SaveHistory - writes SVG chart of recorded metrics to file. See History
+func SaveHistory(fname string, history *cnns.History, cfg Config, names ...string) error {
+	return Save(fname, func(w io.Writer) error {
+		return History(w, history, cfg, names...)
+	})
+}
@@ -0,0 +1,343 @@
+// Package plot renders training curves, confusion matrices and histograms of weights to self-contained SVG (no external fonts, styles or scripts).
+package plot
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"strconv"
+
+	svg "github.com/ajstarks/svgo"
+)
+
+var (
+	// Palette - colors of series (used in order, repeated if there are more series)
+	Palette = []string{"#1f77b4", "#ff7f0e", "#2ca02c", "#d62728", "#9467bd", "#8c564b", "#e377c2", "#7f7f7f", "#bcbd22", "#17becf"}
+	// ErrNoValues - nothing to plot (empty data or all values are NaN / not positive for logarithmic scale)
+	ErrNoValues = errors.New("no values to plot")
+)
+
+const (
+	fontStyle  = "font-family:sans-serif;font-size:12px;fill:#333333"
+	titleStyle = "font-family:sans-serif;font-size:14px;font-weight:bold;fill:#333333"
+	gridStyle  = "stroke:#e0e0e0;stroke-width:1"
+	axisStyle  = "stroke:#333333;stroke-width:1"
+)
+
+// Config - size and labels of chart
+/*
+	Width, Height - size of SVG (640x400 if not set);
+	Title - text above chart;
+	XLabel, YLabel - names of axes;
+	LogScale - logarithmic Y axis (not positive values are skipped).
+*/
+type Config struct {
+	Width    int
+	Height   int
+	Title    string
+	XLabel   string
+	YLabel   string
+	LogScale bool
+}
+
+// size - width and height of chart with defaults
+func (cfg Config) size() (int, int) {
+	width, height := cfg.Width, cfg.Height
+	if width <= 0 {
+		width = 640
+	}
+	if height <= 0 {
+		height = 400
+	}
+	return width, height
+}
+
+// Series - named line of chart. NaN values are gaps
+/*
+	Color - color of line (next one from Palette if not set);
+	Dashed - draw dashed line (e.g. for validation metrics).
+*/
+type Series struct {
+	Name   string
+	Values []float64
+	Color  string
+	Dashed bool
+}
+
+// errWriter - remembers first error of underlying writer, since svgo does not return errors
+type errWriter struct {
+	w   io.Writer
+	err error
+}
+
+func (ew *errWriter) Write(p []byte) (int, error) {
+	if ew.err != nil {
+		return 0, ew.err
+	}
+	n, err := ew.w.Write(p)
+	ew.err = err
+	return n, err
+}
+
+// axis - linear or logarithmic mapping of values to pixels
+type axis struct {
+	min, max   float64
+	from, to   int
+	log        bool
+	ticks      []float64
+	tickLabels []string
+}
+
+// transform - value to scale of axis (log10 for logarithmic one)
+func (a *axis) transform(v float64) float64 {
+	if a.log {
+		return math.Log10(v)
+	}
+	return v
+}
+
+// pixel - position of value
+func (a *axis) pixel(v float64) int {
+	return a.from + int(math.Round((a.transform(v)-a.min)/(a.max-a.min)*float64(a.to-a.from)))
+}
+
+// valid - checks whether value can be drawn on axis
+func (a *axis) valid(v float64) bool {
+	return !math.IsNaN(v) && !math.IsInf(v, 0) && (!a.log || v > 0)
+}
+
+// newAxis - axis covering values in [min, max] with about n ticks. Integer ticks only if integer is set
+func newAxis(min, max float64, from, to, n int, log, integer bool) *axis {
+	a := &axis{from: from, to: to, log: log}
+	if log {
+		lo, hi := math.Floor(math.Log10(min)), math.Ceil(math.Log10(max))
+		if hi == lo {
+			hi = lo + 1
+		}
+		a.min, a.max = lo, hi
+		step := math.Max(1, math.Ceil((hi-lo)/float64(n)))
+		for p := lo; p <= hi; p += step {
+			a.ticks = append(a.ticks, math.Pow(10, p))
+			a.tickLabels = append(a.tickLabels, strconv.FormatFloat(math.Pow(10, p), 'g', -1, 64))
+		}
+		return a
+	}
+	if max == min {
+		delta := math.Max(math.Abs(min)*0.1, 1)
+		min, max = min-delta, max+delta
+	}
+	step := niceStep((max - min) / float64(n))
+	if integer && step < 1 {
+		step = 1
+	}
+	a.min, a.max = math.Floor(min/step)*step, math.Ceil(max/step)*step
+	for i := 0; a.min+float64(i)*step <= a.max+step/2; i++ {
+		v := a.min + float64(i)*step
+		a.ticks = append(a.ticks, v)
+		a.tickLabels = append(a.tickLabels, formatTick(v, step))
+	}
+	return a
+}
+
+// niceStep - rounds step up to 1, 2 or 5 multiplied by power of 10
+func niceStep(step float64) float64 {
+	if step <= 0 || math.IsNaN(step) || math.IsInf(step, 0) {
+		return 1
+	}
+	magnitude := math.Pow(10, math.Floor(math.Log10(step)))
+	for _, m := range []float64{1, 2, 5, 10} {
+		if step <= m*magnitude {
+			return m * magnitude
+		}
+	}
+	return 10 * magnitude
+}
+
+// formatTick - label of tick without floating point noise
+func formatTick(v, step float64) string {
+	digits := 0
+	if step < 1 {
+		digits = int(math.Ceil(-math.Log10(step)))
+	}
+	if math.Abs(v) < step/2 {
+		v = 0
+	}
+	return strconv.FormatFloat(v, 'f', digits, 64)
+}
+
+// canvas - SVG with plotting area and axes
+type canvas struct {
+	*svg.SVG
+	x, y                     *axis
+	left, top, right, bottom int
+}
+
+// valueRange - minimum and maximum of values which can be drawn on axis
+func valueRange(values []float64, log bool) (float64, float64, error) {
+	min, max := math.Inf(1), math.Inf(-1)
+	probe := axis{log: log}
+	for _, v := range values {
+		if !probe.valid(v) {
+			continue
+		}
+		min = math.Min(min, v)
+		max = math.Max(max, v)
+	}
+	if min > max {
+		return 0, 0, ErrNoValues
+	}
+	return min, max, nil
+}
+
+// start - writes header, title, grid and axes
+func start(s *svg.SVG, cfg Config, x, y *axis) *canvas {
+	width, height := cfg.size()
+	c := &canvas{SVG: s, x: x, y: y, left: x.from, right: x.to, top: y.to, bottom: y.from}
+	s.Start(width, height)
+	s.Rect(0, 0, width, height, "fill:white")
+	if cfg.Title != "" {
+		s.Text(width/2, 20, cfg.Title, titleStyle+";text-anchor:middle")
+	}
+	for i, v := range y.ticks {
+		py := y.pixel(v)
+		s.Line(c.left, py, c.right, py, gridStyle)
+		s.Text(c.left-6, py+4, y.tickLabels[i], fontStyle+";text-anchor:end")
+	}
+	for i, v := range x.ticks {
+		px := x.pixel(v)
+		s.Line(px, c.top, px, c.bottom, gridStyle)
+		s.Text(px, c.bottom+16, x.tickLabels[i], fontStyle+";text-anchor:middle")
+	}
+	s.Line(c.left, c.bottom, c.right, c.bottom, axisStyle)
+	s.Line(c.left, c.top, c.left, c.bottom, axisStyle)
+	if cfg.XLabel != "" {
+		s.Text((c.left+c.right)/2, height-8, cfg.XLabel, fontStyle+";text-anchor:middle")
+	}
+	if cfg.YLabel != "" {
+		py := (c.top + c.bottom) / 2
+		s.Text(16, py, cfg.YLabel, fontStyle+";text-anchor:middle", fmt.Sprintf(`transform="rotate(-90 16 %d)"`, py))
+	}
+	return c
+}
+
+// margins - left, top, right and bottom margins of plotting area
+func margins(cfg Config) (int, int, int, int) {
+	width, height := cfg.size()
+	top := 16
+	if cfg.Title != "" {
+		top = 36
+	}
+	return 64, top, width - 16, height - 44
+}
+
+// LineChart - writes SVG line chart of series over shared X values
+func LineChart(w io.Writer, x []float64, series []Series, cfg Config) error {
+	var all []float64
+	for i := range series {
+		if len(series[i].Values) != len(x) {
+			return fmt.Errorf("Series '%s' has %d values, but there are %d points on X axis", series[i].Name, len(series[i].Values), len(x))
+		}
+		all = append(all, series[i].Values...)
+	}
+	xMin, xMax, err := valueRange(x, false)
+	if err != nil {
+		return err
+	}
+	yMin, yMax, err := valueRange(all, cfg.LogScale)
+	if err != nil {
+		return err
+	}
+	integer := true
+	for _, v := range x {
+		if v != math.Trunc(v) {
+			integer = false
+			break
+		}
+	}
+	left, top, right, bottom := margins(cfg)
+	xAxis := newAxis(xMin, xMax, left, right, 8, false, integer)
+	yAxis := newAxis(yMin, yMax, bottom, top, 6, cfg.LogScale, false)
+
+	ew := &errWriter{w: w}
+	c := start(svg.New(ew), cfg, xAxis, yAxis)
+	for i := range series {
+		color := series[i].Color
+		if color == "" {
+			color = Palette[i%len(Palette)]
+		}
+		style := fmt.Sprintf("fill:none;stroke:%s;stroke-width:2", color)
+		if series[i].Dashed {
+			style += ";stroke-dasharray:6,4"
+		}
+		c.Gstyle(style)
+		var px, py []int
+		flush := func() {
+			switch len(px) {
+			case 0:
+			case 1:
+				c.Circle(px[0], py[0], 2, "fill:"+color)
+			default:
+				c.Polyline(px, py)
+			}
+			px, py = nil, nil
+		}
+		for j, v := range series[i].Values {
+			if !yAxis.valid(v) || !xAxis.valid(x[j]) {
+				flush()
+				continue
+			}
+			px = append(px, xAxis.pixel(x[j]))
+			py = append(py, yAxis.pixel(v))
+		}
+		flush()
+		c.Gend()
+	}
+	legend(c, series)
+	c.End()
+	return ew.err
+}
+
+// legend - names of series in top right corner of plotting area
+func legend(c *canvas, series []Series) {
+	if len(series) == 0 {
+		return
+	}
+	longest := 0
+	for i := range series {
+		if len(series[i].Name) > longest {
+			longest = len(series[i].Name)
+		}
+	}
+	width := 36 + 7*longest
+	x0, y0 := c.right-width-8, c.top+8
+	c.Rect(x0, y0, width, 18*len(series)+6, "fill:white;fill-opacity:0.85;stroke:#cccccc")
+	for i := range series {
+		color := series[i].Color
+		if color == "" {
+			color = Palette[i%len(Palette)]
+		}
+		style := fmt.Sprintf("stroke:%s;stroke-width:2", color)
+		if series[i].Dashed {
+			style += ";stroke-dasharray:6,4"
+		}
+		y := y0 + 15 + 18*i
+		c.Line(x0+6, y-4, x0+26, y-4, style)
+		c.Text(x0+32, y, series[i].Name, fontStyle)
+	}
+}
+
+// Save - creates file and writes chart to it, e.g. plot.Save("loss.svg", func(w io.Writer) error { return plot.History(w, history, plot.Config{}) })
+func Save(fname string, draw func(w io.Writer) error) error {
+	f, err := os.Create(fname)
+	if err != nil {
+		return err
+	}
+	err = draw(f)
+	if err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
@@ -0,0 +1,133 @@
+package plot
+
+import (
+	"bytes"
+	"encoding/xml"
+	"io"
+	"math"
+	"strings"
+	"testing"
+
+	"github.com/LdDl/cnns"
+	"github.com/LdDl/cnns/metrics"
+	"github.com/LdDl/cnns/tensor"
+)
+
+// countElements - parses SVG and counts its elements by name
+func countElements(t *testing.T, data []byte) map[string]int {
+	ret := make(map[string]int)
+	decoder := xml.NewDecoder(bytes.NewReader(data))
+	for {
+		token, err := decoder.Token()
+		if err == io.EOF {
+			return ret
+		}
+		if err != nil {
+			t.Fatalf("SVG is not well-formed: %s\n%s", err, data)
+		}
+		if start, ok := token.(xml.StartElement); ok {
+			ret[start.Name.Local]++
+		}
+	}
+}
+
+func TestHistory(t *testing.T) {
+	history := cnns.NewHistory()
+	for epoch := 0; epoch < 5; epoch++ {
+		metrics := map[string]float64{"loss": 1 / float64(epoch+1), "val_loss": 1.5 / float64(epoch+1), "accuracy": 0.2 * float64(epoch)}
+		if epoch == 2 {
+			delete(metrics, "val_loss")
+		}
+		history.OnEpochEnd(&cnns.TrainEvent{Epoch: epoch, Metrics: metrics})
+	}
+
+	var buf bytes.Buffer
+	err := History(&buf, history, Config{Title: "Loss & accuracy", LogScale: true})
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	elements := countElements(t, buf.Bytes())
+	// "loss" is single line, "val_loss" is split into two lines by missing epoch
+	if elements["polyline"] != 3 {
+		t.Errorf("Number of lines is wrong. Expected value: %d. Got: %d", 3, elements["polyline"])
+	}
+	text := buf.String()
+	if !strings.Contains(text, "Loss &amp; accuracy") || !strings.Contains(text, ">val_loss</text>") || !strings.Contains(text, "stroke-dasharray") {
+		t.Errorf("Title or legend is wrong:\n%s", text)
+	}
+	// Logarithmic axis covers [0.1, 10]
+	if !strings.Contains(text, ">0.1</text>") || !strings.Contains(text, ">10</text>") {
+		t.Errorf("Ticks of logarithmic axis are wrong:\n%s", text)
+	}
+
+	buf.Reset()
+	err = History(&buf, history, Config{}, "accuracy")
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	if elements = countElements(t, buf.Bytes()); elements["polyline"] != 1 {
+		t.Errorf("Number of lines is wrong. Expected value: %d. Got: %d", 1, elements["polyline"])
+	}
+	if err = History(io.Discard, history, Config{}, "f1"); err == nil {
+		t.Errorf("Error should be returned for metric which is not recorded")
+	}
+	if err = LineChart(io.Discard, []float64{0, 1}, []Series{{Name: "empty", Values: []float64{math.NaN(), -1}}}, Config{LogScale: true}); err != ErrNoValues {
+		t.Errorf("ErrNoValues should be returned. Got: %v", err)
+	}
+}
+
+func TestConfusionMatrix(t *testing.T) {
+	cm := metrics.NewEmptyConfusionMatrix(3)
+	cm.Labels = []string{"cat", "dog", "<bird>"}
+	cm.Counts = [][]int{{5, 1, 0}, {2, 7, 1}, {0, 0, 4}}
+
+	var buf bytes.Buffer
+	err := ConfusionMatrix(&buf, cm, Config{})
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	elements := countElements(t, buf.Bytes())
+	// background and 9 cells
+	if elements["rect"] != 10 {
+		t.Errorf("Number of rectangles is wrong. Expected value: %d. Got: %d", 10, elements["rect"])
+	}
+	text := buf.String()
+	if !strings.Contains(text, "&lt;bird&gt;") || !strings.Contains(text, "accuracy 0.8000") {
+		t.Errorf("Labels or title are wrong:\n%s", text)
+	}
+}
+
+func TestWeightHistogram(t *testing.T) {
+	conv := cnns.NewConvLayer(1, 3, 2, tensor.TDsize{X: 6, Y: 6, Z: 1})
+	relu := cnns.NewReLULayer(conv.GetOutputSize())
+	fc := cnns.NewFullyConnectedLayer(relu.GetOutputSize(), 2)
+	net := cnns.WholeNet{Layers: []cnns.Layer{conv, relu, fc}}
+
+	var buf bytes.Buffer
+	err := WeightHistogram(&buf, &net, -1, 10, Config{})
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	countElements(t, buf.Bytes())
+	if !strings.Contains(buf.String(), "n=82 ") {
+		t.Errorf("Number of weights is wrong:\n%s", buf.String())
+	}
+	if err = WeightHistogram(io.Discard, &net, 1, 10, Config{}); err == nil {
+		t.Errorf("Error should be returned for layer without weights")
+	}
+
+	buf.Reset()
+	err = Histogram(&buf, []float64{1, 1, 1, 2, 3}, 2, Config{})
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	// background and 2 bins
+	if elements := countElements(t, buf.Bytes()); elements["rect"] != 3 {
+		t.Errorf("Number of rectangles is wrong. Expected value: %d. Got: %d", 3, elements["rect"])
+	}
+}
@@ -8,25 +8,33 @@ import (
 	"github.com/LdDl/cnns/utils/u"
 )
 
-// MaxPoolingLayer is Max Pooling layer structure
+// MaxPoolingLayerOf is Max Pooling layer structure
 // In - Input data
 // Out - Output data
 // Stride - Striding step
 // LocalDelta - Gradients
-type MaxPoolingLayer struct {
-	In           *tensor.Tensor
-	Out          *tensor.Tensor
-	LocalDelta   *tensor.Tensor
+type MaxPoolingLayerOf[T tensor.Float] struct {
+	In           *tensor.TensorOf[T]
+	Out          *tensor.TensorOf[T]
+	LocalDelta   *tensor.TensorOf[T]
 	Stride       int
 	ExtendFilter int
 }
 
+// MaxPoolingLayer - MaxPooling layer of float64 precision
+type MaxPoolingLayer = MaxPoolingLayerOf[float64]
+
 // NewMaxPoolingLayer - constructor for new MaxPooling layer.
 func NewMaxPoolingLayer(stride, extendFilter int, inSize *tensor.TDsize) Layer {
-	newLayer := &MaxPoolingLayer{
-		In:           tensor.NewTensor(inSize.X, inSize.Y, inSize.Z),
-		Out:          tensor.NewTensor((inSize.X-extendFilter)/stride+1, (inSize.Y-extendFilter)/stride+1, inSize.Z),
-		LocalDelta:   tensor.NewTensor(inSize.X, inSize.Y, inSize.Z),
+	return NewMaxPoolingLayerOf[float64](stride, extendFilter, inSize)
+}
+
+// NewMaxPoolingLayerOf - constructor for new MaxPooling layer of given precision (float32 or float64). See NewMaxPoolingLayer.
+func NewMaxPoolingLayerOf[T tensor.Float](stride, extendFilter int, inSize *tensor.TDsize) LayerOf[T] {
+	newLayer := &MaxPoolingLayerOf[T]{
+		In:           tensor.NewTensorOf[T](inSize.X, inSize.Y, inSize.Z),
+		Out:          tensor.NewTensorOf[T]((inSize.X-extendFilter)/stride+1, (inSize.Y-extendFilter)/stride+1, inSize.Z),
+		LocalDelta:   tensor.NewTensorOf[T](inSize.X, inSize.Y, inSize.Z),
 		Stride:       stride,
 		ExtendFilter: extendFilter,
 	}
@@ -34,71 +42,75 @@ func NewMaxPoolingLayer(stride, extendFilter int, inSize *tensor.TDsize) Layer {
 }
 
 // SetCustomWeights - set user's weights (make it carefully)
-func (maxpool *MaxPoolingLayer) SetCustomWeights(t []*tensor.Tensor) {
+func (maxpool *MaxPoolingLayerOf[T]) SetCustomWeights(t []*tensor.TensorOf[T]) {
 	fmt.Println("There are no weights for pooling layer")
 }
 
 // GetOutputSize - returns output size (dimensions)
-func (maxpool *MaxPoolingLayer) GetOutputSize() *tensor.TDsize {
+func (maxpool *MaxPoolingLayerOf[T]) GetOutputSize() *tensor.TDsize {
 	return maxpool.Out.Size
 }
 
 // GetInputSize - returns input size (dimensions)
-func (maxpool *MaxPoolingLayer) GetInputSize() *tensor.TDsize {
+func (maxpool *MaxPoolingLayerOf[T]) GetInputSize() *tensor.TDsize {
 	return maxpool.In.Size
 }
 
 // GetOutput - returns max pooling layer's output
-func (maxpool *MaxPoolingLayer) GetOutput() *tensor.Tensor {
+func (maxpool *MaxPoolingLayerOf[T]) GetOutput() *tensor.TensorOf[T] {
 	return maxpool.Out
 }
 
 // GetWeights - returns pooling layer's weights
-func (maxpool *MaxPoolingLayer) GetWeights() []*tensor.Tensor {
+func (maxpool *MaxPoolingLayerOf[T]) GetWeights() []*tensor.TensorOf[T] {
 	fmt.Println("There are no weights for pooling layer")
-	return []*tensor.Tensor{}
+	return []*tensor.TensorOf[T]{}
 }
 
 // GetGradients - returns max pooling layer's gradients
-func (maxpool *MaxPoolingLayer) GetGradients() *tensor.Tensor {
+func (maxpool *MaxPoolingLayerOf[T]) GetGradients() *tensor.TensorOf[T] {
 	return maxpool.LocalDelta
 }
 
 // FeedForward - feed data to max pooling layer
-func (maxpool *MaxPoolingLayer) FeedForward(t *tensor.Tensor) {
+func (maxpool *MaxPoolingLayerOf[T]) FeedForward(t *tensor.TensorOf[T]) {
 	maxpool.In = t
 	maxpool.DoActivation()
 }
 
 // DoActivation - max pooling layer's output activation
-func (maxpool *MaxPoolingLayer) DoActivation() {
-	for x := 0; x < maxpool.Out.Size.X; x++ {
-		for y := 0; y < maxpool.Out.Size.Y; y++ {
-			for z := 0; z < maxpool.In.Size.Z; z++ {
-				// mappedX, mappedY, _ := maxpool.mapToInput(x, y, 0)
+func (maxpool *MaxPoolingLayerOf[T]) DoActivation() {
+	maxpool.feedForwardInto(maxpool.In, maxpool.Out)
+}
+
+// feedForwardInto - pool input and store result in provided output. Layer's state is not touched.
+func (maxpool *MaxPoolingLayerOf[T]) feedForwardInto(in, out *tensor.TensorOf[T]) {
+	for x := 0; x < out.Size.X; x++ {
+		for y := 0; y < out.Size.Y; y++ {
+			for z := 0; z < in.Size.Z; z++ {
 				mappedX, mappedY := x*maxpool.Stride, y*maxpool.Stride
-				mval := -1.0 * math.MaxFloat64
+				mval := T(math.Inf(-1))
 				for i := 0; i < maxpool.ExtendFilter; i++ {
 					for j := 0; j < maxpool.ExtendFilter; j++ {
-						v := maxpool.In.Get(mappedX+i, mappedY+j, z)
+						v := in.Get(mappedX+i, mappedY+j, z)
 						if v > mval {
 							mval = v
 						}
 					}
 				}
-				maxpool.Out.Set(x, y, z, mval)
+				out.Set(x, y, z, mval)
 			}
 		}
 	}
 }
 
 // CalculateGradients - calculate max pooling layer's gradients
-func (maxpool *MaxPoolingLayer) CalculateGradients(nextLayerGrad *tensor.Tensor) {
+func (maxpool *MaxPoolingLayerOf[T]) CalculateGradients(nextLayerGrad *tensor.TensorOf[T]) {
 	for x := 0; x < maxpool.In.Size.X; x++ {
 		for y := 0; y < (maxpool).In.Size.Y; y++ {
 			rn := maxpool.sameAsOuput(x, y)
 			for z := 0; z < maxpool.In.Size.Z; z++ {
-				sumError := 0.0
+				var sumError T
 				for i := rn.MinX; i <= rn.MaxX; i++ {
 					for j := rn.MinY; j <= rn.MaxY; j++ {
 						if maxpool.In.Get(x, y, z) == maxpool.Out.Get(i, j, z) {
@@ -115,7 +127,7 @@ func (maxpool *MaxPoolingLayer) CalculateGradients(nextLayerGrad *tensor.Tensor)
 }
 
 // UpdateWeights - just to point, that max pooling layer does NOT updating weights
-func (maxpool *MaxPoolingLayer) UpdateWeights() {
+func (maxpool *MaxPoolingLayerOf[T]) UpdateWeights() {
 	/*
 		Empty
 		Need for layer interface.
@@ -123,55 +135,55 @@ func (maxpool *MaxPoolingLayer) UpdateWeights() {
 }
 
 // PrintOutput - print max pooling layer's output
-func (maxpool *MaxPoolingLayer) PrintOutput() {
+func (maxpool *MaxPoolingLayerOf[T]) PrintOutput() {
 	fmt.Println("Printing Max Pooling Layer output...")
 	maxpool.Out.Print()
 }
 
 // PrintWeights - just to point, that max pooling layer has not gradients
-func (maxpool *MaxPoolingLayer) PrintWeights() {
+func (maxpool *MaxPoolingLayerOf[T]) PrintWeights() {
 	fmt.Println("There are no weights for pooling layer")
 }
 
 // PrintGradients - print max pooling layer's gradients
-func (maxpool *MaxPoolingLayer) PrintGradients() {
+func (maxpool *MaxPoolingLayerOf[T]) PrintGradients() {
 	fmt.Println("Printing Max Pooling Layer local gradients...")
 	maxpool.LocalDelta.Print()
 }
 
 // SetActivationFunc - sets activation function for layer
-func (maxpool *MaxPoolingLayer) SetActivationFunc(f func(v float64) float64) {
+func (maxpool *MaxPoolingLayerOf[T]) SetActivationFunc(f func(v float64) float64) {
 	// Nothing here. Just for interface.
 	fmt.Println("You can not set activation function for pooling layer")
 }
 
 // SetActivationDerivativeFunc sets derivative of activation function
-func (maxpool *MaxPoolingLayer) SetActivationDerivativeFunc(f func(v float64) float64) {
+func (maxpool *MaxPoolingLayerOf[T]) SetActivationDerivativeFunc(f func(v float64) float64) {
 	// Nothing here. Just for interface.
 	fmt.Println("You can not set derivative of activation function for pooling layer")
 }
 
 // GetStride - get stride of layer
-func (maxpool *MaxPoolingLayer) GetStride() int {
+func (maxpool *MaxPoolingLayerOf[T]) GetStride() int {
 	return maxpool.Stride
 }
 
 // GetKernelSize - return "conv" as layer's type
-func (maxpool *MaxPoolingLayer) GetKernelSize() int {
+func (maxpool *MaxPoolingLayerOf[T]) GetKernelSize() int {
 	return maxpool.ExtendFilter
 }
 
 // GetType - return "maxpool" as layer's type
-func (maxpool *MaxPoolingLayer) GetType() string {
+func (maxpool *MaxPoolingLayerOf[T]) GetType() string {
 	return "pool"
 }
 
-func (maxpool *MaxPoolingLayer) mapToInput(i, j, k int) (x int, y int, z int) {
+func (maxpool *MaxPoolingLayerOf[T]) mapToInput(i, j, k int) (x int, y int, z int) {
 	return i * maxpool.Stride, j * maxpool.Stride, k
 }
 
 // sameAsOuput - reshape convolutional layer's output
-func (maxpool *MaxPoolingLayer) sameAsOuput(x, y int) Range {
+func (maxpool *MaxPoolingLayerOf[T]) sameAsOuput(x, y int) Range {
 	a := float64(x)
 	b := float64(y)
 	return Range{
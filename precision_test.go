@@ -0,0 +1,86 @@
+package cnns
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"math"
+	"math/rand"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/LdDl/cnns/tensor"
+)
+
+func TestConvertNet(t *testing.T) {
+	rand.Seed(42)
+	conv := NewConvLayer(1, 3, 2, tensor.TDsize{X: 8, Y: 9, Z: 1})
+	relu := NewReLULayer(conv.GetOutputSize())
+	maxpool := NewMaxPoolingLayer(2, 2, relu.GetOutputSize())
+	fullyconnected := NewFullyConnectedLayer(maxpool.GetOutputSize(), 3)
+	fullyconnected.SetActivationFunc(ActivationSygmoid)
+	fullyconnected.SetActivationDerivativeFunc(ActivationSygmoidDerivative)
+	var net WholeNet
+	net.Layers = append(net.Layers, conv, relu, maxpool, fullyconnected)
+
+	input := tensor.NewTensor(8, 9, 1)
+	for j := range input.Data {
+		input.Data[j] = rand.Float64() - 0.5
+	}
+	net.FeedForward(input)
+
+	net32, err := ConvertNet[float32](&net)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	net32.FeedForward(tensor.Convert[float32](input))
+	for i, v := range net32.GetOutput().Data {
+		if math.Abs(float64(v)-net.GetOutput().Data[i]) > 1e-5 {
+			t.Errorf("Outputs are not equal at pos #%d. Expected value: %f. Got: %f", i, net.GetOutput().Data[i], v)
+		}
+	}
+
+	dir, err := ioutil.TempDir("", "cnns")
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	defer os.RemoveAll(dir)
+	fname := filepath.Join(dir, "net32.json")
+	err = net32.ExportToFile(fname)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	fileBytes, err := ioutil.ReadFile(fname)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	var data NetJSON
+	err = json.Unmarshal(fileBytes, &data)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	if data.Precision != tensor.PrecisionFloat32 {
+		t.Errorf("Precision tag should be %s, but got %s", tensor.PrecisionFloat32, data.Precision)
+	}
+
+	var imported WholeNetOf[float32]
+	err = imported.ImportFromFile(fname, false)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	for i := range net32.Layers[0].GetWeights() {
+		expected := net32.Layers[0].GetWeights()[i].Data
+		got := imported.Layers[0].GetWeights()[i].Data
+		for j := range expected {
+			if expected[j] != got[j] {
+				t.Errorf("Weights are not equal at pos #%d. Expected value: %f. Got: %f", j, expected[j], got[j])
+			}
+		}
+	}
+}
@@ -0,0 +1,104 @@
+package cnns
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+
+	"github.com/LdDl/cnns/tensor"
+)
+
+// inferenceLayer - layer which is able to evaluate its output into provided buffer without touching own state (In, Out, gradients and etc.)
+type inferenceLayer[T tensor.Float] interface {
+	LayerOf[T]
+	feedForwardInto(in, out *tensor.TensorOf[T])
+}
+
+// PredictorOf - inference-only wrapper around trained network.
+/*
+	Predictor shares (read-only) weights with the network it has been built from,
+	but every call of Predict uses its own activation buffers (pooled arenas), so Predict is safe for concurrent use.
+	Do not train source network while Predictor is in use: weights are not copied.
+*/
+type PredictorOf[T tensor.Float] struct {
+	layers     []inferenceLayer[T]
+	inputSize  tensor.TDsize
+	outputSize tensor.TDsize
+	arenas     sync.Pool
+}
+
+// Predictor - predictor of float64 precision
+type Predictor = PredictorOf[float64]
+
+// NewPredictor - constructor for Predictor. Network should contain at least one layer and every layer should support stateless inference.
+func NewPredictor[T tensor.Float](wh *WholeNetOf[T]) (*PredictorOf[T], error) {
+	if len(wh.Layers) == 0 {
+		return nil, errors.New("network has no layers")
+	}
+	p := &PredictorOf[T]{
+		layers:     make([]inferenceLayer[T], len(wh.Layers)),
+		inputSize:  *wh.Layers[0].GetInputSize(),
+		outputSize: *wh.Layers[len(wh.Layers)-1].GetOutputSize(),
+	}
+	for i := range wh.Layers {
+		l, ok := wh.Layers[i].(inferenceLayer[T])
+		if !ok {
+			return nil, fmt.Errorf("Layer #%d of type '%s' does not support stateless inference", i, wh.Layers[i].GetType())
+		}
+		p.layers[i] = l
+	}
+	p.arenas.New = func() interface{} {
+		return p.NewArena()
+	}
+	return p, nil
+}
+
+// NewArena - allocate activation buffers for every layer's output. See PredictInto.
+func (p *PredictorOf[T]) NewArena() *ArenaOf[T] {
+	layers := make([]LayerOf[T], len(p.layers))
+	for i := range p.layers {
+		layers[i] = p.layers[i]
+	}
+	return NewArena(layers)
+}
+
+// GetInputSize - returns expected input size (dimensions)
+func (p *PredictorOf[T]) GetInputSize() *tensor.TDsize {
+	return &tensor.TDsize{X: p.inputSize.X, Y: p.inputSize.Y, Z: p.inputSize.Z}
+}
+
+// GetOutputSize - returns output size (dimensions)
+func (p *PredictorOf[T]) GetOutputSize() *tensor.TDsize {
+	return &tensor.TDsize{X: p.outputSize.X, Y: p.outputSize.Y, Z: p.outputSize.Z}
+}
+
+// Predict - forward pass through the net. Returns new instance of Tensor (net's output). Safe for concurrent use.
+func (p *PredictorOf[T]) Predict(input *tensor.TensorOf[T]) (*tensor.TensorOf[T], error) {
+	arena := p.arenas.Get().(*ArenaOf[T])
+	defer p.arenas.Put(arena)
+	out, err := p.PredictInto(arena, input)
+	if err != nil {
+		return nil, err
+	}
+	ret := tensor.NewTensorOf[T](p.outputSize.X, p.outputSize.Y, p.outputSize.Z)
+	copy(ret.Data, out.Data)
+	return ret, nil
+}
+
+// PredictInto - forward pass through the net using provided arena (see NewArena). No heap allocations are made.
+// Returns arena's output buffer, so result is valid until next usage of the arena.
+// Safe for concurrent use as long as every goroutine uses its own arena.
+func (p *PredictorOf[T]) PredictInto(arena *ArenaOf[T], input *tensor.TensorOf[T]) (*tensor.TensorOf[T], error) {
+	if input.Size.X != p.inputSize.X || input.Size.Y != p.inputSize.Y || input.Size.Z != p.inputSize.Z {
+		return nil, tensor.ErrDimensionsAreNotEqual
+	}
+	if len(arena.Activations) != len(p.layers) {
+		return nil, errors.New("arena does not fit predictor's layers")
+	}
+	in := input
+	for i := range p.layers {
+		p.layers[i].feedForwardInto(in, arena.Activations[i])
+		in = arena.Activations[i]
+	}
+	return in, nil
+}
@@ -0,0 +1,108 @@
+package cnns
+
+import (
+	"math/rand"
+	"sync"
+	"testing"
+
+	"github.com/LdDl/cnns/tensor"
+)
+
+func TestPredictorConcurrent(t *testing.T) {
+	rand.Seed(42)
+	conv := NewConvLayer(1, 3, 2, tensor.TDsize{X: 8, Y: 9, Z: 1})
+	relu := NewReLULayer(conv.GetOutputSize())
+	maxpool := NewMaxPoolingLayer(2, 2, relu.GetOutputSize())
+	fullyconnected := NewFullyConnectedLayer(maxpool.GetOutputSize(), 3)
+	var net WholeNet
+	net.Layers = append(net.Layers, conv, relu, maxpool, fullyconnected)
+
+	inputs := make([]*tensor.Tensor, 8)
+	expected := make([]*tensor.Tensor, len(inputs))
+	for i := range inputs {
+		inputs[i] = tensor.NewTensor(8, 9, 1)
+		for j := range inputs[i].Data {
+			inputs[i].Data[j] = rand.Float64() - 0.5
+		}
+		net.FeedForward(inputs[i])
+		expected[i] = tensor.NewTensor(3, 1, 1)
+		copy(expected[i].Data, net.GetOutput().Data)
+	}
+
+	predictor, err := NewPredictor(&net)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	var wg sync.WaitGroup
+	for w := 0; w < 4; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for n := 0; n < 50; n++ {
+				i := n % len(inputs)
+				out, err := predictor.Predict(inputs[i])
+				if err != nil {
+					t.Error(err)
+					return
+				}
+				for j := range out.Data {
+					if out.Data[j] != expected[i].Data[j] {
+						t.Errorf("Outputs are not equal at pos #%d. Expected value: %f. Got: %f", j, expected[i].Data[j], out.Data[j])
+						return
+					}
+				}
+			}
+		}()
+	}
+	wg.Wait()
+
+	_, err = predictor.Predict(tensor.NewTensor(3, 3, 1))
+	if err == nil {
+		t.Errorf("Predict should fail for input of wrong size")
+	}
+}
+
+func TestZeroAllocations(t *testing.T) {
+	rand.Seed(42)
+	conv := NewConvLayer(1, 3, 2, tensor.TDsize{X: 8, Y: 9, Z: 1})
+	relu := NewReLULayer(conv.GetOutputSize())
+	maxpool := NewMaxPoolingLayer(2, 2, relu.GetOutputSize())
+	fullyconnected := NewFullyConnectedLayer(maxpool.GetOutputSize(), 3)
+	var net WholeNet
+	net.Layers = append(net.Layers, conv, relu, maxpool, fullyconnected)
+
+	input := tensor.NewTensor(8, 9, 1)
+	for j := range input.Data {
+		input.Data[j] = rand.Float64() - 0.5
+	}
+	target := tensor.NewTensor(3, 1, 1)
+	target.SetData(3, 1, 1, []float64{1, 0, 0})
+
+	predictor, err := NewPredictor(&net)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	arena := predictor.NewArena()
+	allocs := testing.AllocsPerRun(10, func() {
+		_, err := predictor.PredictInto(arena, input)
+		if err != nil {
+			t.Error(err)
+		}
+	})
+	if allocs != 0 {
+		t.Errorf("PredictInto should not allocate, but got %f allocations", allocs)
+	}
+
+	allocs = testing.AllocsPerRun(10, func() {
+		net.FeedForward(input)
+		err := net.Backpropagate(target)
+		if err != nil {
+			t.Error(err)
+		}
+	})
+	if allocs != 0 {
+		t.Errorf("Training step should not allocate, but got %f allocations", allocs)
+	}
+}
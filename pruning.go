@@ -0,0 +1,296 @@
+package cnns
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"sort"
+	"time"
+
+	"github.com/LdDl/cnns/tensor"
+)
+
+// PruningScope - how target sparsity is reached
+type PruningScope int
+
+const (
+	// PruneGlobal - smallest-magnitude weights are chosen among all prunable layers
+	PruneGlobal = PruningScope(iota)
+	// PrunePerLayer - every prunable layer is pruned to target sparsity independently
+	PrunePerLayer
+)
+
+// PruningSchedule - gradual pruning schedule. See ref. https://arxiv.org/abs/1710.01878 (Zhu & Gupta, 2017)
+/*
+	Sparsity is increased from InitialSparsity to FinalSparsity between StartEpoch and EndEpoch:
+		s(e) = FinalSparsity + (InitialSparsity - FinalSparsity) * (1 - (e - StartEpoch) / (EndEpoch - StartEpoch))^3
+	Frequency - prune every N epochs (1 if not set);
+	Scope - see PruningScope.
+*/
+type PruningSchedule struct {
+	InitialSparsity float64
+	FinalSparsity   float64
+	StartEpoch      int
+	EndEpoch        int
+	Frequency       int
+	Scope           PruningScope
+}
+
+// SparsityAt - returns target sparsity for given epoch and true if pruning should be done at this epoch
+func (ps *PruningSchedule) SparsityAt(epoch int) (float64, bool) {
+	frequency := ps.Frequency
+	if frequency < 1 {
+		frequency = 1
+	}
+	if epoch < ps.StartEpoch || epoch > ps.EndEpoch || (epoch-ps.StartEpoch)%frequency != 0 {
+		return 0, false
+	}
+	if ps.EndEpoch == ps.StartEpoch {
+		return ps.FinalSparsity, true
+	}
+	progress := float64(epoch-ps.StartEpoch) / float64(ps.EndEpoch-ps.StartEpoch)
+	return ps.FinalSparsity + (ps.InitialSparsity-ps.FinalSparsity)*math.Pow(1-progress, 3), true
+}
+
+// LayerSparsity - pruning statistics for single layer
+/*
+	Layer - index of layer;
+	LayerType - type of layer;
+	Total - number of weights;
+	Zeros - number of zero weights;
+	Sparsity - Zeros / Total;
+	DenseTime, SparseTime - mean duration of single feedforward with dense and sparse (CSR) kernels. Sparse kernels are available for fully connected layers only, so SparseTime is zero for others;
+	Speedup - DenseTime / SparseTime (zero if SparseTime is not measured).
+*/
+type LayerSparsity struct {
+	Layer      int
+	LayerType  string
+	Total      int
+	Zeros      int
+	Sparsity   float64
+	DenseTime  time.Duration
+	SparseTime time.Duration
+	Speedup    float64
+}
+
+// String - pretty print for layer's statistics
+func (ls LayerSparsity) String() string {
+	ret := fmt.Sprintf("Layer #%d (%s): %d/%d zeros, sparsity %.4f", ls.Layer, ls.LayerType, ls.Zeros, ls.Total, ls.Sparsity)
+	if ls.SparseTime > 0 {
+		ret += fmt.Sprintf(", dense %v, sparse %v, speedup %.2fx", ls.DenseTime, ls.SparseTime, ls.Speedup)
+	}
+	return ret
+}
+
+// prunableWeights - weights of layer, their masks and momentum buffers
+type prunableWeights[T tensor.Float] struct {
+	layer   int
+	weights []*tensor.TensorOf[T]
+	masks   []*tensor.TensorOf[T]
+	deltas  []*tensor.TensorOf[T]
+}
+
+// prunable - returns weights of convolutional and fully connected layers. Masks are allocated if they have not been yet.
+func (wh *WholeNetOf[T]) prunable() []prunableWeights[T] {
+	var ret []prunableWeights[T]
+	for l := range wh.Layers {
+		switch typed := wh.Layers[l].(type) {
+		case *ConvLayerOf[T]:
+			if typed.KernelsMasks == nil {
+				typed.KernelsMasks = make([]*tensor.TensorOf[T], len(typed.Kernels))
+				for k := range typed.Kernels {
+					typed.KernelsMasks[k] = onesLike(typed.Kernels[k])
+				}
+			}
+			ret = append(ret, prunableWeights[T]{layer: l, weights: typed.Kernels, masks: typed.KernelsMasks, deltas: typed.PreviousKernelsDeltas})
+		case *FullyConnectedLayerOf[T]:
+			if typed.WeightsMask == nil {
+				typed.WeightsMask = onesLike(typed.Weights)
+			}
+			ret = append(ret, prunableWeights[T]{
+				layer:   l,
+				weights: []*tensor.TensorOf[T]{typed.Weights},
+				masks:   []*tensor.TensorOf[T]{typed.WeightsMask},
+				deltas:  []*tensor.TensorOf[T]{typed.PreviousIterationWeights},
+			})
+		}
+	}
+	return ret
+}
+
+// Prune - zero smallest-magnitude weights of convolutional and fully connected layers to reach target sparsity (fraction of zero weights).
+// Masks are kept in layers, so pruned weights stay zero after UpdateWeights.
+func (wh *WholeNetOf[T]) Prune(sparsity float64, scope PruningScope) error {
+	if sparsity < 0 || sparsity >= 1 {
+		return errors.New("sparsity should be in range [0, 1)")
+	}
+	groups := wh.prunable()
+	if len(groups) == 0 {
+		return errors.New("network has no prunable layers")
+	}
+	switch scope {
+	case PruneGlobal:
+		pruneSmallest(groups, sparsity)
+	case PrunePerLayer:
+		for i := range groups {
+			pruneSmallest(groups[i:i+1], sparsity)
+		}
+	default:
+		return fmt.Errorf("Unrecognized pruning scope: %d", scope)
+	}
+	return nil
+}
+
+// pruneSmallest - zero smallest-magnitude weights among all groups so fraction of zeros is equal to sparsity
+func pruneSmallest[T tensor.Float](groups []prunableWeights[T], sparsity float64) {
+	var magnitudes []float64
+	for _, g := range groups {
+		for _, w := range g.weights {
+			for _, v := range w.Data {
+				magnitudes = append(magnitudes, math.Abs(float64(v)))
+			}
+		}
+	}
+	count := int(sparsity * float64(len(magnitudes)))
+	if count == 0 {
+		return
+	}
+	sort.Float64s(magnitudes)
+	threshold := magnitudes[count-1]
+	// Weights equal to threshold are pruned until target count is reached
+	remaining := count
+	for _, v := range magnitudes[:count] {
+		if v < threshold {
+			remaining--
+		}
+	}
+	for _, g := range groups {
+		for k, w := range g.weights {
+			for i, v := range w.Data {
+				m := math.Abs(float64(v))
+				if m > threshold {
+					continue
+				}
+				if m == threshold {
+					if remaining == 0 {
+						continue
+					}
+					remaining--
+				}
+				w.Data[i] = 0
+				g.masks[k].Data[i] = 0
+				g.deltas[k].Data[i] = 0
+			}
+		}
+	}
+}
+
+// SparsityReport - returns pruning statistics for convolutional and fully connected layers.
+// Speedup of sparse kernels is measured for fully connected layers on activations produced by given input (runs - number of repetitions).
+func (wh *WholeNetOf[T]) SparsityReport(input *tensor.TensorOf[T], runs int) ([]LayerSparsity, error) {
+	if runs < 1 {
+		runs = 1
+	}
+	wh.FeedForward(input)
+	var report []LayerSparsity
+	for l := range wh.Layers {
+		var weights []*tensor.TensorOf[T]
+		switch typed := wh.Layers[l].(type) {
+		case *ConvLayerOf[T]:
+			weights = typed.Kernels
+		case *FullyConnectedLayerOf[T]:
+			weights = []*tensor.TensorOf[T]{typed.Weights}
+		default:
+			continue
+		}
+		ls := LayerSparsity{
+			Layer:     l,
+			LayerType: wh.Layers[l].GetType(),
+		}
+		for _, w := range weights {
+			ls.Total += len(w.Data)
+			for _, v := range w.Data {
+				if v == 0 {
+					ls.Zeros++
+				}
+			}
+		}
+		if ls.Total != 0 {
+			ls.Sparsity = float64(ls.Zeros) / float64(ls.Total)
+		}
+		if fc, ok := wh.Layers[l].(*FullyConnectedLayerOf[T]); ok {
+			sparse := newSparseFullyConnectedLayer(fc)
+			out := tensor.NewTensorOf[T](fc.Out.Size.X, fc.Out.Size.Y, fc.Out.Size.Z)
+			st := time.Now()
+			for r := 0; r < runs; r++ {
+				fc.feedForwardInto(fc.In, out)
+			}
+			ls.DenseTime = time.Since(st) / time.Duration(runs)
+			st = time.Now()
+			for r := 0; r < runs; r++ {
+				sparse.feedForwardInto(fc.In, out)
+			}
+			ls.SparseTime = time.Since(st) / time.Duration(runs)
+			if ls.SparseTime > 0 {
+				ls.Speedup = float64(ls.DenseTime) / float64(ls.SparseTime)
+			}
+		}
+		report = append(report, ls)
+	}
+	return report, nil
+}
+
+// sparseFullyConnectedLayer - inference-only fully connected layer with weights in CSR format
+type sparseFullyConnectedLayer[T tensor.Float] struct {
+	*FullyConnectedLayerOf[T]
+	csr *tensor.CSR[T]
+}
+
+func newSparseFullyConnectedLayer[T tensor.Float](fc *FullyConnectedLayerOf[T]) *sparseFullyConnectedLayer[T] {
+	return &sparseFullyConnectedLayer[T]{
+		FullyConnectedLayerOf: fc,
+		csr:                   tensor.NewCSR(fc.Weights),
+	}
+}
+
+// feedForwardInto - sparse matrix-vector product and activation. Layer's state is not touched.
+func (sfc *sparseFullyConnectedLayer[T]) feedForwardInto(in, out *tensor.TensorOf[T]) {
+	sfc.csr.MulVecInto(out.Data, in.Data)
+	for n := range out.Data {
+		out.Data[n] = T(sfc.ActivationFunc(float64(out.Data[n])))
+	}
+}
+
+// NewSparsePredictor - constructor for Predictor which uses sparse (CSR) kernels for fully connected layers with sparsity not less than minSparsity.
+// Weights are converted to CSR once, so pruned net should not be trained further while predictor is in use.
+func NewSparsePredictor[T tensor.Float](wh *WholeNetOf[T], minSparsity float64) (*PredictorOf[T], error) {
+	p, err := NewPredictor(wh)
+	if err != nil {
+		return nil, err
+	}
+	for i := range p.layers {
+		fc, ok := wh.Layers[i].(*FullyConnectedLayerOf[T])
+		if !ok {
+			continue
+		}
+		zeros := 0
+		for _, v := range fc.Weights.Data {
+			if v == 0 {
+				zeros++
+			}
+		}
+		if float64(zeros)/float64(len(fc.Weights.Data)) >= minSparsity {
+			p.layers[i] = newSparseFullyConnectedLayer(fc)
+		}
+	}
+	return p, nil
+}
+
+// onesLike - new tensor of same size filled with ones
+func onesLike[T tensor.Float](t *tensor.TensorOf[T]) *tensor.TensorOf[T] {
+	ret := tensor.NewTensorOf[T](t.Size.X, t.Size.Y, t.Size.Z)
+	for i := range ret.Data {
+		ret.Data[i] = 1
+	}
+	return ret
+}
@@ -0,0 +1,100 @@
+package cnns
+
+import (
+	"math"
+	"math/rand"
+	"testing"
+
+	"github.com/LdDl/cnns/tensor"
+)
+
+func TestPrune(t *testing.T) {
+	rand.Seed(42)
+	fullyconnected1 := NewFullyConnectedLayer(&tensor.TDsize{X: 10, Y: 1, Z: 1}, 20)
+	fullyconnected2 := NewFullyConnectedLayer(fullyconnected1.GetOutputSize(), 4)
+	var net WholeNet
+	net.Layers = append(net.Layers, fullyconnected1, fullyconnected2)
+
+	err := net.Prune(0.5, PrunePerLayer)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+
+	input := tensor.NewTensor(10, 1, 1)
+	for i := range input.Data {
+		input.Data[i] = rand.Float64()
+	}
+	target := tensor.NewTensor(4, 1, 1)
+	target.SetData(4, 1, 1, []float64{1, 0, 0, 1})
+
+	report, err := net.SparsityReport(input, 1)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	for _, ls := range report {
+		if ls.Sparsity != 0.5 {
+			t.Errorf("Layer #%d: sparsity should be %f, but got %f", ls.Layer, 0.5, ls.Sparsity)
+		}
+	}
+
+	// Pruned weights should stay zero after training steps
+	for e := 0; e < 10; e++ {
+		net.FeedForward(input)
+		err = net.Backpropagate(target)
+		if err != nil {
+			t.Error(err)
+			return
+		}
+	}
+	report, err = net.SparsityReport(input, 1)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	for _, ls := range report {
+		if ls.Sparsity != 0.5 {
+			t.Errorf("Layer #%d: sparsity after training should be %f, but got %f", ls.Layer, 0.5, ls.Sparsity)
+		}
+	}
+
+	net.FeedForward(input)
+	predictor, err := NewSparsePredictor(&net, 0.3)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	out, err := predictor.Predict(input)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	for i := range out.Data {
+		if math.Abs(out.Data[i]-net.GetOutput().Data[i]) > 1e-12 {
+			t.Errorf("Outputs are not equal at pos #%d. Expected value: %f. Got: %f", i, net.GetOutput().Data[i], out.Data[i])
+		}
+	}
+}
+
+func TestPruningSchedule(t *testing.T) {
+	schedule := PruningSchedule{
+		InitialSparsity: 0.0,
+		FinalSparsity:   0.8,
+		StartEpoch:      2,
+		EndEpoch:        6,
+		Frequency:       2,
+	}
+	correct := map[int]float64{2: 0.0, 4: 0.7, 6: 0.8}
+	for e := 0; e < 10; e++ {
+		sparsity, ok := schedule.SparsityAt(e)
+		expected, shouldPrune := correct[e]
+		if ok != shouldPrune {
+			t.Errorf("Epoch #%d: pruning flag should be %t, but got %t", e, shouldPrune, ok)
+			continue
+		}
+		if ok && math.Abs(sparsity-expected) > 1e-12 {
+			t.Errorf("Epoch #%d: sparsity should be %f, but got %f", e, expected, sparsity)
+		}
+	}
+}
@@ -0,0 +1,424 @@
+package cnns
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"math"
+
+	"github.com/LdDl/cnns/metrics"
+	"github.com/LdDl/cnns/tensor"
+)
+
+const (
+	// PrecisionInt8 - tag for quantized (int8) models. See QuantizedNet.ExportToFile
+	PrecisionInt8 = "int8"
+	// int8 range is [-127, 127] for symmetric quantization
+	quantMax = 127
+)
+
+// QuantizedNet - net with int8 weights and activations (post-training quantization).
+/*
+	Quantization is symmetric: real = scale * q, where q in [-127, 127].
+	InputScale - scale of input tensor;
+	Layers - quantized layers;
+	Preprocessing - fitted preprocessing of inputs copied from float net (see WholeNetOf.Preprocessing).
+*/
+type QuantizedNet struct {
+	InputScale    float64           `json:"InputScale"`
+	Layers        []*QuantizedLayer `json:"Layers"`
+	Preprocessing json.RawMessage   `json:"Preprocessing,omitempty"`
+}
+
+// QuantizedLayer - quantized version of layer
+/*
+	LayerType - type of source layer ("conv", "fc", "pool", "relu", "leaky_relu");
+	InputSize, OutputSize - dimensions;
+	Stride, KernelSize - for "conv" and "pool" layers;
+	Weights - int8 weights (kernels for "conv", weights matrix for "fc");
+	WeightScales - per-channel scales: one per kernel for "conv", one per output neuron for "fc";
+	InputScale, OutputScale - activations' scales;
+	Alpha - coefficient for "leaky_relu" layer;
+	Activation - name of activation function of "fc" layer ("tanh", "sigmoid", "arctan", "softplus" or "gaussian"), stored in exported file;
+	ActivationFunc - activation function for "fc" layer (ActivationTanh if nil). It is restored from Activation on import.
+*/
+type QuantizedLayer struct {
+	LayerType      string                  `json:"LayerType"`
+	InputSize      tensor.TDsize           `json:"InputSize"`
+	OutputSize     tensor.TDsize           `json:"OutputSize"`
+	Stride         int                     `json:"Stride,omitempty"`
+	KernelSize     int                     `json:"KernelSize,omitempty"`
+	Weights        []int8                  `json:"Weights,omitempty"`
+	WeightScales   []float64               `json:"WeightScales,omitempty"`
+	InputScale     float64                 `json:"InputScale"`
+	OutputScale    float64                 `json:"OutputScale"`
+	Alpha          float64                 `json:"Alpha,omitempty"`
+	Activation     string                  `json:"Activation,omitempty"`
+	ActivationFunc func(v float64) float64 `json:"-"`
+}
+
+// QuantizationReport - comparison of float and quantized nets on test set
+/*
+	Samples - number of test samples;
+	FloatAccuracy - accuracy (argmax of output equals argmax of target) of float net;
+	QuantizedAccuracy - accuracy of quantized net;
+	Agreement - fraction of samples where both nets predict the same class;
+	MeanAbsError - mean absolute difference between outputs of float and quantized nets;
+	MaxAbsError - max absolute difference between outputs of float and quantized nets.
+*/
+type QuantizationReport struct {
+	Samples           int     `json:"Samples"`
+	FloatAccuracy     float64 `json:"FloatAccuracy"`
+	QuantizedAccuracy float64 `json:"QuantizedAccuracy"`
+	Agreement         float64 `json:"Agreement"`
+	MeanAbsError      float64 `json:"MeanAbsError"`
+	MaxAbsError       float64 `json:"MaxAbsError"`
+}
+
+// String - pretty print for report
+func (r *QuantizationReport) String() string {
+	return fmt.Sprintf("Samples: %d\nFloat accuracy: %.4f\nQuantized accuracy: %.4f\nAgreement: %.4f\nMean abs error: %.6f\nMax abs error: %.6f",
+		r.Samples, r.FloatAccuracy, r.QuantizedAccuracy, r.Agreement, r.MeanAbsError, r.MaxAbsError)
+}
+
+// Quantize - post-training quantization of trained net. Activations' scales are calibrated on provided samples.
+/*
+	wh - trained net (layers "conv", "fc", "pool", "relu" and "leaky_relu" are supported);
+	calibration - sample dataset (inputs only). Its distribution should be similar to real data.
+*/
+func Quantize[T tensor.Float](wh *WholeNetOf[T], calibration []*tensor.TensorOf[T]) (*QuantizedNet, error) {
+	if len(wh.Layers) == 0 {
+		return nil, errors.New("network has no layers")
+	}
+	if len(calibration) == 0 {
+		return nil, errors.New("calibration dataset is empty")
+	}
+
+	// Calibration: max absolute value of input and of every layer's output
+	inputMax := 0.0
+	outputMax := make([]float64, len(wh.Layers))
+	for i := range calibration {
+		inputMax = math.Max(inputMax, maxAbs(calibration[i].Data))
+		wh.FeedForward(calibration[i])
+		for l := range wh.Layers {
+			outputMax[l] = math.Max(outputMax[l], maxAbs(wh.Layers[l].GetOutput().Data))
+		}
+	}
+
+	q := &QuantizedNet{
+		InputScale:    scaleFor(inputMax),
+		Layers:        make([]*QuantizedLayer, len(wh.Layers)),
+		Preprocessing: wh.Preprocessing,
+	}
+	inScale := q.InputScale
+	for l := range wh.Layers {
+		layer := wh.Layers[l]
+		ql := &QuantizedLayer{
+			LayerType:   layer.GetType(),
+			InputSize:   *layer.GetInputSize(),
+			OutputSize:  *layer.GetOutputSize(),
+			InputScale:  inScale,
+			OutputScale: inScale, // pooling and ReLU layers do not change scale
+		}
+		switch typed := layer.(type) {
+		case *ConvLayerOf[T]:
+			ql.Stride = typed.Stride
+			ql.KernelSize = typed.KernelSize
+			ql.OutputScale = scaleFor(outputMax[l])
+			ql.Weights, ql.WeightScales = quantizeChannels(typed.Kernels)
+		case *FullyConnectedLayerOf[T]:
+			ql.OutputScale = scaleFor(outputMax[l])
+			ql.ActivationFunc = typed.ActivationFunc
+			rows := make([]*tensor.TensorOf[T], typed.Weights.Size.Y)
+			for n := range rows {
+				rows[n] = &tensor.TensorOf[T]{
+					Data: typed.Weights.Data[n*typed.Weights.Size.X : (n+1)*typed.Weights.Size.X],
+					Size: &tensor.TDsize{X: typed.Weights.Size.X, Y: 1, Z: 1},
+				}
+			}
+			ql.Weights, ql.WeightScales = quantizeChannels(rows)
+		case *MaxPoolingLayerOf[T]:
+			ql.Stride = typed.Stride
+			ql.KernelSize = typed.ExtendFilter
+		case *ReLULayerOf[T]:
+			// Nothing to quantize
+		case *LeakyReLULayerOf[T]:
+			ql.Alpha = typed.alpha
+		default:
+			return nil, fmt.Errorf("Layer #%d of type '%s' can not be quantized", l, layer.GetType())
+		}
+		q.Layers[l] = ql
+		inScale = ql.OutputScale
+	}
+	return q, nil
+}
+
+// Predict - forward pass through quantized net. Input is quantized, output is dequantized.
+func (q *QuantizedNet) Predict(input *tensor.Tensor) (*tensor.Tensor, error) {
+	if len(q.Layers) == 0 {
+		return nil, errors.New("network has no layers")
+	}
+	inSize := q.Layers[0].InputSize
+	if input.Size.X != inSize.X || input.Size.Y != inSize.Y || input.Size.Z != inSize.Z {
+		return nil, tensor.ErrDimensionsAreNotEqual
+	}
+	in := make([]int8, len(input.Data))
+	for i := range input.Data {
+		in[i] = quantizeValue(input.Data[i], q.InputScale)
+	}
+	for l := range q.Layers {
+		out := make([]int8, q.Layers[l].OutputSize.Total())
+		err := q.Layers[l].feedForward(in, out)
+		if err != nil {
+			return nil, err
+		}
+		in = out
+	}
+	last := q.Layers[len(q.Layers)-1]
+	ret := tensor.NewTensor(last.OutputSize.X, last.OutputSize.Y, last.OutputSize.Z)
+	for i := range in {
+		ret.Data[i] = float64(in[i]) * last.OutputScale
+	}
+	return ret, nil
+}
+
+// feedForward - int8 kernels. Accumulation is done in int32.
+func (ql *QuantizedLayer) feedForward(in, out []int8) error {
+	inX, inY, inZ := ql.InputSize.X, ql.InputSize.Y, ql.InputSize.Z
+	outX, outY, outZ := ql.OutputSize.X, ql.OutputSize.Y, ql.OutputSize.Z
+	switch ql.LayerType {
+	case "conv":
+		k := ql.KernelSize
+		kernelTotal := k * k * inZ
+		for filter := 0; filter < outZ; filter++ {
+			kernel := ql.Weights[filter*kernelTotal : (filter+1)*kernelTotal]
+			multiplier := ql.WeightScales[filter] * ql.InputScale
+			for x := 0; x < outX; x++ {
+				for y := 0; y < outY; y++ {
+					mappedX, mappedY := x*ql.Stride, y*ql.Stride
+					var acc int32
+					for i := 0; i < k; i++ {
+						for j := 0; j < k; j++ {
+							for z := 0; z < inZ; z++ {
+								f := int32(kernel[z*k*k+j*k+i])
+								v := int32(in[z*inX*inY+(mappedY+j)*inX+mappedX+i])
+								acc += f * v
+							}
+						}
+					}
+					out[filter*outX*outY+y*outX+x] = quantizeValue(float64(acc)*multiplier, ql.OutputScale)
+				}
+			}
+		}
+	case "fc":
+		total := inX * inY * inZ
+		activation := ql.ActivationFunc
+		if activation == nil {
+			activation = ActivationTanh
+		}
+		for n := 0; n < outX; n++ {
+			row := ql.Weights[n*total : (n+1)*total]
+			var acc int32
+			for m := 0; m < total; m++ {
+				acc += int32(row[m]) * int32(in[m])
+			}
+			v := activation(float64(acc) * ql.WeightScales[n] * ql.InputScale)
+			out[n] = quantizeValue(v, ql.OutputScale)
+		}
+	case "pool":
+		for z := 0; z < outZ; z++ {
+			for x := 0; x < outX; x++ {
+				for y := 0; y < outY; y++ {
+					mappedX, mappedY := x*ql.Stride, y*ql.Stride
+					mval := int8(math.MinInt8)
+					for i := 0; i < ql.KernelSize; i++ {
+						for j := 0; j < ql.KernelSize; j++ {
+							v := in[z*inX*inY+(mappedY+j)*inX+mappedX+i]
+							if v > mval {
+								mval = v
+							}
+						}
+					}
+					out[z*outX*outY+y*outX+x] = mval
+				}
+			}
+		}
+	case "relu":
+		for i := range in {
+			if in[i] < 0 {
+				out[i] = 0
+			} else {
+				out[i] = in[i]
+			}
+		}
+	case "leaky_relu":
+		for i := range in {
+			if in[i] < 0 {
+				out[i] = int8(math.Round(ql.Alpha * float64(in[i])))
+			} else {
+				out[i] = in[i]
+			}
+		}
+	default:
+		return fmt.Errorf("Unrecognized quantized layer type: %v", ql.LayerType)
+	}
+	return nil
+}
+
+// CompareQuantized - evaluate float and quantized nets on test set. Targets are one-hot encoded tensors.
+func CompareQuantized[T tensor.Float](wh *WholeNetOf[T], q *QuantizedNet, inputs, targets []*tensor.TensorOf[T]) (*QuantizationReport, error) {
+	if len(inputs) != len(targets) {
+		return nil, errors.New("number of inputs not equal to number of targets")
+	}
+	report := &QuantizationReport{
+		Samples: len(inputs),
+	}
+	if len(inputs) == 0 {
+		return report, nil
+	}
+	floatCorrect, quantCorrect, agreed := 0, 0, 0
+	sumAbsError := 0.0
+	numOutputs := 0
+	for i := range inputs {
+		wh.FeedForward(inputs[i])
+		floatOut := wh.GetOutput()
+		quantOut, err := q.Predict(tensor.Convert[float64](inputs[i]))
+		if err != nil {
+			return nil, err
+		}
+		for j := range quantOut.Data {
+			diff := math.Abs(float64(floatOut.Data[j]) - quantOut.Data[j])
+			sumAbsError += diff
+			report.MaxAbsError = math.Max(report.MaxAbsError, diff)
+		}
+		numOutputs += len(quantOut.Data)
+
+		target := metrics.Argmax(tensor.ConvertData[float64](targets[i].Data))
+		floatIdx := metrics.Argmax(tensor.ConvertData[float64](floatOut.Data))
+		quantIdx := metrics.Argmax(quantOut.Data)
+		if floatIdx == target {
+			floatCorrect++
+		}
+		if quantIdx == target {
+			quantCorrect++
+		}
+		if floatIdx == quantIdx {
+			agreed++
+		}
+	}
+	report.FloatAccuracy = float64(floatCorrect) / float64(len(inputs))
+	report.QuantizedAccuracy = float64(quantCorrect) / float64(len(inputs))
+	report.Agreement = float64(agreed) / float64(len(inputs))
+	report.MeanAbsError = sumAbsError / float64(numOutputs)
+	return report, nil
+}
+
+// ExportToFile saves quantized network to file. Structure of the net is stored in the same format as for float nets ("Network" section), weights are stored in "Quantized" section.
+// Activation functions of fully connected layers are stored by names, so custom functions can not be exported.
+func (q *QuantizedNet) ExportToFile(fname string) error {
+	var save NetJSON
+	save.Precision = PrecisionInt8
+	// Layers are copied to store names of activation functions, so net itself is not changed
+	quantized := *q
+	quantized.Layers = make([]*QuantizedLayer, len(q.Layers))
+	save.Quantized = &quantized
+	for i, ql := range q.Layers {
+		copied := *ql
+		if ql.LayerType == "fc" {
+			activation := ql.ActivationFunc
+			if activation == nil {
+				activation = ActivationTanh
+			}
+			copied.Activation = activationName(activation)
+			if copied.Activation == "" {
+				return fmt.Errorf("Layer #%d: custom activation function can not be exported", i)
+			}
+		}
+		quantized.Layers[i] = &copied
+		inSize := ql.InputSize
+		outSize := ql.OutputSize
+		newLayer := NetLayerJSON{
+			LayerType: ql.LayerType,
+			InputSize: &inSize,
+		}
+		newLayer.Parameters.Stride = ql.Stride
+		newLayer.Parameters.KernelSize = ql.KernelSize
+		if ql.LayerType == "fc" {
+			newLayer.OutputSize = &outSize
+		}
+		save.Network.Layers = append(save.Network.Layers, newLayer)
+	}
+	saveJSON, err := json.Marshal(save)
+	if err != nil {
+		return err
+	}
+	return ioutil.WriteFile(fname, saveJSON, 0644)
+}
+
+// ImportQuantizedFromFile loads quantized network from file (see QuantizedNet.ExportToFile)
+func ImportQuantizedFromFile(fname string) (*QuantizedNet, error) {
+	fileBytes, err := ioutil.ReadFile(fname)
+	if err != nil {
+		return nil, err
+	}
+	var data NetJSON
+	err = json.Unmarshal(fileBytes, &data)
+	if err != nil {
+		return nil, err
+	}
+	if data.Precision != PrecisionInt8 || data.Quantized == nil {
+		return nil, fmt.Errorf("File '%s' does not contain quantized network", fname)
+	}
+	for i, ql := range data.Quantized.Layers {
+		if ql.LayerType != "fc" {
+			continue
+		}
+		ql.ActivationFunc = activationByName(ql.Activation)
+		if ql.ActivationFunc == nil {
+			return nil, fmt.Errorf("Layer #%d: unknown activation function '%s'", i, ql.Activation)
+		}
+	}
+	return data.Quantized, nil
+}
+
+// quantizeChannels - quantize every tensor (channel) with its own scale. Returns flattened weights and scales.
+func quantizeChannels[T tensor.Float](channels []*tensor.TensorOf[T]) ([]int8, []float64) {
+	var weights []int8
+	scales := make([]float64, len(channels))
+	for c := range channels {
+		scales[c] = scaleFor(maxAbs(channels[c].Data))
+		for _, v := range channels[c].Data {
+			weights = append(weights, quantizeValue(float64(v), scales[c]))
+		}
+	}
+	return weights, scales
+}
+
+// quantizeValue - real value to int8 with given scale (symmetric quantization)
+func quantizeValue(v, scale float64) int8 {
+	q := math.Round(v / scale)
+	if q > quantMax {
+		return quantMax
+	}
+	if q < -quantMax {
+		return -quantMax
+	}
+	return int8(q)
+}
+
+// scaleFor - scale for symmetric quantization of values in [-maxAbs, maxAbs]
+func scaleFor(maxAbs float64) float64 {
+	if maxAbs == 0 {
+		return 1.0
+	}
+	return maxAbs / quantMax
+}
+
+func maxAbs[T tensor.Float](data []T) float64 {
+	max := 0.0
+	for i := range data {
+		max = math.Max(max, math.Abs(float64(data[i])))
+	}
+	return max
+}
@@ -0,0 +1,115 @@
+package cnns
+
+import (
+	"bytes"
+	"io/ioutil"
+	"math/rand"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/LdDl/cnns/tensor"
+)
+
+func TestQuantize(t *testing.T) {
+	rand.Seed(42)
+	conv := NewConvLayer(1, 3, 2, tensor.TDsize{X: 8, Y: 9, Z: 1})
+	relu := NewReLULayer(conv.GetOutputSize())
+	maxpool := NewMaxPoolingLayer(2, 2, relu.GetOutputSize())
+	fullyconnected := NewFullyConnectedLayer(maxpool.GetOutputSize(), 3)
+	fullyconnected.SetActivationFunc(ActivationSygmoid)
+	fullyconnected.SetActivationDerivativeFunc(ActivationSygmoidDerivative)
+	var net WholeNet
+	net.Layers = append(net.Layers, conv, relu, maxpool, fullyconnected)
+
+	inputs := make([]*tensor.Tensor, 50)
+	targets := make([]*tensor.Tensor, len(inputs))
+	for i := range inputs {
+		inputs[i] = tensor.NewTensor(8, 9, 1)
+		for j := range inputs[i].Data {
+			inputs[i].Data[j] = rand.Float64()
+		}
+		targets[i] = tensor.NewTensor(3, 1, 1)
+		targets[i].Data[rand.Intn(3)] = 1.0
+	}
+
+	q, err := Quantize(&net, inputs)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	report, err := CompareQuantized(&net, q, inputs, targets)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	if report.MeanAbsError > 0.02 {
+		t.Errorf("Mean abs error between float and quantized outputs is too big: %f", report.MeanAbsError)
+	}
+	if report.Agreement < 0.9 {
+		t.Errorf("Agreement between float and quantized nets is too low: %f", report.Agreement)
+	}
+
+	dir, err := ioutil.TempDir("", "cnns")
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	defer os.RemoveAll(dir)
+	fname := filepath.Join(dir, "net_int8.json")
+	err = q.ExportToFile(fname)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	imported, err := ImportQuantizedFromFile(fname)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	expected, err := q.Predict(inputs[0])
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	got, err := imported.Predict(inputs[0])
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	for i := range expected.Data {
+		if expected.Data[i] != got.Data[i] {
+			t.Errorf("Outputs are not equal at pos #%d. Expected value: %f. Got: %f", i, expected.Data[i], got.Data[i])
+		}
+	}
+
+	// Unknown activation function
+	fileBytes, err := ioutil.ReadFile(fname)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	err = ioutil.WriteFile(fname, bytes.Replace(fileBytes, []byte(`"Activation":"sigmoid"`), []byte(`"Activation":"swish"`), 1), 0644)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	if _, err = ImportQuantizedFromFile(fname); err == nil {
+		t.Errorf("Import of quantized net with unknown activation function should fail")
+	}
+	q.Layers[3].ActivationFunc = func(v float64) float64 { return v }
+	if err = q.ExportToFile(fname); err == nil {
+		t.Errorf("Export of quantized net with custom activation function should fail")
+	}
+	for i, ql := range q.Layers {
+		if ql.Activation != "" {
+			t.Errorf("Export should not change layer #%d, but activation is set to '%s'", i, ql.Activation)
+		}
+	}
+
+	var floatNet WholeNet
+	err = floatNet.ImportFromFile(fname, false)
+	if err == nil {
+		t.Errorf("Import of quantized net as float net should fail")
+	}
+}
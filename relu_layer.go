@@ -6,85 +6,98 @@ import (
 	"github.com/LdDl/cnns/tensor"
 )
 
-// ReLULayer - Rectified Linear Unit layer (activation: max(0, x))
+// ReLULayerOf - Rectified Linear Unit layer (activation: max(0, x))
 /*
 	In - Input data
 	Out - Output data
 	LocalDelta - incoming gradients*weights (backpropagation)
 */
-type ReLULayer struct {
-	In         *tensor.Tensor
-	Out        *tensor.Tensor
-	LocalDelta *tensor.Tensor
+type ReLULayerOf[T tensor.Float] struct {
+	In         *tensor.TensorOf[T]
+	Out        *tensor.TensorOf[T]
+	LocalDelta *tensor.TensorOf[T]
 }
 
+// ReLULayer - ReLU layer of float64 precision
+type ReLULayer = ReLULayerOf[float64]
+
 // NewReLULayer - Constructor for new ReLU layer. You need to specify input size
 /*
 	inSize - input layer's size
 */
 func NewReLULayer(inSize *tensor.TDsize) Layer {
-	newLayer := &ReLULayer{
-		LocalDelta: tensor.NewTensor(inSize.X, inSize.Y, inSize.Z),
-		In:         tensor.NewTensor(inSize.X, inSize.Y, inSize.Z),
-		Out:        tensor.NewTensor(inSize.X, inSize.Y, inSize.Z),
+	return NewReLULayerOf[float64](inSize)
+}
+
+// NewReLULayerOf - constructor for new ReLU layer of given precision (float32 or float64). See NewReLULayer.
+func NewReLULayerOf[T tensor.Float](inSize *tensor.TDsize) LayerOf[T] {
+	newLayer := &ReLULayerOf[T]{
+		LocalDelta: tensor.NewTensorOf[T](inSize.X, inSize.Y, inSize.Z),
+		In:         tensor.NewTensorOf[T](inSize.X, inSize.Y, inSize.Z),
+		Out:        tensor.NewTensorOf[T](inSize.X, inSize.Y, inSize.Z),
 	}
 	return newLayer
 }
 
 // SetCustomWeights - Set user's weights (make it carefully)
-func (relu *ReLULayer) SetCustomWeights(t []*tensor.Tensor) {
+func (relu *ReLULayerOf[T]) SetCustomWeights(t []*tensor.TensorOf[T]) {
 	fmt.Println("There are no weights for ReLU layer")
 }
 
 // GetOutputSize - Return output size (dimensions)
-func (relu *ReLULayer) GetOutputSize() *tensor.TDsize {
+func (relu *ReLULayerOf[T]) GetOutputSize() *tensor.TDsize {
 	return relu.Out.Size
 }
 
 // GetInputSize - Return input size (dimensions)
-func (relu *ReLULayer) GetInputSize() *tensor.TDsize {
+func (relu *ReLULayerOf[T]) GetInputSize() *tensor.TDsize {
 	return relu.In.Size
 }
 
 // GetOutput - Return ReLU layer's output
-func (relu *ReLULayer) GetOutput() *tensor.Tensor {
+func (relu *ReLULayerOf[T]) GetOutput() *tensor.TensorOf[T] {
 	return relu.Out
 }
 
 // GetWeights - Return ReLU layer's weights
-func (relu *ReLULayer) GetWeights() []*tensor.Tensor {
+func (relu *ReLULayerOf[T]) GetWeights() []*tensor.TensorOf[T] {
 	fmt.Println("There are no weights for ReLU layer")
-	return []*tensor.Tensor{}
+	return []*tensor.TensorOf[T]{}
 }
 
 // GetGradients - Return ReLU layer's gradients
-func (relu *ReLULayer) GetGradients() *tensor.Tensor {
+func (relu *ReLULayerOf[T]) GetGradients() *tensor.TensorOf[T] {
 	return relu.LocalDelta
 }
 
 // FeedForward - Feed data to ReLU layer
-func (relu *ReLULayer) FeedForward(t *tensor.Tensor) {
+func (relu *ReLULayerOf[T]) FeedForward(t *tensor.TensorOf[T]) {
 	relu.In = t
 	relu.DoActivation()
 }
 
 // DoActivation - ReLU layer's output activation
-func (relu *ReLULayer) DoActivation() {
-	for i := 0; i < relu.In.Size.X; i++ {
-		for j := 0; j < relu.In.Size.Y; j++ {
-			for z := 0; z < relu.In.Size.Z; z++ {
-				v := relu.In.Get(i, j, z)
+func (relu *ReLULayerOf[T]) DoActivation() {
+	relu.feedForwardInto(relu.In, relu.Out)
+}
+
+// feedForwardInto - Activate input and store result in provided output. Layer's state is not touched.
+func (relu *ReLULayerOf[T]) feedForwardInto(in, out *tensor.TensorOf[T]) {
+	for i := 0; i < in.Size.X; i++ {
+		for j := 0; j < in.Size.Y; j++ {
+			for z := 0; z < in.Size.Z; z++ {
+				v := in.Get(i, j, z)
 				if v < 0 {
 					v = 0
 				}
-				relu.Out.Set(i, j, z, v)
+				out.Set(i, j, z, v)
 			}
 		}
 	}
 }
 
 // CalculateGradients - Calculate ReLU layer's gradients
-func (relu *ReLULayer) CalculateGradients(nextLayerGrad *tensor.Tensor) {
+func (relu *ReLULayerOf[T]) CalculateGradients(nextLayerGrad *tensor.TensorOf[T]) {
 	for i := 0; i < relu.In.Size.X; i++ {
 		for j := 0; j < relu.In.Size.Y; j++ {
 			for z := 0; z < relu.In.Size.Z; z++ {
@@ -99,7 +112,7 @@ func (relu *ReLULayer) CalculateGradients(nextLayerGrad *tensor.Tensor) {
 }
 
 // UpdateWeights - Just to point, that ReLU layer does NOT updating weights
-func (relu *ReLULayer) UpdateWeights() {
+func (relu *ReLULayerOf[T]) UpdateWeights() {
 	/*
 		Empty
 		Need for layer interface.
@@ -107,45 +120,45 @@ func (relu *ReLULayer) UpdateWeights() {
 }
 
 // PrintOutput - Pretty print ReLU layer's output
-func (relu *ReLULayer) PrintOutput() {
+func (relu *ReLULayerOf[T]) PrintOutput() {
 	fmt.Println("Printing ReLU Layer output...")
 	relu.Out.Print()
 }
 
 // PrintWeights - Just to point, that ReLU layer has not weights
-func (relu *ReLULayer) PrintWeights() {
+func (relu *ReLULayerOf[T]) PrintWeights() {
 	fmt.Println("There are no weights for ReLU layer")
 }
 
 // PrintGradients - Print relu layer's local gradients
-func (relu *ReLULayer) PrintGradients() {
+func (relu *ReLULayerOf[T]) PrintGradients() {
 	fmt.Println("Printing ReLU Layer gradients...")
 	relu.LocalDelta.Print()
 }
 
 // SetActivationFunc - Set activation function for layer
-func (relu *ReLULayer) SetActivationFunc(f func(v float64) float64) {
+func (relu *ReLULayerOf[T]) SetActivationFunc(f func(v float64) float64) {
 	// Nothing here. Just for interface.
 	fmt.Println("You can not set activation function for ReLU layer")
 }
 
 // SetActivationDerivativeFunc - Set derivative of activation function
-func (relu *ReLULayer) SetActivationDerivativeFunc(f func(v float64) float64) {
+func (relu *ReLULayerOf[T]) SetActivationDerivativeFunc(f func(v float64) float64) {
 	// Nothing here. Just for interface.
 	fmt.Println("You can not set derivative of activation function for ReLU layer")
 }
 
 // GetStride - Return stride of layer
-func (relu *ReLULayer) GetStride() int {
+func (relu *ReLULayerOf[T]) GetStride() int {
 	return 0
 }
 
 // GetKernelSize - Return "conv" as layer's type
-func (relu *ReLULayer) GetKernelSize() int {
+func (relu *ReLULayerOf[T]) GetKernelSize() int {
 	return 0
 }
 
 // GetType - Return "relu" as layer's type
-func (relu *ReLULayer) GetType() string {
+func (relu *ReLULayerOf[T]) GetType() string {
 	return "relu"
 }
@@ -0,0 +1,404 @@
+// Package report generates single self-contained HTML file describing training run: summary and structure of the net, hyperparameters, curves of metrics, confusion matrix and misclassified samples.
+package report
+
+import (
+	"bytes"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"html/template"
+	"image"
+	"image/png"
+	"io"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/LdDl/cnns"
+	"github.com/LdDl/cnns/metrics"
+	"github.com/LdDl/cnns/plot"
+	"github.com/LdDl/cnns/tensor"
+	"github.com/LdDl/cnns/utils/im"
+	"github.com/LdDl/cnns/visualize"
+)
+
+// Sample - misclassified sample
+/*
+	Index - index of sample in dataset;
+	Actual, Predicted - classes;
+	Confidence - output of net for predicted class.
+*/
+type Sample[T tensor.Float] struct {
+	Index      int
+	Input      *tensor.TensorOf[T]
+	Actual     int
+	Predicted  int
+	Confidence float64
+}
+
+// ReportOf - content of HTML report. Sections without data are omitted
+/*
+	Title - title of page ("Training report" if not set);
+	Net - trained net (summary and Graphviz structure);
+	Params - parameters of optimizer (optional, parameters recorded by History are used if nil; omitted if neither is set);
+	Hyperparameters - other parameters of training run, e.g. "Epochs": "20" (optional);
+	History - metrics recorded during training (optional). Every metric is plotted on separate chart along with its validation counterpart;
+	LogScale - names of metrics plotted with logarithmic scale (e.g. "loss", "lr");
+	ConfusionMatrix - confusion matrix on test data (optional, see Evaluate);
+	Misclassified - samples shown as images (optional, see Evaluate).
+*/
+type ReportOf[T tensor.Float] struct {
+	Title           string
+	Net             *cnns.WholeNetOf[T]
+	Params          *cnns.LearningParams
+	Hyperparameters map[string]string
+	History         *cnns.History
+	LogScale        []string
+	ConfusionMatrix *metrics.ConfusionMatrix
+	Misclassified   []Sample[T]
+}
+
+// Report - report for net of float64 precision
+type Report = ReportOf[float64]
+
+// NewReport - constructor for ReportOf
+func NewReport[T tensor.Float](net *cnns.WholeNetOf[T], history *cnns.History) *ReportOf[T] {
+	return &ReportOf[T]{
+		Net:     net,
+		History: history,
+	}
+}
+
+// Evaluate - feeds samples of dataset to the net, builds confusion matrix and collects up to maxMisclassified misclassified samples (all if negative)
+/*
+	classes - names of classes (optional).
+*/
+func (r *ReportOf[T]) Evaluate(dataset cnns.DatasetOf[T], classes []string, maxMisclassified int) error {
+	if r.Net == nil || len(r.Net.Layers) == 0 {
+		return errors.New("network has no layers")
+	}
+	var outputs, targets [][]float64
+	r.ConfusionMatrix = nil
+	r.Misclassified = nil
+	for i := 0; i < dataset.Len(); i++ {
+		input, target, err := dataset.Get(i)
+		if err != nil {
+			return err
+		}
+		r.Net.FeedForward(input)
+		output := tensor.ConvertData[float64](r.Net.GetOutput().Data)
+		outputs = append(outputs, output)
+		targets = append(targets, tensor.ConvertData[float64](target.Data))
+		// Inputs are kept for misclassified samples only
+		if maxMisclassified >= 0 && len(r.Misclassified) >= maxMisclassified {
+			continue
+		}
+		actual, err := metrics.TargetClass(output, targets[i])
+		if err != nil {
+			return fmt.Errorf("sample #%d: %w", i, err)
+		}
+		predicted := metrics.PredictedClass(output)
+		if actual == predicted {
+			continue
+		}
+		confidence := output[0]
+		if len(output) > 1 {
+			confidence = output[predicted]
+		}
+		r.Misclassified = append(r.Misclassified, Sample[T]{
+			Index:      i,
+			Input:      input,
+			Actual:     actual,
+			Predicted:  predicted,
+			Confidence: confidence,
+		})
+	}
+	cm, err := metrics.NewConfusionMatrix(outputs, targets, len(classes))
+	if err != nil {
+		return err
+	}
+	cm.Labels = classes
+	r.ConfusionMatrix = cm
+	return nil
+}
+
+// pair - named value for tables of page
+type pair struct {
+	Name  string
+	Value string
+}
+
+// chart - inline SVG chart
+type chart struct {
+	SVG template.HTML
+}
+
+// sampleView - rendered misclassified sample
+type sampleView struct {
+	Image      template.URL
+	Index      int
+	Actual     string
+	Predicted  string
+	Confidence string
+}
+
+// page - data of HTML template
+type page struct {
+	Title           string
+	Generated       string
+	Summary         string
+	Graphviz        string
+	Hyperparameters []pair
+	FinalMetrics    []pair
+	Charts          []chart
+	ConfusionMatrix template.HTML
+	Accuracy        string
+	Misclassified   []sampleView
+}
+
+// Write - writes HTML report
+func (r *ReportOf[T]) Write(w io.Writer) error {
+	p := page{
+		Title:     r.Title,
+		Generated: time.Now().Format(time.RFC1123),
+	}
+	if p.Title == "" {
+		p.Title = "Training report"
+	}
+	if r.Net != nil && len(r.Net.Layers) != 0 {
+		p.Summary = r.Net.GetSummary().String()
+		p.Graphviz = r.Net.GetGraphvizTextWith(cnns.GraphvizOptions{Mode: cnns.GraphvizLayers})
+	}
+
+	params := r.Params
+	if params == nil && r.History != nil {
+		params = r.History.Params
+	}
+	if params != nil {
+		p.Hyperparameters = []pair{
+			{"LearningRate", formatFloat(params.LearningRate)},
+			{"Momentum", formatFloat(params.Momentum)},
+			{"WeightDecay", formatFloat(params.WeightDecay)},
+		}
+	}
+	names := make([]string, 0, len(r.Hyperparameters))
+	for name := range r.Hyperparameters {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		p.Hyperparameters = append(p.Hyperparameters, pair{name, r.Hyperparameters[name]})
+	}
+
+	if r.History != nil && len(r.History.Epochs) != 0 {
+		last := r.History.Epochs[len(r.History.Epochs)-1]
+		p.FinalMetrics = append(p.FinalMetrics, pair{"epochs", strconv.Itoa(len(r.History.Epochs))})
+		for _, name := range r.History.MetricNames() {
+			if v, ok := last.Metrics[name]; ok {
+				p.FinalMetrics = append(p.FinalMetrics, pair{name, formatFloat(v)})
+			}
+		}
+		charts, err := r.charts()
+		if err != nil {
+			return err
+		}
+		p.Charts = charts
+	}
+
+	if r.ConfusionMatrix != nil && r.ConfusionMatrix.NumClasses() != 0 {
+		var buf bytes.Buffer
+		err := plot.ConfusionMatrix(&buf, r.ConfusionMatrix, plot.Config{})
+		if err != nil {
+			return err
+		}
+		p.ConfusionMatrix = inlineSVG(buf.Bytes())
+		p.Accuracy = formatFloat(r.ConfusionMatrix.Accuracy())
+	}
+
+	for _, s := range r.Misclassified {
+		img, err := sampleImage(s.Input)
+		if err != nil {
+			return fmt.Errorf("Sample #%d: %w", s.Index, err)
+		}
+		p.Misclassified = append(p.Misclassified, sampleView{
+			Image:      img,
+			Index:      s.Index,
+			Actual:     r.label(s.Actual),
+			Predicted:  r.label(s.Predicted),
+			Confidence: strconv.FormatFloat(s.Confidence, 'f', 3, 64),
+		})
+	}
+	return pageTemplate.Execute(w, p)
+}
+
+// charts - curves of recorded metrics: one chart for every metric and its validation counterpart
+func (r *ReportOf[T]) charts() ([]chart, error) {
+	recorded := make(map[string]bool)
+	for _, name := range r.History.MetricNames() {
+		recorded[name] = true
+	}
+	var ret []chart
+	for _, name := range r.History.MetricNames() {
+		if strings.HasPrefix(name, "val_") && recorded[strings.TrimPrefix(name, "val_")] {
+			continue
+		}
+		logScale := false
+		for _, l := range r.LogScale {
+			logScale = logScale || l == name
+		}
+		var buf bytes.Buffer
+		err := plot.History(&buf, r.History, plot.Config{Width: 480, Height: 300, Title: name, LogScale: logScale}, name)
+		if err == plot.ErrNoValues {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		ret = append(ret, chart{SVG: inlineSVG(buf.Bytes())})
+	}
+	return ret, nil
+}
+
+// label - name of class
+func (r *ReportOf[T]) label(class int) string {
+	if r.ConfusionMatrix != nil && class < len(r.ConfusionMatrix.Labels) {
+		return r.ConfusionMatrix.Labels[class]
+	}
+	return strconv.Itoa(class)
+}
+
+// inlineSVG - SVG document without XML declaration, so it can be embedded into HTML
+func inlineSVG(data []byte) template.HTML {
+	text := string(data)
+	if i := strings.Index(text, "<svg"); i >= 0 {
+		text = text[i:]
+	}
+	// SVG is generated by package plot and its text is escaped already
+	return template.HTML(text)
+}
+
+// sampleImage - input tensor as PNG data URL. Tensors with 1 or 3 channels are drawn as image (values are scaled to [0, 1]), other ones as grid of channels
+func sampleImage[T tensor.Float](input *tensor.TensorOf[T]) (template.URL, error) {
+	var img image.Image
+	data := tensor.Convert[float64](input)
+	if data.Size.Z == 1 || data.Size.Z == 3 {
+		min, max := data.Data[0], data.Data[0]
+		for _, v := range data.Data {
+			if v < min {
+				min = v
+			}
+			if v > max {
+				max = v
+			}
+		}
+		if max > min {
+			for i := range data.Data {
+				data.Data[i] = (data.Data[i] - min) / (max - min)
+			}
+		}
+		var err error
+		img, err = im.FromTensor(data)
+		if err != nil {
+			return "", err
+		}
+	} else {
+		tiles, err := visualize.TensorTiles(data, "ch", true)
+		if err != nil {
+			return "", err
+		}
+		img = visualize.Grid(tiles, visualize.GridConfig{Scale: 1})
+	}
+	var buf bytes.Buffer
+	err := png.Encode(&buf, img)
+	if err != nil {
+		return "", err
+	}
+	return template.URL("data:image/png;base64," + base64.StdEncoding.EncodeToString(buf.Bytes())), nil
+}
+
+// formatFloat - shortest representation of value
+func formatFloat(v float64) string {
+	return strconv.FormatFloat(v, 'g', 6, 64)
+}
+
+// Save - writes HTML report to file
+func (r *ReportOf[T]) Save(fname string) error {
+	f, err := os.Create(fname)
+	if err != nil {
+		return err
+	}
+	err = r.Write(f)
+	if err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+var pageTemplate = template.Must(template.New("report").Parse(`<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<title>{{.Title}}</title>
+<style>
+body { font-family: sans-serif; color: #333333; margin: 24px auto; max-width: 1040px; }
+h1 { margin-bottom: 4px; }
+h2 { border-bottom: 1px solid #e0e0e0; padding-bottom: 4px; margin-top: 32px; }
+table { border-collapse: collapse; }
+td, th { border: 1px solid #e0e0e0; padding: 4px 10px; text-align: left; }
+pre { background: #f7f7f7; padding: 12px; overflow-x: auto; }
+.charts svg { margin: 0 8px 8px 0; }
+.samples { display: flex; flex-wrap: wrap; }
+.sample { margin: 0 12px 12px 0; font-size: 12px; text-align: center; }
+.sample img { width: 84px; image-rendering: pixelated; border: 1px solid #e0e0e0; display: block; margin-bottom: 4px; }
+.muted { color: #7f7f7f; }
+</style>
+</head>
+<body>
+<h1>{{.Title}}</h1>
+<div class="muted">Generated {{.Generated}}</div>
+{{if .FinalMetrics}}
+<h2>Final metrics</h2>
+<table>{{range .FinalMetrics}}
+<tr><th>{{.Name}}</th><td>{{.Value}}</td></tr>{{end}}
+</table>
+{{end}}
+{{if .Hyperparameters}}
+<h2>Hyperparameters</h2>
+<table>{{range .Hyperparameters}}
+<tr><th>{{.Name}}</th><td>{{.Value}}</td></tr>{{end}}
+</table>
+{{end}}
+{{if .Summary}}
+<h2>Model summary</h2>
+<pre>{{.Summary}}</pre>
+{{end}}
+{{if .Charts}}
+<h2>Training curves</h2>
+<div class="charts">{{range .Charts}}
+{{.SVG}}{{end}}
+</div>
+{{end}}
+{{if .ConfusionMatrix}}
+<h2>Confusion matrix</h2>
+<p>Accuracy: {{.Accuracy}}</p>
+{{.ConfusionMatrix}}
+{{end}}
+{{if .Misclassified}}
+<h2>Misclassified samples</h2>
+<div class="samples">{{range .Misclassified}}
+<div class="sample"><img src="{{.Image}}" alt="sample {{.Index}}">#{{.Index}}<br>actual: {{.Actual}}<br>predicted: {{.Predicted}} ({{.Confidence}})</div>{{end}}
+</div>
+{{end}}
+{{if .Graphviz}}
+<h2>Structure</h2>
+<details>
+<summary>Graphviz (render with <code>dot -Tsvg</code>)</summary>
+<pre>{{.Graphviz}}</pre>
+</details>
+{{end}}
+</body>
+</html>
+`))
@@ -0,0 +1,129 @@
+package report
+
+import (
+	"bytes"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/LdDl/cnns"
+	"github.com/LdDl/cnns/metrics"
+	"github.com/LdDl/cnns/tensor"
+)
+
+func TestReport(t *testing.T) {
+	conv := cnns.NewConvLayer(1, 3, 2, tensor.TDsize{X: 6, Y: 6, Z: 1})
+	relu := cnns.NewReLULayer(conv.GetOutputSize())
+	fc := cnns.NewFullyConnectedLayer(relu.GetOutputSize(), 3)
+	net := &cnns.WholeNet{Layers: []cnns.Layer{conv, relu, fc}}
+
+	inputs := make([]*tensor.Tensor, 6)
+	targets := make([]*tensor.Tensor, 6)
+	for i := range inputs {
+		inputs[i] = tensor.NewTensor(6, 6, 1)
+		for j := range inputs[i].Data {
+			inputs[i].Data[j] = float64((i*j)%7) / 7
+		}
+		targets[i] = tensor.NewTensor(3, 1, 1)
+		targets[i].Data[i%3] = 1
+	}
+	dataset, err := cnns.NewInMemoryDataset(inputs, targets)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	history := cnns.NewHistory()
+	params := &cnns.LearningParams{LearningRate: 0.05, Momentum: 0.5}
+	_, _, err = net.Train(inputs, targets, cnns.TrainConfig{
+		Epochs:            3,
+		Optimizer:         params,
+		ValidationData:    dataset,
+		ValidationMetrics: []metrics.Metric{metrics.Accuracy{}},
+		Callbacks:         []cnns.Callback{history},
+	})
+	if err != nil {
+		t.Error(err)
+		return
+	}
+
+	report := NewReport(net, history)
+	report.Title = "Run <1>"
+	// Parameters of optimizer are taken from history: global ones are restored after training
+	if history.Params == nil || *history.Params != *params {
+		t.Errorf("Parameters of optimizer are not recorded by history: %v", history.Params)
+	}
+	report.Hyperparameters = map[string]string{"Epochs": "3"}
+	report.LogScale = []string{"loss"}
+	err = report.Evaluate(dataset, []string{"a", "b", "c"}, 2)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	if report.ConfusionMatrix.Total() != 6 {
+		t.Errorf("Number of samples in confusion matrix is wrong. Expected value: %d. Got: %d", 6, report.ConfusionMatrix.Total())
+	}
+	wrong := 0
+	for i := range report.ConfusionMatrix.Counts {
+		for j := range report.ConfusionMatrix.Counts[i] {
+			if i != j {
+				wrong += report.ConfusionMatrix.Counts[i][j]
+			}
+		}
+	}
+	if expected := minInt(wrong, 2); len(report.Misclassified) != expected {
+		t.Errorf("Number of misclassified samples is wrong. Expected value: %d. Got: %d", expected, len(report.Misclassified))
+	}
+	other := NewReport(net, history)
+	if err = other.Evaluate(dataset, nil, 0); err != nil || len(other.Misclassified) != 0 || other.ConfusionMatrix.Total() != 6 {
+		t.Errorf("No samples should be kept for zero limit: %d samples, error %v", len(other.Misclassified), err)
+	}
+
+	var buf bytes.Buffer
+	err = report.Write(&buf)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	html := buf.String()
+	for _, expected := range []string{
+		"<title>Run &lt;1&gt;</title>",
+		"<th>LearningRate</th><td>0.05</td>",
+		"<th>Epochs</th><td>3</td>",
+		"<th>val_accuracy</th>",
+		"Model summary",
+		"Total params",
+		"Confusion matrix (accuracy",
+	} {
+		if !strings.Contains(html, expected) {
+			t.Errorf("Report should contain '%s'", expected)
+		}
+	}
+	// Charts of "loss" (along with "val_loss"), "lr", "val_accuracy" and confusion matrix
+	if n := strings.Count(html, "<svg"); n != 4 {
+		t.Errorf("Number of SVG images is wrong. Expected value: %d. Got: %d", 4, n)
+	}
+	if n := strings.Count(html, `src="data:image/png;base64,`); n != len(report.Misclassified) {
+		t.Errorf("Number of images of samples is wrong. Expected value: %d. Got: %d", len(report.Misclassified), n)
+	}
+	if strings.Contains(html, "ZgotmplZ") || strings.Contains(html, "<?xml") {
+		t.Errorf("Report contains unsafe or misplaced content")
+	}
+
+	// No parameters of optimizer without history
+	buf.Reset()
+	if err = (&Report{Net: net}).Write(&buf); err != nil || strings.Contains(buf.String(), "LearningRate") {
+		t.Errorf("Parameters of optimizer should be omitted: %v", err)
+	}
+
+	err = report.Save(filepath.Join(t.TempDir(), "report.html"))
+	if err != nil {
+		t.Error(err)
+	}
+}
+
+func minInt(a, b int) int {
+	if a < b {
+		return a
+	}
+	return b
+}
@@ -0,0 +1,187 @@
+package cnns
+
+import (
+	"fmt"
+	"math"
+	"strings"
+)
+
+// Scheduler - learning rate schedule. It is consulted by training loop before every mini-batch. See TrainConfigOf
+type Scheduler interface {
+	// LearningRate - returns learning rate for given epoch (starting from 0) and step (number of weights' updates done so far). base - learning rate of optimizer
+	LearningRate(base float64, epoch, step int) float64
+}
+
+// MetricObserver - scheduler which depends on metrics of epochs (see ReduceOnPlateau). ObserveMetrics is called after every epoch
+type MetricObserver interface {
+	ObserveMetrics(epoch int, metrics map[string]float64) error
+}
+
+// resettable - scheduler which has state. State is reset before training (but not before resuming)
+type resettable interface {
+	reset()
+}
+
+// StepDecay - learning rate is multiplied by Gamma every StepSize epochs: base * Gamma^(epoch / StepSize)
+type StepDecay struct {
+	StepSize int     `json:"StepSize"`
+	Gamma    float64 `json:"Gamma"`
+}
+
+// LearningRate - see Scheduler
+func (sd *StepDecay) LearningRate(base float64, epoch, step int) float64 {
+	if sd.StepSize < 1 {
+		return base
+	}
+	return base * math.Pow(sd.Gamma, float64(epoch/sd.StepSize))
+}
+
+// ExponentialDecay - learning rate is multiplied by Gamma every epoch: base * Gamma^epoch
+type ExponentialDecay struct {
+	Gamma float64 `json:"Gamma"`
+}
+
+// LearningRate - see Scheduler
+func (ed *ExponentialDecay) LearningRate(base float64, epoch, step int) float64 {
+	return base * math.Pow(ed.Gamma, float64(epoch))
+}
+
+// CosineAnnealing - cosine annealing with warm restarts (SGDR). See ref. https://arxiv.org/abs/1608.03983
+/*
+	Period - number of epochs in first cycle;
+	PeriodMult - every next cycle is PeriodMult times longer (1 if not set);
+	MinLR - learning rate at the end of cycle.
+
+	lr = MinLR + 0.5 * (base - MinLR) * (1 + cos(π * epochInCycle / cycleLength))
+*/
+type CosineAnnealing struct {
+	Period     int     `json:"Period"`
+	PeriodMult int     `json:"PeriodMult"`
+	MinLR      float64 `json:"MinLR"`
+}
+
+// LearningRate - see Scheduler
+func (ca *CosineAnnealing) LearningRate(base float64, epoch, step int) float64 {
+	if ca.Period < 1 {
+		return base
+	}
+	mult := ca.PeriodMult
+	if mult < 1 {
+		mult = 1
+	}
+	cycle := ca.Period
+	for epoch >= cycle {
+		epoch -= cycle
+		cycle *= mult
+	}
+	return ca.MinLR + 0.5*(base-ca.MinLR)*(1+math.Cos(math.Pi*float64(epoch)/float64(cycle)))
+}
+
+// Warmup - learning rate grows linearly from StartFactor*base to base during first Steps steps, then After is used (constant base if not set)
+type Warmup struct {
+	Steps       int       `json:"Steps"`
+	StartFactor float64   `json:"StartFactor"`
+	After       Scheduler `json:"After"`
+}
+
+// LearningRate - see Scheduler
+func (w *Warmup) LearningRate(base float64, epoch, step int) float64 {
+	if step < w.Steps {
+		factor := w.StartFactor + (1-w.StartFactor)*float64(step)/float64(w.Steps)
+		return base * factor
+	}
+	if w.After == nil {
+		return base
+	}
+	return w.After.LearningRate(base, epoch, step)
+}
+
+// ObserveMetrics - passes metrics to After if it is MetricObserver
+func (w *Warmup) ObserveMetrics(epoch int, metrics map[string]float64) error {
+	if observer, ok := w.After.(MetricObserver); ok {
+		return observer.ObserveMetrics(epoch, metrics)
+	}
+	return nil
+}
+
+func (w *Warmup) reset() {
+	if r, ok := w.After.(resettable); ok {
+		r.reset()
+	}
+}
+
+// ReduceOnPlateau - learning rate is multiplied by Factor when monitored metric has stopped improving
+/*
+	Monitor - name of metric in epoch metrics ("val_loss" if not set);
+	Factor - multiplier of learning rate (0.1 if not set);
+	Patience - number of epochs without improvement after which learning rate is reduced;
+	MinDelta - minimum change of metric which is considered as improvement;
+	Mode - see MonitorMode;
+	Cooldown - number of epochs to wait after reduction before resuming normal operation;
+	MinLR - lower bound of learning rate.
+
+	Reductions, Best, BestEpoch, Wait, CooldownLeft - state of scheduler, updated during training.
+*/
+type ReduceOnPlateau struct {
+	Monitor  string      `json:"Monitor"`
+	Factor   float64     `json:"Factor"`
+	Patience int         `json:"Patience"`
+	MinDelta float64     `json:"MinDelta"`
+	Mode     MonitorMode `json:"Mode"`
+	Cooldown int         `json:"Cooldown"`
+	MinLR    float64     `json:"MinLR"`
+
+	Reductions   int     `json:"Reductions"`
+	Best         float64 `json:"Best"`
+	BestEpoch    int     `json:"BestEpoch"`
+	Wait         int     `json:"Wait"`
+	CooldownLeft int     `json:"CooldownLeft"`
+}
+
+func (rp *ReduceOnPlateau) reset() {
+	rp.Reductions = 0
+	rp.Best = 0
+	rp.BestEpoch = -1
+	rp.Wait = 0
+	rp.CooldownLeft = 0
+}
+
+// LearningRate - see Scheduler
+func (rp *ReduceOnPlateau) LearningRate(base float64, epoch, step int) float64 {
+	factor := rp.Factor
+	if factor <= 0 {
+		factor = 0.1
+	}
+	return math.Max(base*math.Pow(factor, float64(rp.Reductions)), rp.MinLR)
+}
+
+// ObserveMetrics - see MetricObserver
+func (rp *ReduceOnPlateau) ObserveMetrics(epoch int, metrics map[string]float64) error {
+	name := rp.Monitor
+	if name == "" {
+		name = "val_loss"
+	}
+	value, ok := metrics[name]
+	if !ok {
+		return fmt.Errorf("Reduce on plateau: metric '%s' is not available. Available metrics: %s", name, strings.Join(sortedKeys(metrics), ", "))
+	}
+	if rp.CooldownLeft > 0 {
+		rp.CooldownLeft--
+	}
+	if rp.BestEpoch < 0 || rp.Mode.resolve(name).isBetter(value, rp.Best, rp.MinDelta) {
+		rp.Best = value
+		rp.BestEpoch = epoch
+		rp.Wait = 0
+		return nil
+	}
+	if rp.CooldownLeft > 0 {
+		return nil
+	}
+	rp.Wait++
+	if rp.Wait >= rp.Patience {
+		rp.Reductions++
+		rp.Wait = 0
+		rp.CooldownLeft = rp.Cooldown
+	}
+	return nil
+}
@@ -0,0 +1,51 @@
+package cnns
+
+import (
+	"math"
+	"testing"
+)
+
+func TestSchedulers(t *testing.T) {
+	base := 0.1
+	cases := []struct {
+		name      string
+		scheduler Scheduler
+		epoch     int
+		step      int
+		expected  float64
+	}{
+		{"step decay", &StepDecay{StepSize: 3, Gamma: 0.5}, 2, 0, 0.1},
+		{"step decay", &StepDecay{StepSize: 3, Gamma: 0.5}, 7, 0, 0.025},
+		{"exponential", &ExponentialDecay{Gamma: 0.9}, 2, 0, 0.081},
+		{"cosine start", &CosineAnnealing{Period: 4, PeriodMult: 2, MinLR: 0.0}, 0, 0, 0.1},
+		{"cosine middle", &CosineAnnealing{Period: 4, PeriodMult: 2, MinLR: 0.0}, 2, 0, 0.05},
+		{"cosine restart", &CosineAnnealing{Period: 4, PeriodMult: 2, MinLR: 0.0}, 4, 0, 0.1},
+		{"cosine second cycle", &CosineAnnealing{Period: 4, PeriodMult: 2, MinLR: 0.0}, 8, 0, 0.05},
+		{"warmup", &Warmup{Steps: 10}, 0, 5, 0.05},
+		{"warmup done", &Warmup{Steps: 10, After: &ExponentialDecay{Gamma: 0.5}}, 1, 10, 0.05},
+	}
+	for _, c := range cases {
+		lr := c.scheduler.LearningRate(base, c.epoch, c.step)
+		if math.Abs(lr-c.expected) > 1e-12 {
+			t.Errorf("%s (epoch %d, step %d): Expected value: %f. Got: %f", c.name, c.epoch, c.step, c.expected, lr)
+		}
+	}
+}
+
+func TestReduceOnPlateau(t *testing.T) {
+	rp := &ReduceOnPlateau{Factor: 0.5, Patience: 2, Cooldown: 1, MinLR: 0.02}
+	rp.reset()
+	losses := []float64{1.0, 0.9, 0.95, 0.91, 0.92, 0.93, 0.94, 0.95, 0.96}
+	correct := []float64{0.1, 0.1, 0.1, 0.05, 0.05, 0.025, 0.025, 0.02, 0.02}
+	for e, loss := range losses {
+		err := rp.ObserveMetrics(e, map[string]float64{"val_loss": loss})
+		if err != nil {
+			t.Error(err)
+			return
+		}
+		lr := rp.LearningRate(0.1, e+1, 0)
+		if math.Abs(lr-correct[e]) > 1e-12 {
+			t.Errorf("Epoch #%d: Expected value: %f. Got: %f", e, correct[e], lr)
+		}
+	}
+}
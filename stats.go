@@ -0,0 +1,336 @@
+package cnns
+
+import (
+	"fmt"
+	"io"
+	"log"
+	"math"
+
+	"github.com/LdDl/cnns/tensor"
+)
+
+// TensorStats - statistics of values
+/*
+	Count - number of values;
+	Mean, Std, Min, Max, L2Norm - over finite values only;
+	Zeros, NaNs, Infs - fractions of zero, NaN and infinite values.
+*/
+type TensorStats struct {
+	Count  int     `json:"Count"`
+	Mean   float64 `json:"Mean"`
+	Std    float64 `json:"Std"`
+	Min    float64 `json:"Min"`
+	Max    float64 `json:"Max"`
+	L2Norm float64 `json:"L2Norm"`
+	Zeros  float64 `json:"Zeros"`
+	NaNs   float64 `json:"NaNs"`
+	Infs   float64 `json:"Infs"`
+}
+
+// NewTensorStats - evaluates statistics of values of tensors
+func NewTensorStats[T tensor.Float](tensors ...*tensor.TensorOf[T]) TensorStats {
+	ret := TensorStats{Min: math.Inf(1), Max: math.Inf(-1)}
+	finite := 0
+	sum, sumSquares := 0.0, 0.0
+	for _, t := range tensors {
+		for _, value := range t.Data {
+			v := float64(value)
+			ret.Count++
+			switch {
+			case math.IsNaN(v):
+				ret.NaNs++
+				continue
+			case math.IsInf(v, 0):
+				ret.Infs++
+				continue
+			case v == 0:
+				ret.Zeros++
+			}
+			finite++
+			sum += v
+			sumSquares += v * v
+			ret.Min = math.Min(ret.Min, v)
+			ret.Max = math.Max(ret.Max, v)
+		}
+	}
+	if finite == 0 {
+		ret.Min, ret.Max = 0, 0
+	} else {
+		ret.Mean = sum / float64(finite)
+		ret.Std = math.Sqrt(math.Max(sumSquares/float64(finite)-ret.Mean*ret.Mean, 0))
+		ret.L2Norm = math.Sqrt(sumSquares)
+	}
+	if ret.Count != 0 {
+		ret.Zeros /= float64(ret.Count)
+		ret.NaNs /= float64(ret.Count)
+		ret.Infs /= float64(ret.Count)
+	}
+	return ret
+}
+
+// LayerStats - statistics of single layer
+/*
+	Weights - statistics of weights (convolutional and fully connected layers only);
+	Gradients - statistics of gradients of loss with respect to weights for the last backpropagated sample (see WeightGradients);
+	UpdateRatio - ||Δw|| / ||w||: L2 norm of last update of weights (with momentum) relative to L2 norm of weights. Values about 1e-3 are healthy;
+	Outputs - statistics of outputs (for all probe inputs, see WholeNetOf.GetStats);
+	DeadUnits - fraction of outputs which are zero for every probe input (e.g. dead ReLUs). Evaluated for probe inputs only: single sample is not enough to tell dead unit from inactive one.
+*/
+type LayerStats struct {
+	Index       int          `json:"Index"`
+	Type        string       `json:"Type"`
+	Weights     *TensorStats `json:"Weights,omitempty"`
+	Gradients   *TensorStats `json:"Gradients,omitempty"`
+	UpdateRatio float64      `json:"UpdateRatio"`
+	Outputs     TensorStats  `json:"Outputs"`
+	DeadUnits   float64      `json:"DeadUnits"`
+}
+
+// NetStats - statistics of every layer of the net
+/*
+	Layers - statistics of layers;
+	Probes - number of probe inputs which outputs and dead units are evaluated for (0 - outputs for the last sample are used, dead units are not evaluated).
+*/
+type NetStats struct {
+	Layers []LayerStats `json:"Layers"`
+	Probes int          `json:"Probes"`
+}
+
+// weightUpdates - last updates of weights (momentum buffers) of layer
+func weightUpdates[T tensor.Float](layer LayerOf[T]) []*tensor.TensorOf[T] {
+	switch typed := layer.(type) {
+	case *ConvLayerOf[T]:
+		return typed.PreviousKernelsDeltas
+	case *FullyConnectedLayerOf[T]:
+		return []*tensor.TensorOf[T]{typed.PreviousIterationWeights}
+	}
+	return nil
+}
+
+// GetStats - evaluates statistics of weights, gradients and outputs of every layer (weights are not changed)
+/*
+	probe - inputs fed to the net for statistics of outputs and dead units (current outputs of layers, i.e. for the last sample, are used if not set; dead units are not evaluated then).
+	Gradients are the ones of the last backpropagated sample, so call it after training step (or see StatsMonitor).
+*/
+func (wh *WholeNetOf[T]) GetStats(probe ...*tensor.TensorOf[T]) *NetStats {
+	ret := &NetStats{Layers: make([]LayerStats, len(wh.Layers)), Probes: len(probe)}
+	// Gradients are kept by layers during backpropagation, so neither probe inputs nor validation affect them
+	for i, layer := range wh.Layers {
+		ret.Layers[i].Index = i
+		ret.Layers[i].Type = layer.GetType()
+		gradients := WeightGradients(layer)
+		if gradients == nil {
+			continue
+		}
+		weights, updates := layer.GetWeights(), weightUpdates(layer)
+		weightStats, gradientStats, updateStats := NewTensorStats(weights...), NewTensorStats(gradients...), NewTensorStats(updates...)
+		ret.Layers[i].Weights, ret.Layers[i].Gradients = &weightStats, &gradientStats
+		if weightStats.L2Norm > 0 {
+			ret.Layers[i].UpdateRatio = updateStats.L2Norm / weightStats.L2Norm
+		}
+	}
+
+	outputs := make([][]*tensor.TensorOf[T], len(wh.Layers))
+	alive := make([][]bool, len(wh.Layers))
+	collect := func() {
+		for i, layer := range wh.Layers {
+			out := layer.GetOutput()
+			copied := tensor.NewTensorOf[T](out.Size.X, out.Size.Y, out.Size.Z)
+			copy(copied.Data, out.Data)
+			outputs[i] = append(outputs[i], copied)
+			if alive[i] == nil {
+				alive[i] = make([]bool, len(out.Data))
+			}
+			for j, v := range out.Data {
+				alive[i][j] = alive[i][j] || v != 0
+			}
+		}
+	}
+	if len(probe) == 0 {
+		collect()
+	}
+	for _, input := range probe {
+		wh.FeedForward(input)
+		collect()
+	}
+	for i := range wh.Layers {
+		ret.Layers[i].Outputs = NewTensorStats(outputs[i]...)
+		dead := 0
+		for _, a := range alive[i] {
+			if !a {
+				dead++
+			}
+		}
+		if len(probe) != 0 && len(alive[i]) != 0 {
+			ret.Layers[i].DeadUnits = float64(dead) / float64(len(alive[i]))
+		}
+	}
+	return ret
+}
+
+// Stats - prints table of statistics (see NetStats.String) and returns it as struct. Compact alternative to PrintWeights and PrintGradients of layers
+func (wh *WholeNetOf[T]) Stats(probe ...*tensor.TensorOf[T]) *NetStats {
+	stats := wh.GetStats(probe...)
+	fmt.Print(stats)
+	return stats
+}
+
+// StatsThresholds - thresholds of warnings about training problems. Zero values disable corresponding checks (NaN and infinite values are always reported)
+/*
+	VanishingGradient - L2 norm of gradients of layer below it;
+	ExplodingGradient - L2 norm of gradients of layer above it;
+	MinUpdateRatio, MaxUpdateRatio - update-to-weight ratio (see LayerStats) out of range;
+	DeadUnits - fraction of dead units of layer above it;
+	MinProbes - minimum number of probe inputs for check of dead units (see NetStats.Probes): unit which is zero for few samples is not necessarily dead. Check of dead units is skipped if there are fewer probe inputs or no probe inputs at all.
+*/
+type StatsThresholds struct {
+	VanishingGradient float64
+	ExplodingGradient float64
+	MinUpdateRatio    float64
+	MaxUpdateRatio    float64
+	DeadUnits         float64
+	MinProbes         int
+}
+
+// DefaultStatsThresholds - thresholds used by StatsMonitor if not set
+var DefaultStatsThresholds = StatsThresholds{
+	VanishingGradient: 1e-7,
+	ExplodingGradient: 1e3,
+	MinUpdateRatio:    1e-6,
+	MaxUpdateRatio:    1e-1,
+	DeadUnits:         0.5,
+	MinProbes:         8,
+}
+
+// Check - returns warnings about layers whose statistics cross thresholds
+func (ns *NetStats) Check(thresholds StatsThresholds) []string {
+	var ret []string
+	for _, ls := range ns.Layers {
+		name := fmt.Sprintf("Layer #%d (%s)", ls.Index, ls.Type)
+		if ls.Weights != nil && (ls.Weights.NaNs > 0 || ls.Weights.Infs > 0) {
+			ret = append(ret, fmt.Sprintf("%s: weights contain NaN or Inf values (%.2f%%)", name, 100*(ls.Weights.NaNs+ls.Weights.Infs)))
+		}
+		if ls.Gradients != nil {
+			g := ls.Gradients
+			switch {
+			case g.NaNs > 0 || g.Infs > 0:
+				ret = append(ret, fmt.Sprintf("%s: gradients contain NaN or Inf values (%.2f%%)", name, 100*(g.NaNs+g.Infs)))
+			case thresholds.ExplodingGradient > 0 && g.L2Norm > thresholds.ExplodingGradient:
+				ret = append(ret, fmt.Sprintf("%s: exploding gradients, L2 norm %.3g > %.3g", name, g.L2Norm, thresholds.ExplodingGradient))
+			case thresholds.VanishingGradient > 0 && g.L2Norm < thresholds.VanishingGradient:
+				ret = append(ret, fmt.Sprintf("%s: vanishing gradients, L2 norm %.3g < %.3g", name, g.L2Norm, thresholds.VanishingGradient))
+			}
+			// Ratio is zero before first update
+			switch {
+			case ls.UpdateRatio == 0:
+			case thresholds.MaxUpdateRatio > 0 && ls.UpdateRatio > thresholds.MaxUpdateRatio:
+				ret = append(ret, fmt.Sprintf("%s: update-to-weight ratio %.3g > %.3g (learning rate may be too high)", name, ls.UpdateRatio, thresholds.MaxUpdateRatio))
+			case thresholds.MinUpdateRatio > 0 && ls.UpdateRatio < thresholds.MinUpdateRatio:
+				ret = append(ret, fmt.Sprintf("%s: update-to-weight ratio %.3g < %.3g (learning rate may be too low)", name, ls.UpdateRatio, thresholds.MinUpdateRatio))
+			}
+		}
+		if ls.Outputs.NaNs > 0 || ls.Outputs.Infs > 0 {
+			ret = append(ret, fmt.Sprintf("%s: outputs contain NaN or Inf values (%.2f%%)", name, 100*(ls.Outputs.NaNs+ls.Outputs.Infs)))
+		}
+		if thresholds.DeadUnits > 0 && ns.Probes > 0 && ns.Probes >= thresholds.MinProbes && ls.DeadUnits > thresholds.DeadUnits {
+			ret = append(ret, fmt.Sprintf("%s: %.1f%% of units are dead (zero output for every probe input)", name, 100*ls.DeadUnits))
+		}
+	}
+	return ret
+}
+
+// String - pretty print of statistics: table of layers
+func (ns *NetStats) String() string {
+	cells := [][]string{{"#", "type", "w mean", "w std", "w min", "w max", "w L2", "grad L2", "grad max|g|", "update/w", "out zeros", "dead", "NaN/Inf"}}
+	for _, ls := range ns.Layers {
+		row := []string{fmt.Sprint(ls.Index), ls.Type}
+		nonFinite := ls.Outputs.NaNs + ls.Outputs.Infs
+		if ls.Weights != nil {
+			w, g := ls.Weights, ls.Gradients
+			row = append(row,
+				fmt.Sprintf("%.3g", w.Mean), fmt.Sprintf("%.3g", w.Std), fmt.Sprintf("%.3g", w.Min), fmt.Sprintf("%.3g", w.Max), fmt.Sprintf("%.3g", w.L2Norm),
+				fmt.Sprintf("%.3g", g.L2Norm), fmt.Sprintf("%.3g", math.Max(math.Abs(g.Min), math.Abs(g.Max))), fmt.Sprintf("%.3g", ls.UpdateRatio),
+			)
+			nonFinite = math.Max(nonFinite, math.Max(w.NaNs+w.Infs, g.NaNs+g.Infs))
+		} else {
+			row = append(row, "-", "-", "-", "-", "-", "-", "-", "-")
+		}
+		dead := "-"
+		if ns.Probes > 0 {
+			dead = fmt.Sprintf("%.1f%%", 100*ls.DeadUnits)
+		}
+		row = append(row, fmt.Sprintf("%.1f%%", 100*ls.Outputs.Zeros), dead, fmt.Sprintf("%.1f%%", 100*nonFinite))
+		cells = append(cells, row)
+	}
+	return formatTable(cells, 2, 0)
+}
+
+// StatsMonitor - callback which collects statistics of layers (see WholeNetOf.GetStats) and logs warnings (see NetStats.Check)
+/*
+	Net - trained net;
+	Probe - inputs for statistics of outputs and dead units (optional, outputs for the last sample are used if not set). Dead units are checked for at least Thresholds.MinProbes inputs only (8 by default);
+	EveryBatches - check statistics after every N-th mini-batch also (0 - after every epoch only);
+	Thresholds - thresholds of warnings (DefaultStatsThresholds if nil);
+	Logger - destination of warnings (standard logger is used if nil);
+	Verbose - log table of statistics after every epoch also;
+	Last - statistics of last check;
+	Warnings - all warnings reported so far, prefixed by epoch and batch.
+*/
+type StatsMonitor[T tensor.Float] struct {
+	BaseCallback
+	Net          *WholeNetOf[T]
+	Probe        []*tensor.TensorOf[T]
+	EveryBatches int
+	Thresholds   *StatsThresholds
+	Logger       *log.Logger
+	Verbose      bool
+	Last         *NetStats
+	Warnings     []string
+}
+
+// NewStatsMonitor - constructor for StatsMonitor writing warnings to w. Pass enough probe inputs (see StatsThresholds.MinProbes) to get warnings about dead units
+func NewStatsMonitor[T tensor.Float](net *WholeNetOf[T], w io.Writer, probe ...*tensor.TensorOf[T]) *StatsMonitor[T] {
+	return &StatsMonitor[T]{
+		Net:    net,
+		Probe:  probe,
+		Logger: log.New(w, "", log.LstdFlags),
+	}
+}
+
+func (sm *StatsMonitor[T]) printf(format string, v ...interface{}) {
+	if sm.Logger == nil {
+		log.Printf(format, v...)
+		return
+	}
+	sm.Logger.Printf(format, v...)
+}
+
+// check - collects statistics and reports warnings
+func (sm *StatsMonitor[T]) check(prefix string) {
+	thresholds := DefaultStatsThresholds
+	if sm.Thresholds != nil {
+		thresholds = *sm.Thresholds
+	}
+	sm.Last = sm.Net.GetStats(sm.Probe...)
+	for _, warning := range sm.Last.Check(thresholds) {
+		warning = prefix + ": " + warning
+		sm.Warnings = append(sm.Warnings, warning)
+		sm.printf("%s", warning)
+	}
+}
+
+// OnBatchEnd - check statistics if EveryBatches is set
+func (sm *StatsMonitor[T]) OnBatchEnd(event *TrainEvent) {
+	if sm.EveryBatches <= 0 || (event.Batch+1)%sm.EveryBatches != 0 {
+		return
+	}
+	sm.check(fmt.Sprintf("Epoch #%v batch %v/%v", event.Epoch, event.Batch+1, event.Batches))
+}
+
+// OnEpochEnd - check statistics
+func (sm *StatsMonitor[T]) OnEpochEnd(event *TrainEvent) {
+	sm.check(fmt.Sprintf("Epoch #%v", event.Epoch))
+	if sm.Verbose {
+		sm.printf("Epoch #%v statistics:\n%s", event.Epoch, sm.Last)
+	}
+}
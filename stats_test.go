@@ -0,0 +1,160 @@
+package cnns
+
+import (
+	"bytes"
+	"io"
+	"math"
+	"strings"
+	"testing"
+
+	"github.com/LdDl/cnns/tensor"
+)
+
+func TestTensorStats(t *testing.T) {
+	values := tensor.NewTensor(6, 1, 1)
+	values.SetData(6, 1, 1, []float64{3, -4, 0, 0, math.NaN(), math.Inf(1)})
+	stats := NewTensorStats(values)
+	if stats.Count != 6 || stats.Min != -4 || stats.Max != 3 || stats.L2Norm != 5 {
+		t.Errorf("Statistics are wrong: %+v", stats)
+	}
+	if math.Abs(stats.Mean+0.25) > 1e-12 || math.Abs(stats.Zeros-2.0/6) > 1e-12 || math.Abs(stats.NaNs-1.0/6) > 1e-12 || math.Abs(stats.Infs-1.0/6) > 1e-12 {
+		t.Errorf("Mean or fractions are wrong: %+v", stats)
+	}
+	// Variance of 3, -4, 0, 0 with mean -0.25
+	if std := math.Sqrt(25.0/4 - 0.0625); math.Abs(stats.Std-std) > 1e-12 {
+		t.Errorf("Std is wrong. Expected value: %f. Got: %f", std, stats.Std)
+	}
+}
+
+func TestNetStats(t *testing.T) {
+	conv := NewConvLayer(1, 3, 2, tensor.TDsize{X: 5, Y: 5, Z: 1})
+	relu := NewReLULayer(conv.GetOutputSize())
+	fc := NewFullyConnectedLayer(relu.GetOutputSize(), 2)
+	net := WholeNet{Layers: []Layer{conv, relu, fc}}
+	// Negative kernels make every output of ReLU zero for positive inputs
+	for _, kernel := range conv.GetWeights() {
+		for i := range kernel.Data {
+			kernel.Data[i] = -0.1
+		}
+	}
+	probe := []*tensor.Tensor{tensor.NewTensor(5, 5, 1), tensor.NewTensor(5, 5, 1)}
+	for i := range probe[0].Data {
+		probe[0].Data[i] = 1
+		probe[1].Data[i] = float64(i) / 25
+	}
+	net.FeedForward(probe[0])
+	err := net.Backpropagate(tensor.NewTensor(2, 1, 1))
+	if err != nil {
+		t.Error(err)
+		return
+	}
+
+	stats := net.GetStats(probe...)
+	if stats.Layers[1].Weights != nil || stats.Layers[0].Weights == nil || stats.Layers[2].Gradients == nil {
+		t.Errorf("Statistics of weights should be evaluated for layers with weights only")
+	}
+	if stats.Layers[1].DeadUnits != 1 || stats.Layers[1].Outputs.Count != 2*18 {
+		t.Errorf("Statistics of ReLU outputs are wrong: dead %f, count %d", stats.Layers[1].DeadUnits, stats.Layers[1].Outputs.Count)
+	}
+	// No gradient passes through dead ReLUs. Two probe inputs are enough for this test only
+	thresholds := DefaultStatsThresholds
+	thresholds.MinProbes = len(probe)
+	warnings := stats.Check(thresholds)
+	for _, expected := range []string{"Layer #0 (conv): vanishing gradients", "Layer #1 (relu): 100.0% of units are dead"} {
+		found := false
+		for _, w := range warnings {
+			found = found || strings.HasPrefix(w, expected)
+		}
+		if !found {
+			t.Errorf("Warning '%s' should be reported: %v", expected, warnings)
+		}
+	}
+	// Too few probe inputs to judge dead units
+	for _, w := range stats.Check(DefaultStatsThresholds) {
+		if strings.Contains(w, "units are dead") {
+			t.Errorf("Dead units should not be checked for %d probe inputs: %s", len(probe), w)
+		}
+	}
+	if stats = net.GetStats(); stats.Probes != 0 || stats.Layers[1].DeadUnits != 0 {
+		t.Errorf("Dead units should not be evaluated without probe inputs: %+v", stats.Layers[1])
+	}
+	stats = net.GetStats(probe...)
+	if table := stats.String(); !strings.Contains(table, "grad L2") || strings.Count(table, "\n") != 5 {
+		t.Errorf("Table is wrong:\n%s", table)
+	}
+
+	fc.GetWeights()[0].Data[0] = math.NaN()
+	warnings = net.GetStats().Check(StatsThresholds{})
+	if len(warnings) == 0 || !strings.Contains(warnings[0], "Layer #2 (fc): weights contain NaN or Inf values") {
+		t.Errorf("NaN weights should be reported: %v", warnings)
+	}
+}
+
+func TestStatsMonitor(t *testing.T) {
+	inputs, desired := xorData()
+	net := newXORNet(5)
+	var buf bytes.Buffer
+	monitor := NewStatsMonitor(net, &buf, inputs...)
+	monitor.Thresholds = &StatsThresholds{MaxUpdateRatio: 1e-12}
+	_, _, err := net.Train(inputs, desired, TrainConfig{Epochs: 2, BatchSize: 2, Callbacks: []Callback{monitor}})
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	if monitor.Last == nil || len(monitor.Last.Layers) != 2 || monitor.Last.Layers[0].UpdateRatio <= 0 {
+		t.Errorf("Statistics are not collected")
+		return
+	}
+	// Both layers exceed ratio after every epoch
+	if len(monitor.Warnings) != 4 || !strings.HasPrefix(monitor.Warnings[0], "Epoch #0: Layer #0 (fc): update-to-weight ratio") {
+		t.Errorf("Warnings are wrong: %v", monitor.Warnings)
+	}
+	if strings.Count(buf.String(), "update-to-weight ratio") != 4 {
+		t.Errorf("Warnings are not logged: %s", buf.String())
+	}
+}
+
+// gradientsRecorder - remembers statistics of gradients of every layer after last batch of epoch
+type gradientsRecorder struct {
+	BaseCallback
+	net   *WholeNet
+	stats []TensorStats
+}
+
+func (gr *gradientsRecorder) OnBatchEnd(event *TrainEvent) {
+	if event.Batch != event.Batches-1 {
+		return
+	}
+	gr.stats = gr.stats[:0]
+	for _, layer := range gr.net.Layers {
+		gr.stats = append(gr.stats, NewTensorStats(WeightGradients(layer)...))
+	}
+}
+
+func TestStatsMonitorValidation(t *testing.T) {
+	inputs, desired := xorData()
+	validation := []*tensor.Tensor{tensor.NewTensor(2, 1, 1), tensor.NewTensor(2, 1, 1)}
+	validation[0].SetData(2, 1, 1, []float64{0.3, -0.7})
+	validation[1].SetData(2, 1, 1, []float64{-0.9, 0.2})
+	net := newXORNet(5)
+	recorder := &gradientsRecorder{net: net}
+	monitor := NewStatsMonitor(net, io.Discard, inputs[1])
+	_, _, err := net.Train(inputs, desired, TrainConfig{
+		Epochs:            2,
+		BatchSize:         2,
+		ValidationInputs:  validation,
+		ValidationTargets: desired[:2],
+		Callbacks:         []Callback{recorder, monitor},
+	})
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	// Validation pass and probe inputs should not change gradients of last batch
+	for i := range net.Layers {
+		expected, got := recorder.stats[i].L2Norm, monitor.Last.Layers[i].Gradients.L2Norm
+		if math.Abs(expected-got) > 1e-12 {
+			t.Errorf("L2 norm of gradients of layer #%d is wrong. Expected value: %f. Got: %f", i, expected, got)
+		}
+	}
+}
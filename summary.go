@@ -0,0 +1,192 @@
+package cnns
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/LdDl/cnns/tensor"
+)
+
+// LayerSummary - size of single layer
+/*
+	Index, Type - position and type of layer;
+	InputSize, OutputSize - dimensions;
+	KernelSize, Stride - for "conv" and "pool" layers;
+	Parameters - number of trainable parameters (weights);
+	ActivationBytes - memory of layer's output;
+	MACs - estimated number of multiply-accumulate operations for single sample.
+*/
+type LayerSummary struct {
+	Index           int           `json:"Index"`
+	Type            string        `json:"Type"`
+	InputSize       tensor.TDsize `json:"InputSize"`
+	OutputSize      tensor.TDsize `json:"OutputSize"`
+	KernelSize      int           `json:"KernelSize,omitempty"`
+	Stride          int           `json:"Stride,omitempty"`
+	Parameters      int           `json:"Parameters"`
+	ActivationBytes int           `json:"ActivationBytes"`
+	MACs            int64         `json:"MACs"`
+}
+
+// NetSummary - size of the net: layers and totals. See WholeNetOf.Summary
+/*
+	Precision - "float32" or "float64";
+	ParameterBytes - memory of weights;
+	ActivationBytes - memory of outputs of all layers.
+*/
+type NetSummary struct {
+	Precision       string         `json:"Precision"`
+	Layers          []LayerSummary `json:"Layers"`
+	Parameters      int            `json:"Parameters"`
+	ParameterBytes  int            `json:"ParameterBytes"`
+	ActivationBytes int            `json:"ActivationBytes"`
+	MACs            int64          `json:"MACs"`
+}
+
+// layerMACs - estimated number of multiply-accumulate operations of layer for single sample
+func layerMACs[T tensor.Float](layer LayerOf[T]) int64 {
+	switch typed := layer.(type) {
+	case *ConvLayerOf[T]:
+		out := typed.GetOutputSize()
+		in := typed.GetInputSize()
+		return int64(out.Total()) * int64(typed.KernelSize*typed.KernelSize*in.Z)
+	case *FullyConnectedLayerOf[T]:
+		return int64(len(typed.Weights.Data))
+	}
+	return 0
+}
+
+// GetSummary - returns size of the net: parameters, memory and operations of every layer
+func (wh *WholeNetOf[T]) GetSummary() *NetSummary {
+	elementBytes := 8
+	precision := tensor.Precision[T]()
+	if precision == tensor.PrecisionFloat32 {
+		elementBytes = 4
+	}
+	summary := &NetSummary{
+		Precision: precision,
+		Layers:    make([]LayerSummary, len(wh.Layers)),
+	}
+	for l, layer := range wh.Layers {
+		ls := LayerSummary{
+			Index:      l,
+			Type:       layer.GetType(),
+			InputSize:  *layer.GetInputSize(),
+			OutputSize: *layer.GetOutputSize(),
+			Parameters: layerParameters(layer),
+			MACs:       layerMACs(layer),
+		}
+		if ls.Type == "conv" || ls.Type == "pool" {
+			ls.KernelSize = layer.GetKernelSize()
+			ls.Stride = layer.GetStride()
+		}
+		ls.ActivationBytes = ls.OutputSize.Total() * elementBytes
+		summary.Layers[l] = ls
+		summary.Parameters += ls.Parameters
+		summary.ActivationBytes += ls.ActivationBytes
+		summary.MACs += ls.MACs
+	}
+	summary.ParameterBytes = summary.Parameters * elementBytes
+	return summary
+}
+
+// Summary - prints table of layers (see NetSummary.String) and returns it as struct
+func (wh *WholeNetOf[T]) Summary() *NetSummary {
+	summary := wh.GetSummary()
+	fmt.Println(summary)
+	return summary
+}
+
+// JSON - returns summary in JSON format
+func (ns *NetSummary) JSON() ([]byte, error) {
+	return json.MarshalIndent(ns, "", "    ")
+}
+
+// ExportToFile - saves summary to JSON file
+func (ns *NetSummary) ExportToFile(fname string) error {
+	fileContent, err := ns.JSON()
+	if err != nil {
+		return err
+	}
+	return ioutil.WriteFile(fname, fileContent, 0644)
+}
+
+// formatBytes - human-readable size
+func formatBytes(n int) string {
+	switch {
+	case n >= 1<<20:
+		return fmt.Sprintf("%.2f MiB", float64(n)/(1<<20))
+	case n >= 1<<10:
+		return fmt.Sprintf("%.2f KiB", float64(n)/(1<<10))
+	default:
+		return fmt.Sprintf("%d B", n)
+	}
+}
+
+// String - pretty print of summary: table of layers and totals
+func (ns *NetSummary) String() string {
+	cells := [][]string{{"#", "type", "input", "output", "kernel/stride", "params", "activations", "MACs"}}
+	for _, ls := range ns.Layers {
+		kernel := "-"
+		if ls.KernelSize != 0 {
+			kernel = fmt.Sprintf("%d/%d", ls.KernelSize, ls.Stride)
+		}
+		inSize, outSize := ls.InputSize, ls.OutputSize
+		cells = append(cells, []string{
+			fmt.Sprint(ls.Index),
+			ls.Type,
+			formatSize(&inSize),
+			formatSize(&outSize),
+			kernel,
+			fmt.Sprint(ls.Parameters),
+			formatBytes(ls.ActivationBytes),
+			fmt.Sprint(ls.MACs),
+		})
+	}
+	cells = append(cells, []string{"", "total", "", "", "", fmt.Sprint(ns.Parameters), formatBytes(ns.ActivationBytes), fmt.Sprint(ns.MACs)})
+
+	var sb strings.Builder
+	sb.WriteString(formatTable(cells, 2, 0, len(cells)-2))
+	sb.WriteString(fmt.Sprintf("Precision: %s\nParameters: %d (%s)\nActivations: %s\nMACs per sample: %d\n", ns.Precision, ns.Parameters, formatBytes(ns.ParameterBytes), formatBytes(ns.ActivationBytes), ns.MACs))
+	return sb.String()
+}
+
+// formatTable - aligns cells by columns. First leftColumns columns are aligned to the left, other ones (numbers and sizes) to the right. Separator line is written after every row listed in separators
+func formatTable(cells [][]string, leftColumns int, separators ...int) string {
+	widths := make([]int, len(cells[0]))
+	for _, row := range cells {
+		for i, cell := range row {
+			if n := utf8.RuneCountInString(cell); n > widths[i] {
+				widths[i] = n
+			}
+		}
+	}
+	total := len(widths)*2 - 2
+	for _, w := range widths {
+		total += w
+	}
+	var sb strings.Builder
+	for r, row := range cells {
+		for i, cell := range row {
+			if i > 0 {
+				sb.WriteString("  ")
+			}
+			if i < leftColumns {
+				sb.WriteString(fmt.Sprintf("%-*s", widths[i], cell))
+			} else {
+				sb.WriteString(fmt.Sprintf("%*s", widths[i], cell))
+			}
+		}
+		sb.WriteString("\n")
+		for _, sep := range separators {
+			if r == sep {
+				sb.WriteString(strings.Repeat("-", total) + "\n")
+				break
+			}
+		}
+	}
+	return sb.String()
+}
@@ -0,0 +1,54 @@
+package cnns
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/LdDl/cnns/tensor"
+)
+
+func TestSummary(t *testing.T) {
+	conv := NewConvLayerOf[float32](1, 5, 4, tensor.TDsize{X: 28, Y: 28, Z: 1})
+	relu := NewReLULayerOf[float32](conv.GetOutputSize())
+	pool := NewMaxPoolingLayerOf[float32](2, 2, relu.GetOutputSize())
+	fc := NewFullyConnectedLayerOf[float32](pool.GetOutputSize(), 10)
+	net := WholeNetOf[float32]{Layers: []LayerOf[float32]{conv, relu, pool, fc}}
+
+	summary := net.GetSummary()
+	if summary.Parameters != 100+576*10 {
+		t.Errorf("Number of parameters is wrong. Expected value: %d. Got: %d", 100+576*10, summary.Parameters)
+	}
+	if summary.ParameterBytes != 4*summary.Parameters {
+		t.Errorf("Memory of float32 parameters is wrong. Expected value: %d. Got: %d", 4*summary.Parameters, summary.ParameterBytes)
+	}
+	// 24*24*4 outputs of conv and ReLU, 12*12*4 of pooling, 10 of fully connected layer
+	if summary.ActivationBytes != 4*(2*2304+576+10) {
+		t.Errorf("Memory of activations is wrong. Expected value: %d. Got: %d", 4*(2*2304+576+10), summary.ActivationBytes)
+	}
+	if summary.Layers[0].MACs != 2304*25 || summary.Layers[3].MACs != 5760 || summary.MACs != 2304*25+5760 {
+		t.Errorf("MACs are wrong: %d, %d, %d", summary.Layers[0].MACs, summary.Layers[3].MACs, summary.MACs)
+	}
+	if summary.Layers[2].KernelSize != 2 || summary.Layers[2].Stride != 2 {
+		t.Errorf("Kernel and stride of pooling layer are wrong: %d/%d", summary.Layers[2].KernelSize, summary.Layers[2].Stride)
+	}
+
+	text := summary.String()
+	if !strings.Contains(text, "24×24×4") || !strings.Contains(text, "Parameters: 5860") {
+		t.Errorf("Table is wrong: %s", text)
+	}
+
+	data, err := summary.JSON()
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	var decoded NetSummary
+	if err = json.Unmarshal(data, &decoded); err != nil {
+		t.Error(err)
+		return
+	}
+	if decoded.MACs != summary.MACs || len(decoded.Layers) != 4 || decoded.Layers[3].Type != "fc" {
+		t.Errorf("Summary after JSON round trip is wrong: %+v", decoded)
+	}
+}
@@ -0,0 +1,57 @@
+package tensor
+
+// CSR - Compressed Sparse Row representation of 2D matrix (X - columns, Y - rows). See ref. https://en.wikipedia.org/wiki/Sparse_matrix#Compressed_sparse_row_(CSR,_CRS_or_Yale_format)
+/*
+	Rows, Cols - dimensions of matrix;
+	RowPtr - RowPtr[r]:RowPtr[r+1] is range of non-zero elements of r-th row in ColIdx and Values;
+	ColIdx - column index of every non-zero element;
+	Values - non-zero elements.
+*/
+type CSR[T Float] struct {
+	Rows   int
+	Cols   int
+	RowPtr []int
+	ColIdx []int
+	Values []T
+}
+
+// NewCSR - Constructor for CSR. Only first 2D component (z = 0) of tensor is used.
+func NewCSR[T Float](t *TensorOf[T]) *CSR[T] {
+	ret := &CSR[T]{
+		Rows:   t.Size.Y,
+		Cols:   t.Size.X,
+		RowPtr: make([]int, t.Size.Y+1),
+	}
+	for y := 0; y < t.Size.Y; y++ {
+		for x := 0; x < t.Size.X; x++ {
+			v := t.Get(x, y, 0)
+			if v == 0 {
+				continue
+			}
+			ret.ColIdx = append(ret.ColIdx, x)
+			ret.Values = append(ret.Values, v)
+		}
+		ret.RowPtr[y+1] = len(ret.Values)
+	}
+	return ret
+}
+
+// NonZero - Returns number of non-zero elements
+func (m *CSR[T]) NonZero() int {
+	return len(m.Values)
+}
+
+// MulVecInto - Matrix-vector product: dst = M * vec. Length of vec should be equal to Cols, length of dst should be equal to Rows. No allocations are made.
+func (m *CSR[T]) MulVecInto(dst, vec []T) error {
+	if len(vec) != m.Cols || len(dst) != m.Rows {
+		return ErrDimensionsNotFit
+	}
+	for r := 0; r < m.Rows; r++ {
+		var sum T
+		for k := m.RowPtr[r]; k < m.RowPtr[r+1]; k++ {
+			sum += m.Values[k] * vec[m.ColIdx[k]]
+		}
+		dst[r] = sum
+	}
+	return nil
+}
@@ -0,0 +1,66 @@
+package tensor
+
+import "unsafe"
+
+// Float - constraint for tensor's element type
+type Float interface {
+	~float32 | ~float64
+}
+
+const (
+	// PrecisionFloat32 - tag for single precision
+	PrecisionFloat32 = "float32"
+	// PrecisionFloat64 - tag for double precision
+	PrecisionFloat64 = "float64"
+)
+
+// Precision - returns precision tag ("float32" or "float64") for element type T
+func Precision[T Float]() string {
+	var v T
+	if unsafe.Sizeof(v) == 4 {
+		return PrecisionFloat32
+	}
+	return PrecisionFloat64
+}
+
+// Convert - Returns new instance of tensor with elements converted to another precision.
+// Usage: tensor.Convert[float32](t)
+func Convert[To, From Float](t *TensorOf[From]) *TensorOf[To] {
+	ret := NewTensorOf[To](t.Size.X, t.Size.Y, t.Size.Z)
+	for i := range t.Data {
+		ret.Data[i] = To(t.Data[i])
+	}
+	return ret
+}
+
+// ConvertData3D - Returns 3-D array with elements converted to another precision.
+func ConvertData3D[To, From Float](data [][][]From) [][][]To {
+	ret := make([][][]To, len(data))
+	for z := range data {
+		ret[z] = make([][]To, len(data[z]))
+		for y := range data[z] {
+			ret[z][y] = make([]To, len(data[z][y]))
+			for x := range data[z][y] {
+				ret[z][y][x] = To(data[z][y][x])
+			}
+		}
+	}
+	return ret
+}
+
+// ConvertData - Returns flat array with elements converted to another precision.
+func ConvertData[To, From Float](data []From) []To {
+	ret := make([]To, len(data))
+	ConvertInto(ret, data)
+	return ret
+}
+
+// ConvertInto - Converts elements of src to precision of dst. Number of converted elements is minimum of lengths.
+func ConvertInto[To, From Float](dst []To, src []From) {
+	for i := range dst {
+		if i >= len(src) {
+			return
+		}
+		dst[i] = To(src[i])
+	}
+}
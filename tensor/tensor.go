@@ -4,14 +4,17 @@ import (
 	"fmt"
 )
 
-// Tensor - Structure for storing data of float64. Actually we can't call this Tensor in terms of math: this one just implements 3 dimensions
-// Data - one-dimensional array of float64;
+// TensorOf - Structure for storing data of float32 or float64. Actually we can't call this Tensor in terms of math: this one just implements 3 dimensions
+// Data - one-dimensional array of T;
 // Size - tensor's data size (see "TDsize" structure).
-type Tensor struct {
-	Data []float64
+type TensorOf[T Float] struct {
+	Data []T
 	Size *TDsize
 }
 
+// Tensor - Tensor of float64 (default precision)
+type Tensor = TensorOf[float64]
+
 // NewTensor - Constructor for Tensor type.
 /*
 	x - number of columns (width);
@@ -19,8 +22,18 @@ type Tensor struct {
 	z - depth.
 */
 func NewTensor(x, y, z int) *Tensor {
-	return &Tensor{
-		Data: make([]float64, x*y*z),
+	return NewTensorOf[float64](x, y, z)
+}
+
+// NewTensorOf - Constructor for TensorOf type. Element type should be provided explicitly: NewTensorOf[float32](x, y, z)
+/*
+	x - number of columns (width);
+	y - number of rows (height);
+	z - depth.
+*/
+func NewTensorOf[T Float](x, y, z int) *TensorOf[T] {
+	return &TensorOf[T]{
+		Data: make([]T, x*y*z),
 		Size: &TDsize{
 			X: x,
 			Y: y,
@@ -33,8 +46,8 @@ func NewTensor(x, y, z int) *Tensor {
 /*
 	t - *Tensor which you want to copy.
 */
-func NewTensorCopy(t *Tensor) *Tensor {
-	return &Tensor{
+func NewTensorCopy[T Float](t *TensorOf[T]) *TensorOf[T] {
+	return &TensorOf[T]{
 		Data: t.Data,
 		Size: &TDsize{
 			X: t.Size.X,
@@ -50,7 +63,7 @@ func NewTensorCopy(t *Tensor) *Tensor {
 	y - col;
 	z - depth.
 */
-func (t *Tensor) Get(x, y, z int) float64 {
+func (t *TensorOf[T]) Get(x, y, z int) T {
 	return t.Data[z*t.Size.X*t.Size.Y+y*t.Size.X+x]
 }
 
@@ -59,9 +72,9 @@ func (t *Tensor) Get(x, y, z int) float64 {
 	x - row;
 	y - col;
 	z - depth;
-	value - value of float64.
+	value - value of T.
 */
-func (t *Tensor) Set(x, y, z int, val float64) {
+func (t *TensorOf[T]) Set(x, y, z int, val T) {
 	t.Data[z*t.Size.X*t.Size.Y+y*t.Size.X+x] = val
 }
 
@@ -70,17 +83,17 @@ func (t *Tensor) Set(x, y, z int, val float64) {
 	x - row;
 	y - col;
 	z - depth;
-	value - value of float64.
+	value - value of T.
 */
-func (t *Tensor) SetAdd(x, y, z int, val float64) {
+func (t *TensorOf[T]) SetAdd(x, y, z int, val T) {
 	t.Data[z*t.Size.X*t.Size.Y+y*t.Size.X+x] += val
 }
 
 // SetData3D - Set data for *Tensor (as 3-d array)
 /*
-	data - 3-D array of float64.
+	data - 3-D array of T.
 */
-func (t *Tensor) SetData3D(data [][][]float64) {
+func (t *TensorOf[T]) SetData3D(data [][][]T) {
 	z := len(data)       // depth
 	y := len(data[0])    // height (number of rows)
 	x := len(data[0][0]) // width (number of columns)
@@ -98,9 +111,9 @@ func (t *Tensor) SetData3D(data [][][]float64) {
 	r - number of rows;
 	c - number of columns (width);
 	d - depth;
-	data - 1-D array of float64.
+	data - 1-D array of T.
 */
-func (t *Tensor) SetData(c, r, d int, data []float64) {
+func (t *TensorOf[T]) SetData(c, r, d int, data []T) {
 	for i := 0; i < c; i++ {
 		for j := 0; j < r; j++ {
 			for k := 0; k < d; k++ {
@@ -111,7 +124,7 @@ func (t *Tensor) SetData(c, r, d int, data []float64) {
 }
 
 // Print - Pretty print for *Tensor (10 decimal places)
-func (t *Tensor) Print() {
+func (t *TensorOf[T]) Print() {
 	mx := t.Size.X
 	my := t.Size.Y
 	mz := t.Size.Z
@@ -127,15 +140,15 @@ func (t *Tensor) Print() {
 }
 
 // GetData3D - Return *Tensor as 3-D array
-func (t *Tensor) GetData3D() [][][]float64 {
+func (t *TensorOf[T]) GetData3D() [][][]T {
 	mx := t.Size.X
 	my := t.Size.Y
 	mz := t.Size.Z
-	ret := make([][][]float64, mz)
+	ret := make([][][]T, mz)
 	for z := 0; z < mz; z++ {
-		ret[z] = make([][]float64, my)
+		ret[z] = make([][]T, my)
 		for y := 0; y < my; y++ {
-			ret[z][y] = make([]float64, mx)
+			ret[z][y] = make([]T, mx)
 			for x := 0; x < mx; x++ {
 				ret[z][y][x] = t.Get(x, y, z)
 			}
@@ -145,7 +158,7 @@ func (t *Tensor) GetData3D() [][][]float64 {
 }
 
 // IsEqualDims Returns true if dimensions of two tensors are equal, otherwise -> false.
-func (t *Tensor) IsEqualDims(t2 *Tensor) bool {
+func (t *TensorOf[T]) IsEqualDims(t2 *TensorOf[T]) bool {
 	if t.Size.X != t2.Size.X || t.Size.Y != t2.Size.Y || t.Size.Z != t2.Size.Z {
 		return false
 	}
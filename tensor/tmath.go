@@ -1,100 +1,131 @@
 package tensor
 
-import (
-	"math"
-)
-
 /*
 	Simple math operations for Tensor type
 */
 
 // Add Element-wise summation.
-func (t1 *Tensor) Add(t2 *Tensor) (*Tensor, error) {
-	var ret = NewTensor(t1.Size.X, t1.Size.Y, t1.Size.Z)
-	ok := t1.IsEqualDims(t2)
-	if !ok {
-		return ret, ErrDimensionsAreNotEqual
+func (t1 *TensorOf[T]) Add(t2 *TensorOf[T]) (*TensorOf[T], error) {
+	var ret = NewTensorOf[T](t1.Size.X, t1.Size.Y, t1.Size.Z)
+	err := AddInto(ret, t1, t2)
+	return ret, err
+}
+
+// AddInto Element-wise summation. Result is stored in dst (it could be t1 or t2 also), so no allocations are made.
+func AddInto[T Float](dst, t1, t2 *TensorOf[T]) error {
+	if !t1.IsEqualDims(t2) || !dst.IsEqualDims(t1) {
+		return ErrDimensionsAreNotEqual
 	}
 	for i := 0; i < t2.Size.Total(); i++ {
-		ret.Data[i] = t1.Data[i] + t2.Data[i]
+		dst.Data[i] = t1.Data[i] + t2.Data[i]
 	}
-	return ret, nil
+	return nil
 }
 
 // Sub Element-wise subtraction.
-func (t1 *Tensor) Sub(t2 *Tensor) (*Tensor, error) {
-	var ret = NewTensor(t1.Size.X, t1.Size.Y, t1.Size.Z)
-	ok := t1.IsEqualDims(t2)
-	if !ok {
-		return ret, ErrDimensionsAreNotEqual
+func (t1 *TensorOf[T]) Sub(t2 *TensorOf[T]) (*TensorOf[T], error) {
+	var ret = NewTensorOf[T](t1.Size.X, t1.Size.Y, t1.Size.Z)
+	err := SubInto(ret, t1, t2)
+	return ret, err
+}
+
+// SubInto Element-wise subtraction. Result is stored in dst (it could be t1 or t2 also), so no allocations are made.
+func SubInto[T Float](dst, t1, t2 *TensorOf[T]) error {
+	if !t1.IsEqualDims(t2) || !dst.IsEqualDims(t1) {
+		return ErrDimensionsAreNotEqual
 	}
 	for i := 0; i < t2.Size.Total(); i++ {
-		ret.Data[i] = t1.Data[i] - t2.Data[i]
+		dst.Data[i] = t1.Data[i] - t2.Data[i]
 	}
-	return ret, nil
+	return nil
 }
 
 // MSE Mean square error. See ref. https://en.wikipedia.org/wiki/Mean_squared_error
-func (t1 *Tensor) MSE(t2 *Tensor) float64 {
+func (t1 *TensorOf[T]) MSE(t2 *TensorOf[T]) float64 {
 	sum := 0.0
-	var ret = NewTensorCopy(t1)
 	num := t2.Size.Total()
 	for i := 0; i < num; i++ {
-		ret.Data[i] = math.Pow((ret.Data[i] - t2.Data[i]), 2.0)
-		sum += ret.Data[i]
+		diff := float64(t1.Data[i] - t2.Data[i])
+		sum += diff * diff
 	}
 	return sum / float64(num)
 }
 
 // Transpose Transponse tensor by X and Y axis (2D). See ref. https://en.wikipedia.org/wiki/Transpose
-func (t1 *Tensor) Transpose() *Tensor {
-	ret := NewTensor(t1.Size.Y, t1.Size.X, t1.Size.Z)
+func (t1 *TensorOf[T]) Transpose() *TensorOf[T] {
+	ret := NewTensorOf[T](t1.Size.Y, t1.Size.X, t1.Size.Z)
+	TransposeInto(ret, t1)
+	return ret
+}
+
+// TransposeInto Transponse tensor by X and Y axis (2D). Result is stored in dst, which should not share data with t1.
+func TransposeInto[T Float](dst, t1 *TensorOf[T]) error {
+	if dst.Size.X != t1.Size.Y || dst.Size.Y != t1.Size.X || dst.Size.Z != t1.Size.Z {
+		return ErrDimensionsNotFit
+	}
 	for z := 0; z < t1.Size.Z; z++ {
 		for y := 0; y < t1.Size.Y; y++ {
 			for x := 0; x < t1.Size.X; x++ {
-				ret.Set(y, x, z, t1.Get(x, y, z))
+				dst.Set(y, x, z, t1.Get(x, y, z))
 			}
 		}
 	}
-	return ret
+	return nil
 }
 
 // Multiply Product of two tensors (by X and Y axis, Matrix2D). See ref. https://en.wikipedia.org/wiki/Matrix_multiplication
-func (t1 *Tensor) Multiply(t2 *Tensor) (*Tensor, error) {
+func (t1 *TensorOf[T]) Multiply(t2 *TensorOf[T]) (*TensorOf[T], error) {
 	if t1.Size.Z != t2.Size.Z || t1.Size.X != t2.Size.Y {
 		return nil, ErrDimensionsNotFit
 	}
-	ret := NewTensor(t2.Size.X, t1.Size.Y, t1.Size.Z)
+	ret := NewTensorOf[T](t2.Size.X, t1.Size.Y, t1.Size.Z)
+	err := MultiplyInto(ret, t1, t2)
+	return ret, err
+}
+
+// MultiplyInto Product of two tensors (by X and Y axis, Matrix2D). Result is stored in dst, which should not share data with t1 or t2.
+func MultiplyInto[T Float](dst, t1, t2 *TensorOf[T]) error {
+	if t1.Size.Z != t2.Size.Z || t1.Size.X != t2.Size.Y {
+		return ErrDimensionsNotFit
+	}
+	if dst.Size.X != t2.Size.X || dst.Size.Y != t1.Size.Y || dst.Size.Z != t1.Size.Z {
+		return ErrDimensionsNotFit
+	}
 	for z := 0; z < t1.Size.Z; z++ {
 		for y := 0; y < t1.Size.Y; y++ {
 			for x := 0; x < t2.Size.X; x++ {
-				var e float64
+				var e T
 				for i := 0; i < t1.Size.X; i++ {
 					e += t1.Get(i, y, z) * t2.Get(x, i, z)
 				}
-				ret.Set(x, y, z, e)
+				dst.Set(x, y, z, e)
 			}
 		}
 	}
-	return ret, nil
+	return nil
 }
 
 // HadamardProduct Element-wise product. See ref. https://en.wikipedia.org/wiki/Hadamard_product_(matrices)
-func HadamardProduct(t1, t2 *Tensor) (*Tensor, error) {
-	ret := NewTensor(t1.Size.X, t1.Size.Y, t1.Size.Z)
-	ok := t1.IsEqualDims(t2)
-	if !ok {
-		return ret, ErrDimensionsAreNotEqual
+func HadamardProduct[T Float](t1, t2 *TensorOf[T]) (*TensorOf[T], error) {
+	ret := NewTensorOf[T](t1.Size.X, t1.Size.Y, t1.Size.Z)
+	err := HadamardProductInto(ret, t1, t2)
+	return ret, err
+}
+
+// HadamardProductInto Element-wise product. Result is stored in dst (it could be t1 or t2 also), so no allocations are made.
+func HadamardProductInto[T Float](dst, t1, t2 *TensorOf[T]) error {
+	if !t1.IsEqualDims(t2) || !dst.IsEqualDims(t1) {
+		return ErrDimensionsAreNotEqual
 	}
-	for i := range ret.Data {
-		ret.Data[i] = t1.Data[i] * t2.Data[i]
+	for i := range dst.Data {
+		dst.Data[i] = t1.Data[i] * t2.Data[i]
 	}
-	return ret, nil
+	return nil
 }
 
 // Convolve2D Convolution between a kernel and a tensor (by X and Y axis, Matrix2D).See ref. https://en.wikipedia.org/wiki/Kernel_(image_processing)#Convolution
-func (t1 *Tensor) Convolve2D(kernel *Tensor, stride int) (*Tensor, error) {
-	outTensor := NewTensor((t1.Size.X-kernel.Size.X)/stride+1, (t1.Size.Y-kernel.Size.Y)/stride+1, t1.Size.Z)
+func (t1 *TensorOf[T]) Convolve2D(kernel *TensorOf[T], stride int) (*TensorOf[T], error) {
+	outTensor := NewTensorOf[T]((t1.Size.X-kernel.Size.X)/stride+1, (t1.Size.Y-kernel.Size.Y)/stride+1, t1.Size.Z)
 	for x := 0; x < outTensor.Size.X; x++ {
 		for y := 0; y < outTensor.Size.Y; y++ {
 			mappedX, mappedY := x*stride, y*stride
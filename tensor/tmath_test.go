@@ -50,6 +50,54 @@ func TestSubWise(t *testing.T) {
 	}
 }
 
+func TestSubIntoAllocs(t *testing.T) {
+	tensor1 := NewTensor(2, 2, 1)
+	tensor1.SetData(2, 2, 1, []float64{1, 2, 3, 4})
+	tensor2 := NewTensor(2, 2, 1)
+	tensor2.SetData(2, 2, 1, []float64{5, 6, 7, 8})
+	dst := NewTensor(2, 2, 1)
+
+	tensorCorrect := NewTensor(2, 2, 1)
+	tensorCorrect.SetData(2, 2, 1, []float64{-4, -4, -4, -4})
+
+	allocs := testing.AllocsPerRun(10, func() {
+		err := SubInto(dst, tensor1, tensor2)
+		if err != nil {
+			t.Error(err)
+		}
+	})
+	if allocs != 0 {
+		t.Errorf("SubInto should not allocate, but got %f allocations", allocs)
+	}
+	for i := range dst.Data {
+		if dst.Data[i] != tensorCorrect.Data[i] {
+			t.Errorf("Tensors are not equal at pos #%d. Expected value: %f. Got: %f", i, tensorCorrect.Data[i], dst.Data[i])
+		}
+	}
+
+	err := SubInto(NewTensor(3, 2, 1), tensor1, tensor2)
+	if err == nil || err != ErrDimensionsAreNotEqual {
+		t.Error("Error must appear because of dst has shape (3,2,1) and tensors have shape (2,2,1)")
+	}
+}
+
+func TestMSE(t *testing.T) {
+	tensor1 := NewTensor(2, 2, 1)
+	tensor1.SetData(2, 2, 1, []float64{1, 2, 3, 4})
+	tensor2 := NewTensor(2, 2, 1)
+	tensor2.SetData(2, 2, 1, []float64{2, 2, 5, 4})
+
+	correct := 1.25
+	got := tensor1.MSE(tensor2)
+	if got != correct {
+		t.Errorf("Should be %f, but got %f", correct, got)
+	}
+	// MSE should not modify operands
+	if tensor1.Data[0] != 1 || tensor1.Data[2] != 3 {
+		t.Errorf("MSE has modified first operand: %v", tensor1.Data)
+	}
+}
+
 func TestTranspose(t *testing.T) {
 	tensor := NewTensor(2, 3, 1)
 	tensor.SetData(2, 3, 1, []float64{1, 2, 3, 4, 5, 6})
@@ -193,3 +241,29 @@ func TestConvolve2D(t *testing.T) {
 	}
 
 }
+
+func TestCSRMulVec(t *testing.T) {
+	matrix := NewTensor(3, 2, 1)
+	matrix.SetData(3, 2, 1, []float64{1, 0, 2, 0, 0, 3})
+	csr := NewCSR(matrix)
+	if csr.NonZero() != 3 {
+		t.Errorf("Number of non-zero elements should be %d, but got %d", 3, csr.NonZero())
+	}
+	vec := []float64{1, 2, 3}
+	correct := []float64{7, 9}
+	dst := make([]float64, 2)
+	err := csr.MulVecInto(dst, vec)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	for i := range dst {
+		if dst[i] != correct[i] {
+			t.Errorf("Vectors are not equal at pos #%d. Expected value: %f. Got: %f", i, correct[i], dst[i])
+		}
+	}
+	err = csr.MulVecInto(dst, []float64{1, 2})
+	if err == nil || err != ErrDimensionsNotFit {
+		t.Error("Error must appear because of vector has length 2 and matrix has 3 columns")
+	}
+}
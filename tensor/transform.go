@@ -0,0 +1,92 @@
+package tensor
+
+import (
+	"math"
+)
+
+// FlipX Mirror tensor (2d component) horizontally. Returns new instance of Tensor
+func (t1 *TensorOf[T]) FlipX() *TensorOf[T] {
+	ret := NewTensorOf[T](t1.Size.X, t1.Size.Y, t1.Size.Z)
+	for z := 0; z < ret.Size.Z; z++ {
+		for y := 0; y < ret.Size.Y; y++ {
+			for x := 0; x < ret.Size.X; x++ {
+				ret.Set(x, y, z, t1.Get(t1.Size.X-x-1, y, z))
+			}
+		}
+	}
+	return ret
+}
+
+// FlipY Mirror tensor (2d component) vertically. Returns new instance of Tensor
+func (t1 *TensorOf[T]) FlipY() *TensorOf[T] {
+	ret := NewTensorOf[T](t1.Size.X, t1.Size.Y, t1.Size.Z)
+	for z := 0; z < ret.Size.Z; z++ {
+		for y := 0; y < ret.Size.Y; y++ {
+			for x := 0; x < ret.Size.X; x++ {
+				ret.Set(x, y, z, t1.Get(x, t1.Size.Y-y-1, z))
+			}
+		}
+	}
+	return ret
+}
+
+// Interpolate2D Returns value at non-integer point (x, y) of z-th channel using bilinear interpolation. Points outside of tensor have value fill
+func (t1 *TensorOf[T]) Interpolate2D(x, y float64, z int, fill T) T {
+	x0 := int(math.Floor(x))
+	y0 := int(math.Floor(y))
+	fx := x - float64(x0)
+	fy := y - float64(y0)
+	at := func(xi, yi int) float64 {
+		if xi < 0 || yi < 0 || xi >= t1.Size.X || yi >= t1.Size.Y {
+			return float64(fill)
+		}
+		return float64(t1.Get(xi, yi, z))
+	}
+	top := at(x0, y0)*(1-fx) + at(x0+1, y0)*fx
+	bottom := at(x0, y0+1)*(1-fx) + at(x0+1, y0+1)*fx
+	return T(top*(1-fy) + bottom*fy)
+}
+
+// Warp2D Returns new instance of Tensor (width x height x Z) where value of point (x, y) is taken from point mapping(x, y) of source tensor (see Interpolate2D)
+func (t1 *TensorOf[T]) Warp2D(width, height int, mapping func(x, y int) (float64, float64), fill T) *TensorOf[T] {
+	ret := NewTensorOf[T](width, height, t1.Size.Z)
+	for y := 0; y < height; y++ {
+		for x := 0; x < width; x++ {
+			sx, sy := mapping(x, y)
+			for z := 0; z < t1.Size.Z; z++ {
+				ret.Set(x, y, z, t1.Interpolate2D(sx, sy, z, fill))
+			}
+		}
+	}
+	return ret
+}
+
+// Rotate2D Rotate tensor (2d component) by arbitrary angle (in degrees, counterclockwise as Rot2D90 does) around its center. Size is not changed, uncovered points have value fill. Returns new instance of Tensor
+func (t1 *TensorOf[T]) Rotate2D(degrees float64, fill T) *TensorOf[T] {
+	sin, cos := math.Sincos(degrees * math.Pi / 180.0)
+	cx := float64(t1.Size.X-1) / 2.0
+	cy := float64(t1.Size.Y-1) / 2.0
+	return t1.Warp2D(t1.Size.X, t1.Size.Y, func(x, y int) (float64, float64) {
+		dx := float64(x) - cx
+		dy := float64(y) - cy
+		return cx + cos*dx - sin*dy, cy + sin*dx + cos*dy
+	}, fill)
+}
+
+// Crop2D Returns region (width x height) of tensor (2d component) starting at point (x0, y0). Region can be partially outside of tensor: such points have value fill (padding). Returns new instance of Tensor
+func (t1 *TensorOf[T]) Crop2D(x0, y0, width, height int, fill T) *TensorOf[T] {
+	ret := NewTensorOf[T](width, height, t1.Size.Z)
+	for z := 0; z < ret.Size.Z; z++ {
+		for y := 0; y < height; y++ {
+			for x := 0; x < width; x++ {
+				sx, sy := x0+x, y0+y
+				if sx < 0 || sy < 0 || sx >= t1.Size.X || sy >= t1.Size.Y {
+					ret.Set(x, y, z, fill)
+					continue
+				}
+				ret.Set(x, y, z, t1.Get(sx, sy, z))
+			}
+		}
+	}
+	return ret
+}
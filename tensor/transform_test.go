@@ -0,0 +1,39 @@
+package tensor
+
+import (
+	"math"
+	"testing"
+)
+
+func TestTransform2D(t *testing.T) {
+	t1 := NewTensor(3, 3, 1)
+	t1.SetData(3, 3, 1, []float64{
+		1, 2, 3,
+		4, 5, 6,
+		7, 8, 9,
+	})
+	rotated := t1.Rotate2D(90, 0)
+	expected := t1.Rot2D90()
+	for i := range expected.Data {
+		if math.Abs(rotated.Data[i]-expected.Data[i]) > 1e-9 {
+			t.Errorf("Rotate2D(90) is wrong at %d. Expected value: %f. Got: %f", i, expected.Data[i], rotated.Data[i])
+		}
+	}
+	flipped := t1.FlipX().FlipY()
+	expected = t1.Rot2D180()
+	for i := range expected.Data {
+		if flipped.Data[i] != expected.Data[i] {
+			t.Errorf("FlipX and FlipY are wrong at %d. Expected value: %f. Got: %f", i, expected.Data[i], flipped.Data[i])
+		}
+	}
+	if v := t1.Interpolate2D(0.5, 1.5, 0, 0); v != 6.0 {
+		t.Errorf("Interpolate2D is wrong. Expected value: %f. Got: %f", 6.0, v)
+	}
+	cropped := t1.Crop2D(-1, 1, 3, 2, -1)
+	expectedData := []float64{-1, 4, 5, -1, 7, 8}
+	for i := range expectedData {
+		if cropped.Data[i] != expectedData[i] {
+			t.Errorf("Crop2D is wrong at %d. Expected value: %f. Got: %f", i, expectedData[i], cropped.Data[i])
+		}
+	}
+}
@@ -0,0 +1,146 @@
+package tensorboard
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/LdDl/cnns"
+	"github.com/LdDl/cnns/tensor"
+	"github.com/LdDl/cnns/visualize"
+)
+
+// Callback - training callback which writes TensorBoard events
+/*
+	Writer - destination of events;
+	Net - trained net (needed for histograms and images only);
+	EveryBatches - write metrics of every N-th mini-batch as "batch/<name>" with number of weights' updates as step (0 - disabled).
+		Metrics of every epoch ("loss", "val_loss", "lr" and etc.) are always written with epoch as step;
+	HistogramsEveryEpochs - write histograms of weights and gradients (of last sample, see cnns.WeightGradients) of every layer
+		as "layer_<index>_<type>/weights" and "layer_<index>_<type>/gradients" every N epochs (0 - disabled);
+	Bins - number of buckets of histograms (30 if not set);
+	Samples - inputs rendered as "samples/<index>/input" along with outputs of every layer (feature maps) as "samples/<index>/layer_<index>_<type>";
+	ImagesEveryEpochs - write images of Samples every N epochs (0 - disabled);
+	Grid - layout of rendered tensors. See visualize.GridConfig.
+*/
+type Callback[T tensor.Float] struct {
+	cnns.BaseCallback
+	Writer                *Writer
+	Net                   *cnns.WholeNetOf[T]
+	EveryBatches          int
+	HistogramsEveryEpochs int
+	Bins                  int
+	Samples               []*tensor.TensorOf[T]
+	ImagesEveryEpochs     int
+	Grid                  visualize.GridConfig
+	err                   error
+}
+
+// NewCallback - constructor for Callback writing metrics of every epoch, histograms of every epoch and images of samples (if any) of every epoch
+func NewCallback[T tensor.Float](writer *Writer, net *cnns.WholeNetOf[T], samples ...*tensor.TensorOf[T]) *Callback[T] {
+	return &Callback[T]{
+		Writer:                writer,
+		Net:                   net,
+		HistogramsEveryEpochs: 1,
+		Samples:               samples,
+		ImagesEveryEpochs:     1,
+	}
+}
+
+// Err - returns first error of writing events (callbacks can not interrupt training with error)
+func (cb *Callback[T]) Err() error {
+	return cb.err
+}
+
+// check - remembers first error
+func (cb *Callback[T]) check(err error) {
+	if err != nil && cb.err == nil {
+		cb.err = err
+	}
+}
+
+// scalars - writes metrics in order of their names
+func (cb *Callback[T]) scalars(prefix string, step int, metrics map[string]float64) {
+	names := make([]string, 0, len(metrics))
+	for name := range metrics {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		cb.check(cb.Writer.Scalar(prefix+name, step, metrics[name]))
+	}
+}
+
+// OnBatchEnd - writes metrics of mini-batch if EveryBatches is set
+func (cb *Callback[T]) OnBatchEnd(event *cnns.TrainEvent) {
+	if cb.EveryBatches <= 0 || (event.Batch+1)%cb.EveryBatches != 0 {
+		return
+	}
+	cb.scalars("batch/", event.Step, event.Metrics)
+}
+
+// OnEpochEnd - writes metrics of epoch, histograms and images
+func (cb *Callback[T]) OnEpochEnd(event *cnns.TrainEvent) {
+	cb.scalars("", event.Epoch, event.Metrics)
+	if cb.Net != nil && cb.HistogramsEveryEpochs > 0 && (event.Epoch+1)%cb.HistogramsEveryEpochs == 0 {
+		cb.histograms(event.Epoch)
+	}
+	if cb.Net != nil && len(cb.Samples) != 0 && cb.ImagesEveryEpochs > 0 && (event.Epoch+1)%cb.ImagesEveryEpochs == 0 {
+		cb.images(event.Epoch)
+	}
+	cb.check(cb.Writer.Flush())
+}
+
+// OnTrainEnd - flushes events
+func (cb *Callback[T]) OnTrainEnd(event *cnns.TrainEvent) {
+	cb.check(cb.Writer.Flush())
+}
+
+// layerName - "layer_<index>_<type>"
+func layerName[T tensor.Float](index int, layer cnns.LayerOf[T]) string {
+	return fmt.Sprintf("layer_%02d_%s", index, layer.GetType())
+}
+
+// flatten - values of tensors
+func flatten[T tensor.Float](tensors []*tensor.TensorOf[T]) []float64 {
+	var ret []float64
+	for _, t := range tensors {
+		for _, v := range t.Data {
+			ret = append(ret, float64(v))
+		}
+	}
+	return ret
+}
+
+// histograms - writes histograms of weights and gradients of layers with weights
+func (cb *Callback[T]) histograms(step int) {
+	for i, layer := range cb.Net.Layers {
+		gradients := cnns.WeightGradients(layer)
+		if gradients == nil {
+			continue
+		}
+		name := layerName(i, layer)
+		cb.check(cb.Writer.Histogram(name+"/weights", step, flatten(layer.GetWeights()), cb.Bins))
+		cb.check(cb.Writer.Histogram(name+"/gradients", step, flatten(gradients), cb.Bins))
+	}
+}
+
+// images - feeds samples to the net and writes them along with feature maps of every layer
+func (cb *Callback[T]) images(step int) {
+	for s, sample := range cb.Samples {
+		tiles, err := visualize.TensorTiles(sample, "ch", cb.Grid.SharedScale)
+		if err != nil {
+			cb.check(err)
+			return
+		}
+		cb.check(cb.Writer.Image(fmt.Sprintf("samples/%d/input", s), step, visualize.Grid(tiles, cb.Grid)))
+		cb.Net.FeedForward(sample)
+		for i, layer := range cb.Net.Layers {
+			tiles, err = visualize.OutputTiles(layer, cb.Grid.SharedScale)
+			if err != nil {
+				cb.check(err)
+				return
+			}
+			cb.check(cb.Writer.Image(fmt.Sprintf("samples/%d/%s", s, layerName(i, layer)), step, visualize.Grid(tiles, cb.Grid)))
+		}
+	}
+}
@@ -0,0 +1,73 @@
+package tensorboard
+
+import (
+	"encoding/binary"
+	"math"
+)
+
+// Wire types of protocol buffers
+const (
+	wireVarint  = 0
+	wireFixed64 = 1
+	wireBytes   = 2
+	wireFixed32 = 5
+)
+
+// protoBuffer - minimal protocol buffers encoder for messages of event.proto and summary.proto
+type protoBuffer []byte
+
+func (pb *protoBuffer) varint(v uint64) {
+	var buf [binary.MaxVarintLen64]byte
+	n := binary.PutUvarint(buf[:], v)
+	*pb = append(*pb, buf[:n]...)
+}
+
+func (pb *protoBuffer) fixed64(v uint64) {
+	var buf [8]byte
+	binary.LittleEndian.PutUint64(buf[:], v)
+	*pb = append(*pb, buf[:]...)
+}
+
+func (pb *protoBuffer) fixed32(v uint32) {
+	var buf [4]byte
+	binary.LittleEndian.PutUint32(buf[:], v)
+	*pb = append(*pb, buf[:]...)
+}
+
+func (pb *protoBuffer) key(field, wireType int) {
+	pb.varint(uint64(field<<3 | wireType))
+}
+
+func (pb *protoBuffer) int64(field int, v int64) {
+	pb.key(field, wireVarint)
+	pb.varint(uint64(v))
+}
+
+func (pb *protoBuffer) double(field int, v float64) {
+	pb.key(field, wireFixed64)
+	pb.fixed64(math.Float64bits(v))
+}
+
+func (pb *protoBuffer) float(field int, v float32) {
+	pb.key(field, wireFixed32)
+	pb.fixed32(math.Float32bits(v))
+}
+
+func (pb *protoBuffer) bytes(field int, v []byte) {
+	pb.key(field, wireBytes)
+	pb.varint(uint64(len(v)))
+	*pb = append(*pb, v...)
+}
+
+func (pb *protoBuffer) string(field int, v string) {
+	pb.bytes(field, []byte(v))
+}
+
+// packedDoubles - repeated double field with packed encoding
+func (pb *protoBuffer) packedDoubles(field int, v []float64) {
+	data := make(protoBuffer, 0, 8*len(v))
+	for _, d := range v {
+		data.fixed64(math.Float64bits(d))
+	}
+	pb.bytes(field, data)
+}
@@ -0,0 +1,77 @@
+package tensorboard
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"hash/crc32"
+	"io"
+)
+
+var (
+	crc32c = crc32.MakeTable(crc32.Castagnoli)
+	// ErrCorruptedRecord - checksum of record's length or data does not match
+	ErrCorruptedRecord = errors.New("corrupted record: checksum mismatch")
+	// MaxRecordSize - max length of data of record read by ReadRecord in bytes
+	MaxRecordSize uint64 = 1 << 30
+)
+
+// maskedCRC - masked CRC32C used by TFRecord format
+func maskedCRC(data []byte) uint32 {
+	crc := crc32.Checksum(data, crc32c)
+	return ((crc >> 15) | (crc << 17)) + 0xa282ead8
+}
+
+// writeRecord - writes data in TFRecord framing
+/*
+	uint64 length
+	uint32 masked CRC32C of length
+	byte   data[length]
+	uint32 masked CRC32C of data
+	All integers are little-endian.
+*/
+func writeRecord(w io.Writer, data []byte) error {
+	header := make([]byte, 12)
+	binary.LittleEndian.PutUint64(header[:8], uint64(len(data)))
+	binary.LittleEndian.PutUint32(header[8:], maskedCRC(header[:8]))
+	footer := make([]byte, 4)
+	binary.LittleEndian.PutUint32(footer, maskedCRC(data))
+	for _, part := range [][]byte{header, data, footer} {
+		_, err := w.Write(part)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// ReadRecord - reads single TFRecord and verifies its checksums. Returns io.EOF if there are no more records
+func ReadRecord(r io.Reader) ([]byte, error) {
+	header := make([]byte, 12)
+	_, err := io.ReadFull(r, header)
+	if err != nil {
+		if err == io.ErrUnexpectedEOF {
+			return nil, errors.New("truncated record header")
+		}
+		return nil, err
+	}
+	if binary.LittleEndian.Uint32(header[8:]) != maskedCRC(header[:8]) {
+		return nil, ErrCorruptedRecord
+	}
+	length := binary.LittleEndian.Uint64(header[:8])
+	if length > MaxRecordSize {
+		return nil, fmt.Errorf("record length %d exceeds %d bytes", length, MaxRecordSize)
+	}
+	// Buffer grows while data is read, so truncated record does not allocate memory for declared length
+	var buf bytes.Buffer
+	_, err = io.CopyN(&buf, r, int64(length)+4)
+	if err != nil {
+		return nil, errors.New("truncated record data")
+	}
+	data := buf.Bytes()
+	if binary.LittleEndian.Uint32(data[length:]) != maskedCRC(data[:length]) {
+		return nil, ErrCorruptedRecord
+	}
+	return data[:length], nil
+}
@@ -0,0 +1,313 @@
+package tensorboard
+
+import (
+	"bytes"
+	"encoding/binary"
+	"image"
+	"image/png"
+	"io"
+	"math"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/LdDl/cnns"
+	"github.com/LdDl/cnns/tensor"
+)
+
+// message - decoded protocol buffer: raw values of fields by field number (varints as uint64, fixed as uint64/uint32 bits, bytes as []byte)
+type message map[int][]interface{}
+
+func decodeMessage(t *testing.T, data []byte) message {
+	ret := message{}
+	for len(data) > 0 {
+		key, n := binary.Uvarint(data)
+		if n <= 0 {
+			t.Fatalf("Bad key")
+		}
+		data = data[n:]
+		field := int(key >> 3)
+		switch key & 7 {
+		case wireVarint:
+			v, n := binary.Uvarint(data)
+			if n <= 0 {
+				t.Fatalf("Bad varint")
+			}
+			ret[field] = append(ret[field], v)
+			data = data[n:]
+		case wireFixed64:
+			ret[field] = append(ret[field], binary.LittleEndian.Uint64(data))
+			data = data[8:]
+		case wireFixed32:
+			ret[field] = append(ret[field], binary.LittleEndian.Uint32(data))
+			data = data[4:]
+		case wireBytes:
+			l, n := binary.Uvarint(data)
+			if n <= 0 || int(l) > len(data)-n {
+				t.Fatalf("Bad length")
+			}
+			ret[field] = append(ret[field], data[n:n+int(l)])
+			data = data[n+int(l):]
+		default:
+			t.Fatalf("Unexpected wire type %d", key&7)
+		}
+	}
+	return ret
+}
+
+func (m message) double(field int) float64 {
+	return math.Float64frombits(m[field][0].(uint64))
+}
+
+func (m message) bytes(field int) []byte {
+	return m[field][0].([]byte)
+}
+
+func (m message) doubles(field int) []float64 {
+	data := m.bytes(field)
+	ret := make([]float64, len(data)/8)
+	for i := range ret {
+		ret[i] = math.Float64frombits(binary.LittleEndian.Uint64(data[8*i:]))
+	}
+	return ret
+}
+
+// readValue - reads event and returns it with its step and its only summary value
+func readValue(t *testing.T, r io.Reader) (message, int, message) {
+	record, err := ReadRecord(r)
+	if err != nil {
+		t.Fatal(err)
+	}
+	event := decodeMessage(t, record)
+	summary := decodeMessage(t, event.bytes(eventSummary))
+	return event, int(event[eventStep][0].(uint64)), decodeMessage(t, summary.bytes(summaryValue))
+}
+
+func TestWriter(t *testing.T) {
+	var buf bytes.Buffer
+	w, err := NewWriterTo(&buf)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	if err = w.Scalar("loss", 3, 0.25); err != nil {
+		t.Error(err)
+		return
+	}
+	if err = w.Histogram("weights", 4, []float64{-1, 0, 0.5, 1, math.NaN()}, 2); err != nil {
+		t.Error(err)
+		return
+	}
+	img := image.NewGray(image.Rect(0, 0, 3, 2))
+	img.Pix[4] = 200
+	if err = w.Image("sample", 5, img); err != nil {
+		t.Error(err)
+		return
+	}
+	if err = w.Close(); err != nil {
+		t.Error(err)
+		return
+	}
+
+	data := buf.Bytes()
+	r := bytes.NewReader(data)
+	record, err := ReadRecord(r)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	if version := string(decodeMessage(t, record).bytes(eventFileVersion)); version != FileVersion {
+		t.Errorf("File version is wrong. Expected value: %s. Got: %s", FileVersion, version)
+	}
+
+	_, step, value := readValue(t, r)
+	scalar := math.Float32frombits(value[valueSimpleValue][0].(uint32))
+	if string(value.bytes(valueTag)) != "loss" || step != 3 || scalar != 0.25 {
+		t.Errorf("Scalar is wrong: %s %d %f", value.bytes(valueTag), step, scalar)
+	}
+
+	_, step, value = readValue(t, r)
+	histo := decodeMessage(t, value.bytes(valueHisto))
+	if step != 4 || histo.double(histoNum) != 4 || histo.double(histoSum) != 0.5 || histo.double(histoMin) != -1 || histo.double(histoMax) != 1 {
+		t.Errorf("Histogram is wrong: step %d, num %f, sum %f", step, histo.double(histoNum), histo.double(histoSum))
+	}
+	limits, counts := histo.doubles(histoBucketLimit), histo.doubles(histoBucket)
+	if len(limits) != 2 || limits[0] != 0 || limits[1] != 1 || counts[0] != 2 || counts[1] != 2 {
+		t.Errorf("Buckets are wrong: %v %v", limits, counts)
+	}
+
+	_, step, value = readValue(t, r)
+	summaryImage := decodeMessage(t, value.bytes(valueImage))
+	decoded, err := png.Decode(bytes.NewReader(summaryImage.bytes(imageEncoded)))
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	if step != 5 || summaryImage[imageWidth][0].(uint64) != 3 || summaryImage[imageHeight][0].(uint64) != 2 || summaryImage[imageColorspace][0].(uint64) != 1 {
+		t.Errorf("Image is wrong: %v", summaryImage)
+	}
+	if gray := decoded.(*image.Gray); gray.Pix[4] != 200 {
+		t.Errorf("Pixel of image is wrong. Expected value: %d. Got: %d", 200, gray.Pix[4])
+	}
+	if _, err = ReadRecord(r); err != io.EOF {
+		t.Errorf("io.EOF should be returned after last record. Got: %v", err)
+	}
+
+	// Corrupted data
+	data[len(data)-10] ^= 0xff
+	r = bytes.NewReader(data)
+	for err == io.EOF || err == nil {
+		_, err = ReadRecord(r)
+	}
+	if err != ErrCorruptedRecord {
+		t.Errorf("ErrCorruptedRecord should be returned. Got: %v", err)
+	}
+	// Huge or truncated data with valid checksum of length
+	for _, length := range []uint64{math.MaxUint64 - 1, 1 << 40, 100} {
+		header := make([]byte, 12)
+		binary.LittleEndian.PutUint64(header[:8], length)
+		binary.LittleEndian.PutUint32(header[8:], maskedCRC(header[:8]))
+		_, err = ReadRecord(bytes.NewReader(append(header, 1, 2, 3)))
+		if err == nil || err == io.EOF {
+			t.Errorf("Error should be returned for length %d", length)
+		}
+	}
+}
+
+// gradientRecorder - remembers sum of gradients of weights of layer after last batch of every epoch
+type gradientRecorder struct {
+	cnns.BaseCallback
+	layer cnns.Layer
+	sums  []float64
+}
+
+func (gr *gradientRecorder) OnBatchEnd(event *cnns.TrainEvent) {
+	if event.Batch != event.Batches-1 {
+		return
+	}
+	sum := 0.0
+	for _, g := range cnns.WeightGradients(gr.layer) {
+		for _, v := range g.Data {
+			sum += v
+		}
+	}
+	gr.sums = append(gr.sums, sum)
+}
+
+func TestCallback(t *testing.T) {
+	conv := cnns.NewConvLayer(1, 3, 2, tensor.TDsize{X: 6, Y: 6, Z: 1})
+	relu := cnns.NewReLULayer(conv.GetOutputSize())
+	fc := cnns.NewFullyConnectedLayer(relu.GetOutputSize(), 2)
+	net := &cnns.WholeNet{Layers: []cnns.Layer{conv, relu, fc}}
+
+	inputs := make([]*tensor.Tensor, 4)
+	targets := make([]*tensor.Tensor, 4)
+	for i := range inputs {
+		inputs[i] = tensor.NewTensor(6, 6, 1)
+		for j := range inputs[i].Data {
+			inputs[i].Data[j] = float64((i+j)%5) / 5
+		}
+		targets[i] = tensor.NewTensor(2, 1, 1)
+		targets[i].Data[i%2] = 1
+	}
+
+	validation := make([]*tensor.Tensor, 2)
+	for i := range validation {
+		validation[i] = tensor.NewTensor(6, 6, 1)
+		for j := range validation[i].Data {
+			validation[i].Data[j] = float64((3*i+j)%7) / 7
+		}
+	}
+
+	dir := t.TempDir()
+	w, err := NewWriter(dir)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	cb := NewCallback(w, net, inputs[0])
+	cb.EveryBatches = 1
+	// Gradients of fully connected layer after last batch of every epoch: histograms should not be affected by validation pass
+	recorder := &gradientRecorder{layer: fc}
+	_, _, err = net.Train(inputs, targets, cnns.TrainConfig{
+		Epochs:            2,
+		BatchSize:         2,
+		ValidationInputs:  validation,
+		ValidationTargets: targets[:2],
+		Callbacks:         []cnns.Callback{recorder, cb},
+	})
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	if err = cb.Err(); err != nil {
+		t.Error(err)
+		return
+	}
+	if err = w.Close(); err != nil {
+		t.Error(err)
+		return
+	}
+
+	files, err := filepath.Glob(filepath.Join(dir, "events.out.tfevents.*"))
+	if err != nil || len(files) != 1 || files[0] != w.Path() {
+		t.Errorf("Event file is not found: %v %v", files, err)
+		return
+	}
+	f, err := os.Open(files[0])
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	defer f.Close()
+	tags := make(map[string]int)
+	var gradientSums []float64
+	for {
+		record, err := ReadRecord(f)
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			t.Error(err)
+			return
+		}
+		event := decodeMessage(t, record)
+		if _, ok := event[eventSummary]; !ok {
+			continue
+		}
+		value := decodeMessage(t, decodeMessage(t, event.bytes(eventSummary)).bytes(summaryValue))
+		tags[string(value.bytes(valueTag))]++
+		if string(value.bytes(valueTag)) == "layer_02_fc/gradients" {
+			gradientSums = append(gradientSums, decodeMessage(t, value.bytes(valueHisto)).double(histoSum))
+		}
+	}
+	for e, sum := range gradientSums {
+		if math.Abs(sum-recorder.sums[e]) > 1e-9 {
+			t.Errorf("Sum of gradients of epoch #%d is wrong. Expected value: %f. Got: %f", e, recorder.sums[e], sum)
+		}
+	}
+	// 2 epochs of 2 batches
+	expected := map[string]int{
+		"batch/loss":              4,
+		"batch/lr":                4,
+		"loss":                    2,
+		"val_loss":                2,
+		"lr":                      2,
+		"layer_00_conv/weights":   2,
+		"layer_00_conv/gradients": 2,
+		"layer_02_fc/weights":     2,
+		"layer_02_fc/gradients":   2,
+		"samples/0/input":         2,
+		"samples/0/layer_00_conv": 2,
+		"samples/0/layer_01_relu": 2,
+		"samples/0/layer_02_fc":   2,
+	}
+	for tag, count := range expected {
+		if tags[tag] != count {
+			t.Errorf("Number of events '%s' is wrong. Expected value: %d. Got: %d", tag, count, tags[tag])
+		}
+	}
+	if len(tags) != len(expected) {
+		t.Errorf("Unexpected tags: %v", tags)
+	}
+}
@@ -0,0 +1,241 @@
+// Package tensorboard writes TensorBoard event files (TFRecord framing, Event and Summary protocol buffers) in pure Go: scalars, histograms and images. See Callback for logging of training process.
+package tensorboard
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"image"
+	"image/png"
+	"io"
+	"math"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+// Field numbers of event.proto and summary.proto
+const (
+	eventWallTime    = 1
+	eventStep        = 2
+	eventFileVersion = 3
+	eventSummary     = 5
+
+	summaryValue = 1
+
+	valueTag         = 1
+	valueSimpleValue = 2
+	valueImage       = 4
+	valueHisto       = 5
+
+	imageHeight     = 1
+	imageWidth      = 2
+	imageColorspace = 3
+	imageEncoded    = 4
+
+	histoMin         = 1
+	histoMax         = 2
+	histoNum         = 3
+	histoSum         = 4
+	histoSumSquares  = 5
+	histoBucketLimit = 6
+	histoBucket      = 7
+)
+
+// FileVersion - version of event file written as first event
+const FileVersion = "brain.Event:2"
+
+// Writer - writer of TensorBoard events. Safe for concurrent use
+type Writer struct {
+	mu   sync.Mutex
+	buf  *bufio.Writer
+	file *os.File
+	path string
+}
+
+// NewWriter - creates directory (if it does not exist) and event file "events.out.tfevents.<unix time>.<hostname>" in it. Run "tensorboard --logdir <dir>" to see events
+func NewWriter(dir string) (*Writer, error) {
+	err := os.MkdirAll(dir, 0755)
+	if err != nil {
+		return nil, err
+	}
+	hostname, err := os.Hostname()
+	if err != nil {
+		hostname = "localhost"
+	}
+	path := filepath.Join(dir, fmt.Sprintf("events.out.tfevents.%d.%s", time.Now().Unix(), hostname))
+	f, err := os.Create(path)
+	if err != nil {
+		return nil, err
+	}
+	w, err := newWriter(f)
+	if err != nil {
+		f.Close()
+		return nil, err
+	}
+	w.file = f
+	w.path = path
+	return w, nil
+}
+
+// NewWriterTo - writer of events to w (e.g. for writing to memory)
+func NewWriterTo(w io.Writer) (*Writer, error) {
+	return newWriter(w)
+}
+
+func newWriter(w io.Writer) (*Writer, error) {
+	ret := &Writer{buf: bufio.NewWriter(w)}
+	event := newEvent(0)
+	event.string(eventFileVersion, FileVersion)
+	err := ret.write(event)
+	if err != nil {
+		return nil, err
+	}
+	return ret, ret.Flush()
+}
+
+// Path - name of event file (empty for NewWriterTo)
+func (w *Writer) Path() string {
+	return w.path
+}
+
+// newEvent - event with current time and step
+func newEvent(step int) protoBuffer {
+	var event protoBuffer
+	event.double(eventWallTime, float64(time.Now().UnixNano())/1e9)
+	event.int64(eventStep, int64(step))
+	return event
+}
+
+// write - writes event as single record
+func (w *Writer) write(event protoBuffer) error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return writeRecord(w.buf, event)
+}
+
+// writeValue - writes event with summary of single value
+func (w *Writer) writeValue(step int, value protoBuffer) error {
+	var summary protoBuffer
+	summary.bytes(summaryValue, value)
+	event := newEvent(step)
+	event.bytes(eventSummary, summary)
+	return w.write(event)
+}
+
+// Scalar - writes scalar value
+func (w *Writer) Scalar(tag string, step int, value float64) error {
+	var v protoBuffer
+	v.string(valueTag, tag)
+	v.float(valueSimpleValue, float32(value))
+	return w.writeValue(step, v)
+}
+
+// Histogram - writes histogram of values with given number of equal-width buckets (30 if not set). NaN and infinite values are skipped
+func (w *Writer) Histogram(tag string, step int, values []float64, bins int) error {
+	var v protoBuffer
+	v.string(valueTag, tag)
+	v.bytes(valueHisto, histogram(values, bins))
+	return w.writeValue(step, v)
+}
+
+// histogram - encoded HistogramProto. Bucket i counts values in (limit[i-1], limit[i]], first bucket starts from minimum
+func histogram(values []float64, bins int) protoBuffer {
+	if bins <= 0 {
+		bins = 30
+	}
+	min, max := math.Inf(1), math.Inf(-1)
+	num, sum, sumSquares := 0.0, 0.0, 0.0
+	for _, v := range values {
+		if math.IsNaN(v) || math.IsInf(v, 0) {
+			continue
+		}
+		min = math.Min(min, v)
+		max = math.Max(max, v)
+		num++
+		sum += v
+		sumSquares += v * v
+	}
+	var limits, counts []float64
+	switch {
+	case num == 0:
+		min, max = 0, 0
+	case min == max:
+		limits, counts = []float64{max}, []float64{num}
+	default:
+		limits, counts = make([]float64, bins), make([]float64, bins)
+		width := (max - min) / float64(bins)
+		for i := range limits {
+			limits[i] = min + float64(i+1)*width
+		}
+		limits[bins-1] = max
+		for _, v := range values {
+			if math.IsNaN(v) || math.IsInf(v, 0) {
+				continue
+			}
+			bin := int(math.Ceil((v-min)/width)) - 1
+			if bin < 0 {
+				bin = 0
+			}
+			if bin >= bins {
+				bin = bins - 1
+			}
+			counts[bin]++
+		}
+	}
+	var h protoBuffer
+	h.double(histoMin, min)
+	h.double(histoMax, max)
+	h.double(histoNum, num)
+	h.double(histoSum, sum)
+	h.double(histoSumSquares, sumSquares)
+	h.packedDoubles(histoBucketLimit, limits)
+	h.packedDoubles(histoBucket, counts)
+	return h
+}
+
+// Image - writes image encoded as PNG
+func (w *Writer) Image(tag string, step int, img image.Image) error {
+	var encoded bytes.Buffer
+	err := png.Encode(&encoded, img)
+	if err != nil {
+		return err
+	}
+	// Colorspace: 1 - grayscale, 3 - RGB, 4 - RGBA
+	colorspace := 4
+	if _, ok := img.(*image.Gray); ok {
+		colorspace = 1
+	} else if opaque, ok := img.(interface{ Opaque() bool }); ok && opaque.Opaque() {
+		colorspace = 3
+	}
+	var i protoBuffer
+	i.int64(imageHeight, int64(img.Bounds().Dy()))
+	i.int64(imageWidth, int64(img.Bounds().Dx()))
+	i.int64(imageColorspace, int64(colorspace))
+	i.bytes(imageEncoded, encoded.Bytes())
+	var v protoBuffer
+	v.string(valueTag, tag)
+	v.bytes(valueImage, i)
+	return w.writeValue(step, v)
+}
+
+// Flush - writes buffered events to underlying writer
+func (w *Writer) Flush() error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.buf.Flush()
+}
+
+// Close - flushes events and closes event file (if writer was created by NewWriter)
+func (w *Writer) Close() error {
+	err := w.Flush()
+	if w.file == nil {
+		return err
+	}
+	closeErr := w.file.Close()
+	if err != nil {
+		return err
+	}
+	return closeErr
+}
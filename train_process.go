@@ -3,102 +3,346 @@ package cnns
 import (
 	"errors"
 	"fmt"
-	"log"
 	"math/rand"
 	"time"
 
+	"github.com/LdDl/cnns/metrics"
 	"github.com/LdDl/cnns/tensor"
 )
 
-// Train Train neural network
+// TrainConfigOf - parameters of training process
+/*
+	Epochs - number of epochs;
+	BatchSize - number of samples per weights update (mini-batch gradient descent). 1 (online learning) if not set;
+	Shuffle - shuffle order of samples every epoch. Caller's slices are never modified;
+	Workers, Prefetch - number of goroutines loading mini-batches and number of mini-batches loaded ahead. See DataLoaderOf;
+	Seed - seed for shuffling. Order of samples for epoch e depends on Seed and e only, so runs are reproducible;
+	Loss - loss function. LossMSE if not set;
+	Optimizer - learning rate, momentum and weight decay for this training. Parameters set via SetEta/SetMomentum are used if not set;
+	Scheduler - learning rate schedule (optional). Learning rate is reported to callbacks as "lr" metric. See Scheduler;
+	ValidationInputs, ValidationTargets - data for evaluating "val_loss" after every epoch (optional);
+	ValidationData - dataset for validation (optional, used instead of ValidationInputs and ValidationTargets);
+	ValidationMetrics - metrics evaluated on validation data after every epoch and reported as "val_<name>" (optional). See package metrics;
+	Callbacks - listeners of training events. Train prints nothing itself, use ProgressLogger to see progress;
+	Pruning - gradual pruning schedule (optional). See PruningSchedule;
+	EarlyStopping - stop training when monitored metric has stopped improving (optional). See EarlyStopping;
+	Checkpoint - periodic saving of training state for resuming (optional). See CheckpointConfig and WholeNetOf.Resume.
+*/
+type TrainConfigOf[T tensor.Float] struct {
+	Epochs            int
+	BatchSize         int
+	Shuffle           bool
+	Seed              int64
+	Workers           int
+	Prefetch          int
+	Loss              Loss
+	Optimizer         *LearningParams
+	Scheduler         Scheduler
+	ValidationInputs  []*tensor.TensorOf[T]
+	ValidationTargets []*tensor.TensorOf[T]
+	ValidationData    DatasetOf[T]
+	ValidationMetrics []metrics.Metric
+	Callbacks         []Callback
+	Pruning           *PruningSchedule
+	EarlyStopping     *EarlyStopping
+	Checkpoint        *CheckpointConfig
+}
+
+// TrainConfig - training parameters for net of float64 precision
+type TrainConfig = TrainConfigOf[float64]
+
+// batchLayer - layer which supports mini-batch gradient descent: gradients of samples are summed up and applied once per batch
+type batchLayer interface {
+	accumulateGradients()
+	applyGradients(batchSize int)
+}
+
+// Train - train neural network
 /*
 	inputs - input data for training
 	desired - target outputs for input
+	cfg - parameters of training process. See TrainConfigOf
 
-	testData - input data for doing tests
-	testDesired - target outputs for testing
-
-	epochsNum - number of epochs
+	Returns mean loss on training data over last epoch and mean loss on validation data after last epoch (zero if validation data is not provided).
+	If early stopping restores best weights, returned losses are still ones of last epoch: see EarlyStopping.Best for best value.
+	Layers which do not support mini-batches (custom ones) are updated after every sample.
 */
-func (n *WholeNet) Train(inputs []*tensor.Tensor, desired []*tensor.Tensor, testData []*tensor.Tensor, testDesired []*tensor.Tensor, epochsNum int) (float64, float64, error) {
-	var err error
-	trainError := 0.0
-	testError := 0.0
+func (n *WholeNetOf[T]) Train(inputs []*tensor.TensorOf[T], desired []*tensor.TensorOf[T], cfg TrainConfigOf[T]) (float64, float64, error) {
+	dataset, err := NewInMemoryDataset(inputs, desired)
+	if err != nil {
+		return 0.0, 0.0, err
+	}
+	return n.TrainOn(dataset, cfg)
+}
 
-	if len(inputs) != len(desired) {
-		return trainError, testError, errors.New("number of inputs not equal to number of desired")
+// TrainOn - train neural network on dataset. Samples are loaded by DataLoaderOf, so dataset does not have to fit in memory. See Train
+func (n *WholeNetOf[T]) TrainOn(dataset DatasetOf[T], cfg TrainConfigOf[T]) (float64, float64, error) {
+	if cfg.EarlyStopping != nil {
+		cfg.EarlyStopping.reset()
+	}
+	if r, ok := cfg.Scheduler.(resettable); ok {
+		r.reset()
 	}
+	return n.train(dataset, cfg, &trainState[T]{})
+}
 
-	if len(testData) != len(testDesired) {
-		return trainError, testError, errors.New("number of inputs for test not equal to number of desired for test")
+// train - training loop starting from given state. See Train and Resume
+func (n *WholeNetOf[T]) train(dataset DatasetOf[T], cfg TrainConfigOf[T], state *trainState[T]) (float64, float64, error) {
+	trainLoss := 0.0
+	valLoss := 0.0
+	if len(state.history) != 0 {
+		last := state.history[len(state.history)-1].Metrics
+		trainLoss, valLoss = last["loss"], last["val_loss"]
 	}
 
-	// Initial shuffling of input data
-	// rand.Seed(time.Now().UTC().UnixNano())
-	for i := range inputs {
-		j := rand.Intn(i + 1)
-		inputs[i], inputs[j] = inputs[j], inputs[i]
-		desired[i], desired[j] = desired[j], desired[i]
+	if len(n.Layers) == 0 {
+		return trainLoss, valLoss, errors.New("network has no layers")
+	}
+	numSamples := dataset.Len()
+	if numSamples == 0 {
+		return trainLoss, valLoss, errors.New("no training data")
+	}
+	validation := cfg.ValidationData
+	if validation == nil && len(cfg.ValidationInputs) != 0 {
+		var err error
+		validation, err = NewInMemoryDataset(cfg.ValidationInputs, cfg.ValidationTargets)
+		if err != nil {
+			return trainLoss, valLoss, errors.New("number of inputs for validation not equal to number of desired for validation")
+		}
+	}
+
+	batchSize := cfg.BatchSize
+	if batchSize < 1 {
+		batchSize = 1
+	}
+	if batchSize > numSamples {
+		batchSize = numSamples
+	}
+	loader := &DataLoaderOf[T]{
+		Dataset:   dataset,
+		BatchSize: batchSize,
+		Shuffle:   cfg.Shuffle,
+		Seed:      cfg.Seed,
+		Workers:   cfg.Workers,
+		Prefetch:  cfg.Prefetch,
+	}
+	loss := cfg.Loss
+	if loss == nil {
+		loss = LossMSE{}
+	}
+	saved := lp
+	defer func() {
+		lp = saved
+	}()
+	if cfg.Optimizer != nil {
+		lp = *cfg.Optimizer
+	}
+	state.params = lp
+	if state.learningRate > 0 {
+		lp.LearningRate = state.learningRate
+	}
+
+	batchLayers := make([]batchLayer, len(n.Layers))
+	if batchSize > 1 {
+		for i := range n.Layers {
+			if bl, ok := n.Layers[i].(batchLayer); ok {
+				batchLayers[i] = bl
+			}
+		}
+	}
+
+	numBatches := loader.NumBatches()
+	if state.batch > numBatches {
+		return trainLoss, valLoss, fmt.Errorf("State has %d batches done, but epoch contains %d batches only", state.batch, numBatches)
+	}
+	event := &TrainEvent{
+		Epochs:  cfg.Epochs,
+		Batches: numBatches,
+		Step:    state.step,
+		Params:  state.params,
 	}
 
 	start := time.Now()
-	for e := 0; e < epochsNum; e++ {
-		// Shuffle training data every epoch
-		for i := range inputs {
-			j := rand.Intn(i + 1)
-			inputs[i], inputs[j] = inputs[j], inputs[i]
-			desired[i], desired[j] = desired[j], desired[i]
+	for e := state.epoch; e < cfg.Epochs; e++ {
+		startBatch := state.batch
+		event.Epoch = e
+		event.Batch = 0
+		event.Metrics = map[string]float64{}
+		event.Elapsed = 0
+		for _, cb := range cfg.Callbacks {
+			cb.OnEpochStart(event)
+		}
+
+		if cfg.Pruning != nil && startBatch == 0 {
+			if sparsity, ok := cfg.Pruning.SparsityAt(e); ok {
+				err := n.Prune(sparsity, cfg.Pruning.Scope)
+				if err != nil {
+					return trainLoss, valLoss, err
+				}
+			}
 		}
 
+		it := loader.Iterate(e, startBatch)
 		st := time.Now()
-		for i := range inputs {
-			in := inputs[i]
-			target := desired[i]
-			n.FeedForward(in)
-			err := n.Backpropagate(target)
+		for {
+			bst := time.Now()
+			batch, ok := it.Next()
+			if !ok {
+				break
+			}
+			if cfg.Scheduler != nil {
+				lp.LearningRate = cfg.Scheduler.LearningRate(state.params.LearningRate, e, state.step)
+				state.learningRate = lp.LearningRate
+			}
+			batchLoss, err := n.trainBatch(batch, loss, batchLayers)
+			if err != nil {
+				it.Close()
+				return trainLoss, valLoss, err
+			}
+			state.epochLoss += batchLoss
+			event.Step++
+			state.step = event.Step
+			state.batch = batch.Index + 1
+			event.Batch = batch.Index
+			event.Metrics = map[string]float64{"loss": batchLoss / float64(len(batch.Inputs)), "lr": lp.LearningRate}
+			event.Elapsed = time.Since(bst)
+			for _, cb := range cfg.Callbacks {
+				cb.OnBatchEnd(event)
+			}
+			if cfg.Checkpoint != nil && cfg.Checkpoint.EveryBatches > 0 && state.step%cfg.Checkpoint.EveryBatches == 0 {
+				err := n.saveCheckpoint(state, &cfg)
+				if err != nil {
+					it.Close()
+					return trainLoss, valLoss, err
+				}
+			}
+		}
+		it.Close()
+		if it.Err() != nil {
+			return trainLoss, valLoss, it.Err()
+		}
+		trainLoss = state.epochLoss / float64(numSamples)
+
+		epochMetrics := map[string]float64{"loss": trainLoss, "lr": lp.LearningRate}
+		if validation != nil && validation.Len() != 0 {
+			valMetrics, err := n.evaluate(validation, loss, cfg.ValidationMetrics)
+			if err != nil {
+				return trainLoss, valLoss, err
+			}
+			valLoss = valMetrics["loss"]
+			for name, value := range valMetrics {
+				epochMetrics["val_"+name] = value
+			}
+		}
+		event.Metrics = epochMetrics
+		event.Elapsed = time.Since(st)
+		if cfg.EarlyStopping != nil {
+			improved, stop, err := cfg.EarlyStopping.update(e, epochMetrics)
+			if err != nil {
+				return trainLoss, valLoss, err
+			}
+			if improved && cfg.EarlyStopping.RestoreBest {
+				state.bestWeights = n.snapshotWeights(state.bestWeights)
+			}
+			event.StopTraining = event.StopTraining || stop
+		}
+		if observer, ok := cfg.Scheduler.(MetricObserver); ok {
+			err := observer.ObserveMetrics(e, epochMetrics)
+			if err != nil {
+				return trainLoss, valLoss, err
+			}
+		}
+		event.EpochsDone++
+		for _, cb := range cfg.Callbacks {
+			cb.OnEpochEnd(event)
+		}
+		state.history = append(state.history, EpochLog{
+			Epoch:    e,
+			Duration: event.Elapsed,
+			Metrics:  epochMetrics,
+		})
+		state.epoch = e + 1
+		state.batch = 0
+		state.epochLoss = 0
+		if cfg.Checkpoint != nil && cfg.Checkpoint.EveryEpochs > 0 && state.epoch%cfg.Checkpoint.EveryEpochs == 0 {
+			err := n.saveCheckpoint(state, &cfg)
 			if err != nil {
-				log.Printf("Backpropagate caused error: %s", err.Error())
-				return 0.0, 0.0, err
+				return trainLoss, valLoss, err
 			}
 		}
-		log.Printf("Epoch #%v done in %v", e, time.Since(st))
+		if event.StopTraining {
+			break
+		}
 	}
-	log.Printf("Training %v epochs done in %v", epochsNum, time.Since(start))
-
-	fmt.Println("Evaluating errors...")
-	for i := range inputs {
-		in := inputs[i]
-		target := desired[i]
-		n.FeedForward(in)
-		out := n.GetOutput()
-		loss := target.MSE(out)
-		trainError += loss
+	if state.bestWeights != nil {
+		err := n.restoreWeights(state.bestWeights)
+		if err != nil {
+			return trainLoss, valLoss, err
+		}
 	}
+	event.Elapsed = time.Since(start)
+	for _, cb := range cfg.Callbacks {
+		cb.OnTrainEnd(event)
+	}
+	return trainLoss, valLoss, nil
+}
 
-	for i := range testData {
-		in := testData[i]
-		// in.Print()
-		target := testDesired[i]
-		n.FeedForward(in)
-		out := n.GetOutput()
-		fmt.Println("\n>>>Out:")
-		out.Print()
-		fmt.Println(">>>Desired:")
-		target.Print()
-		loss := target.MSE(out)
-		testError += loss
+// trainBatch - forward and backward passes for every sample of batch and update of weights. Returns sum of samples' losses
+func (n *WholeNetOf[T]) trainBatch(batch *BatchOf[T], loss Loss, batchLayers []batchLayer) (float64, error) {
+	batchLoss := 0.0
+	batched := false
+	for i := range batch.Inputs {
+		n.FeedForward(batch.Inputs[i])
+		sl, err := sampleLoss(loss, n.GetOutput(), batch.Targets[i])
+		if err != nil {
+			return 0.0, err
+		}
+		batchLoss += sl
+		err = n.calculateGradients(batch.Targets[i], loss)
+		if err != nil {
+			return 0.0, err
+		}
+		for l := range n.Layers {
+			if batchLayers[l] != nil {
+				batchLayers[l].accumulateGradients()
+				batched = true
+				continue
+			}
+			n.Layers[l].UpdateWeights()
+		}
 	}
+	if batched {
+		for l := range batchLayers {
+			if batchLayers[l] != nil {
+				batchLayers[l].applyGradients(len(batch.Inputs))
+			}
+		}
+	}
+	return batchLoss, nil
+}
 
-	return trainError, testError, err
+// sampleLoss - mean of loss over output's elements
+func sampleLoss[T tensor.Float](loss Loss, output, target *tensor.TensorOf[T]) (float64, error) {
+	if !output.IsEqualDims(target) {
+		return 0.0, fmt.Errorf("Output size %v does not fit target size %v", output.Size, target.Size)
+	}
+	sum := 0.0
+	for i := range output.Data {
+		sum += loss.Value(float64(output.Data[i]), float64(target.Data[i]))
+	}
+	return sum / float64(len(output.Data)), nil
 }
 
-func maxIdx(tt *tensor.Tensor) (max int) {
-	maxF := 0.0
-	for i := range tt.Data {
-		if maxF < tt.Data[i] {
-			maxF = tt.Data[i]
-			max = i
-		}
+// epochOrder - fills order of samples for given epoch
+func epochOrder(order []int, shuffle bool, seed int64, epoch int) {
+	for i := range order {
+		order[i] = i
+	}
+	if !shuffle {
+		return
 	}
-	return max
+	rnd := rand.New(rand.NewSource(seed + int64(epoch)))
+	rnd.Shuffle(len(order), func(i, j int) {
+		order[i], order[j] = order[j], order[i]
+	})
 }
@@ -0,0 +1,211 @@
+package cnns
+
+import (
+	"bytes"
+	"math"
+	"math/rand"
+	"strings"
+	"testing"
+
+	"github.com/LdDl/cnns/metrics"
+	"github.com/LdDl/cnns/tensor"
+)
+
+func newXORNet(seed int64) *WholeNet {
+	rand.Seed(seed)
+	fullyconnected1 := NewFullyConnectedLayer(&tensor.TDsize{X: 2, Y: 1, Z: 1}, 4)
+	fullyconnected1.SetActivationFunc(ActivationTanh)
+	fullyconnected1.SetActivationDerivativeFunc(ActivationTanhDerivative)
+	fullyconnected2 := NewFullyConnectedLayer(fullyconnected1.GetOutputSize(), 1)
+	fullyconnected2.SetActivationFunc(ActivationTanh)
+	fullyconnected2.SetActivationDerivativeFunc(ActivationTanhDerivative)
+	net := &WholeNet{}
+	net.Layers = append(net.Layers, fullyconnected1, fullyconnected2)
+	return net
+}
+
+func xorData() ([]*tensor.Tensor, []*tensor.Tensor) {
+	inputs := []*tensor.Tensor{}
+	desired := []*tensor.Tensor{}
+	for _, sample := range [][3]float64{{0, 0, 0}, {0, 1, 1}, {1, 0, 1}, {1, 1, 0}} {
+		in := tensor.NewTensor(2, 1, 1)
+		in.SetData(2, 1, 1, []float64{sample[0], sample[1]})
+		out := tensor.NewTensor(1, 1, 1)
+		out.SetData(1, 1, 1, []float64{sample[2]})
+		inputs = append(inputs, in)
+		desired = append(desired, out)
+	}
+	return inputs, desired
+}
+
+func TestTrainOnline(t *testing.T) {
+	inputs, desired := xorData()
+	// Online training without shuffling should be equal to plain FeedForward + Backpropagate loop
+	manual := newXORNet(7)
+	for e := 0; e < 10; e++ {
+		for i := range inputs {
+			manual.FeedForward(inputs[i])
+			err := manual.Backpropagate(desired[i])
+			if err != nil {
+				t.Error(err)
+				return
+			}
+		}
+	}
+	net := newXORNet(7)
+	_, _, err := net.Train(inputs, desired, TrainConfig{Epochs: 10})
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	for l := range net.Layers {
+		expected := manual.Layers[l].(*FullyConnectedLayer).Weights.Data
+		got := net.Layers[l].(*FullyConnectedLayer).Weights.Data
+		for i := range expected {
+			if expected[i] != got[i] {
+				t.Errorf("Layer #%d: weights are not equal at pos #%d. Expected value: %f. Got: %f", l, i, expected[i], got[i])
+			}
+		}
+	}
+}
+
+func TestTrainMiniBatch(t *testing.T) {
+	inputs, desired := xorData()
+	first := inputs[0]
+	net := newXORNet(42)
+	history := NewHistory()
+	csvBuf := &bytes.Buffer{}
+	epochs := 500
+	trainLoss, valLoss, err := net.Train(inputs, desired, TrainConfig{
+		Epochs:            epochs,
+		BatchSize:         2,
+		Shuffle:           true,
+		Seed:              1,
+		Optimizer:         &LearningParams{LearningRate: 0.1, Momentum: 0.6},
+		ValidationInputs:  inputs,
+		ValidationTargets: desired,
+		ValidationMetrics: []metrics.Metric{metrics.Accuracy{}},
+		Callbacks:         []Callback{history, NewCSVLogger(csvBuf)},
+	})
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	if inputs[0] != first {
+		t.Errorf("Training data should not be reordered")
+	}
+	if lp.LearningRate != 0.01 {
+		t.Errorf("Learning rate should be restored after training. Expected value: %f. Got: %f", 0.01, lp.LearningRate)
+	}
+	if len(history.Epochs) != epochs {
+		t.Errorf("History should contain %d epochs, but got %d", epochs, len(history.Epochs))
+		return
+	}
+	losses := history.Metric("loss")
+	if losses[len(losses)-1] >= losses[0] {
+		t.Errorf("Loss should decrease. First epoch: %f. Last epoch: %f", losses[0], losses[len(losses)-1])
+	}
+	if trainLoss != losses[len(losses)-1] {
+		t.Errorf("Returned loss should be equal to loss of last epoch. Expected value: %f. Got: %f", losses[len(losses)-1], trainLoss)
+	}
+	valLosses := history.Metric("val_loss")
+	if math.IsNaN(valLosses[0]) || valLoss != valLosses[len(valLosses)-1] {
+		t.Errorf("Returned validation loss should be equal to val_loss of last epoch. Expected value: %f. Got: %f", valLosses[len(valLosses)-1], valLoss)
+	}
+	if math.IsNaN(history.Metric("val_accuracy")[0]) {
+		t.Errorf("Validation metrics should be reported")
+	}
+	lines := strings.Split(strings.TrimSpace(csvBuf.String()), "\n")
+	if lines[0] != "epoch,duration,loss,lr,val_accuracy,val_loss" {
+		t.Errorf("Wrong CSV header: %s", lines[0])
+	}
+	if len(lines) != epochs+1 {
+		t.Errorf("CSV should contain %d rows, but got %d", epochs+1, len(lines))
+	}
+}
+
+// stopAfterBatch - requests stop of training on mini-batch of given epoch
+type stopAfterBatch struct {
+	BaseCallback
+	epoch int
+}
+
+func (sb *stopAfterBatch) OnBatchEnd(event *TrainEvent) {
+	if event.Epoch == sb.epoch {
+		event.StopTraining = true
+	}
+}
+
+func TestTrainEarlyStopping(t *testing.T) {
+	inputs, desired := xorData()
+	// Nothing is improvement with such MinDelta, so training should be stopped after Patience epochs and weights of first epoch should be restored
+	net := newXORNet(3)
+	history := NewHistory()
+	es := &EarlyStopping{
+		Monitor:     "loss",
+		Patience:    2,
+		MinDelta:    1e9,
+		RestoreBest: true,
+	}
+	_, _, err := net.Train(inputs, desired, TrainConfig{
+		Epochs:        100,
+		EarlyStopping: es,
+		Callbacks:     []Callback{history},
+	})
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	if len(history.Epochs) != 3 {
+		t.Errorf("Training should be stopped after %d epochs, but got %d", 3, len(history.Epochs))
+	}
+	if es.BestEpoch != 0 || es.StoppedEpoch != 2 {
+		t.Errorf("Best epoch should be %d and stopped epoch should be %d, but got %d and %d", 0, 2, es.BestEpoch, es.StoppedEpoch)
+	}
+	single := newXORNet(3)
+	_, _, err = single.Train(inputs, desired, TrainConfig{Epochs: 1})
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	for l := range net.Layers {
+		expected := single.Layers[l].(*FullyConnectedLayer).Weights.Data
+		got := net.Layers[l].(*FullyConnectedLayer).Weights.Data
+		for i := range expected {
+			if expected[i] != got[i] {
+				t.Errorf("Layer #%d: weights should be restored at pos #%d. Expected value: %f. Got: %f", l, i, expected[i], got[i])
+			}
+		}
+	}
+
+	// Stop requested by callback should not be cancelled by early stopping
+	history = NewHistory()
+	logBuf := &bytes.Buffer{}
+	_, _, err = newXORNet(3).Train(inputs, desired, TrainConfig{
+		Epochs:        100,
+		EarlyStopping: &EarlyStopping{Monitor: "loss", Patience: 100},
+		Callbacks:     []Callback{history, &stopAfterBatch{epoch: 1}, NewProgressLogger(logBuf)},
+	})
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	if len(history.Epochs) != 2 {
+		t.Errorf("Training should be stopped after %d epochs, but got %d", 2, len(history.Epochs))
+	}
+	// Number of epochs is reported even if there are none
+	_, _, err = newXORNet(3).Train(inputs, desired, TrainConfig{Epochs: 0, Callbacks: []Callback{NewProgressLogger(logBuf)}})
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	if !strings.Contains(logBuf.String(), "Training 2 epochs done") || !strings.Contains(logBuf.String(), "Training 0 epochs done") {
+		t.Errorf("Numbers of done epochs are wrong:\n%s", logBuf.String())
+	}
+
+	// Monitored metric should exist
+	_, _, err = newXORNet(3).Train(inputs, desired, TrainConfig{Epochs: 1, EarlyStopping: &EarlyStopping{}})
+	if err == nil {
+		t.Errorf("Training should fail when val_loss is monitored without validation data")
+	}
+}
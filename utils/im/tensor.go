@@ -0,0 +1,215 @@
+package im
+
+import (
+	"errors"
+	"fmt"
+	"image"
+	"image/color"
+	"math"
+
+	"github.com/LdDl/cnns/tensor"
+)
+
+// ColorMode - channels of image tensor
+type ColorMode int
+
+const (
+	// Grayscale - single channel (luminance: 0.299*R + 0.587*G + 0.114*B)
+	Grayscale = ColorMode(iota)
+	// RGB - three channels (Z = 0 is red, Z = 1 is green, Z = 2 is blue)
+	RGB
+	// RGBA - four channels (RGB and alpha as Z = 3)
+	RGBA
+)
+
+// Channels - returns number of channels for color mode
+func (cm ColorMode) Channels() int {
+	switch cm {
+	case RGB:
+		return 3
+	case RGBA:
+		return 4
+	default:
+		return 1
+	}
+}
+
+// String - name of color mode
+func (cm ColorMode) String() string {
+	switch cm {
+	case RGB:
+		return "rgb"
+	case RGBA:
+		return "rgba"
+	default:
+		return "grayscale"
+	}
+}
+
+// ToTensor - converts image to tensor (X - width, Y - height, Z - channels of color mode) with values in [0, 1]. Colors are not premultiplied by alpha
+func ToTensor(img image.Image, mode ColorMode) *tensor.Tensor {
+	bounds := img.Bounds()
+	width, height := bounds.Dx(), bounds.Dy()
+	ret := tensor.NewTensor(width, height, mode.Channels())
+	for y := 0; y < height; y++ {
+		for x := 0; x < width; x++ {
+			r, g, b, a := nonPremultiplied(img.At(bounds.Min.X+x, bounds.Min.Y+y))
+			switch mode {
+			case RGB, RGBA:
+				ret.Set(x, y, 0, r)
+				ret.Set(x, y, 1, g)
+				ret.Set(x, y, 2, b)
+				if mode == RGBA {
+					ret.Set(x, y, 3, a)
+				}
+			default:
+				ret.Set(x, y, 0, 0.299*r+0.587*g+0.114*b)
+			}
+		}
+	}
+	return ret
+}
+
+// nonPremultiplied - returns color components (not premultiplied by alpha) in [0, 1]
+func nonPremultiplied(c color.Color) (float64, float64, float64, float64) {
+	switch c := c.(type) {
+	case color.NRGBA:
+		return float64(c.R) / 0xff, float64(c.G) / 0xff, float64(c.B) / 0xff, float64(c.A) / 0xff
+	case color.NRGBA64:
+		return float64(c.R) / 0xffff, float64(c.G) / 0xffff, float64(c.B) / 0xffff, float64(c.A) / 0xffff
+	}
+	r, g, b, a := c.RGBA()
+	if a == 0 {
+		return 0, 0, 0, 0
+	}
+	fa := float64(a)
+	return float64(r) / fa, float64(g) / fa, float64(b) / fa, fa / 0xffff
+}
+
+// ToTensorNormalized - converts image to tensor (see ToTensor) and applies per-channel normalization (see Normalize)
+func ToTensorNormalized(img image.Image, mode ColorMode, mean, std []float64) (*tensor.Tensor, error) {
+	ret := ToTensor(img, mode)
+	err := Normalize(ret, mean, std)
+	if err != nil {
+		return nil, err
+	}
+	return ret, nil
+}
+
+// checkNormalization - checks sizes of mean and std
+func checkNormalization(channels int, mean, std []float64) error {
+	if (len(mean) != 0 && len(mean) != channels) || (len(std) != 0 && len(std) != channels) {
+		return fmt.Errorf("Mean and Std should contain %d values", channels)
+	}
+	for _, s := range std {
+		if s == 0 {
+			return errors.New("Std can not contain zero values")
+		}
+	}
+	return nil
+}
+
+// Normalize - per-channel (x - mean) / std in place. Mean (zeros if empty) and std (ones if empty) should contain value for every channel
+func Normalize(t *tensor.Tensor, mean, std []float64) error {
+	err := checkNormalization(t.Size.Z, mean, std)
+	if err != nil {
+		return err
+	}
+	plane := t.Size.X * t.Size.Y
+	for z := 0; z < t.Size.Z; z++ {
+		m, s := 0.0, 1.0
+		if len(mean) != 0 {
+			m = mean[z]
+		}
+		if len(std) != 0 {
+			s = std[z]
+		}
+		for i := z * plane; i < (z+1)*plane; i++ {
+			t.Data[i] = (t.Data[i] - m) / s
+		}
+	}
+	return nil
+}
+
+// Denormalize - inverse of Normalize: x * std + mean in place
+func Denormalize(t *tensor.Tensor, mean, std []float64) error {
+	err := checkNormalization(t.Size.Z, mean, std)
+	if err != nil {
+		return err
+	}
+	plane := t.Size.X * t.Size.Y
+	for z := 0; z < t.Size.Z; z++ {
+		m, s := 0.0, 1.0
+		if len(mean) != 0 {
+			m = mean[z]
+		}
+		if len(std) != 0 {
+			s = std[z]
+		}
+		for i := z * plane; i < (z+1)*plane; i++ {
+			t.Data[i] = t.Data[i]*s + m
+		}
+	}
+	return nil
+}
+
+// toUint8 - converts value in [0, 1] to byte (values outside of range are clipped)
+func toUint8(v float64) uint8 {
+	return uint8(math.Round(math.Min(math.Max(v, 0), 1) * 255))
+}
+
+// FromTensor - converts tensor with values in [0, 1] to image. Tensor should have 1 (*image.Gray), 3 or 4 (*image.NRGBA) channels. Values outside of [0, 1] are clipped
+func FromTensor(t *tensor.Tensor) (image.Image, error) {
+	width, height := t.Size.X, t.Size.Y
+	switch t.Size.Z {
+	case 1:
+		ret := image.NewGray(image.Rect(0, 0, width, height))
+		for y := 0; y < height; y++ {
+			for x := 0; x < width; x++ {
+				ret.SetGray(x, y, color.Gray{Y: toUint8(t.Get(x, y, 0))})
+			}
+		}
+		return ret, nil
+	case 3, 4:
+		ret := image.NewNRGBA(image.Rect(0, 0, width, height))
+		for y := 0; y < height; y++ {
+			for x := 0; x < width; x++ {
+				c := color.NRGBA{
+					R: toUint8(t.Get(x, y, 0)),
+					G: toUint8(t.Get(x, y, 1)),
+					B: toUint8(t.Get(x, y, 2)),
+					A: 255,
+				}
+				if t.Size.Z == 4 {
+					c.A = toUint8(t.Get(x, y, 3))
+				}
+				ret.SetNRGBA(x, y, c)
+			}
+		}
+		return ret, nil
+	default:
+		return nil, fmt.Errorf("Tensor should have 1, 3 or 4 channels, but has %d", t.Size.Z)
+	}
+}
+
+// ChannelToGray - converts z-th channel of tensor (e.g. feature map) to grayscale image. Values are scaled so minimum is black and maximum is white
+func ChannelToGray(t *tensor.Tensor, z int) (*image.Gray, error) {
+	if z < 0 || z >= t.Size.Z {
+		return nil, fmt.Errorf("Channel %d is out of range [0, %d)", z, t.Size.Z)
+	}
+	plane := t.Data[z*t.Size.X*t.Size.Y : (z+1)*t.Size.X*t.Size.Y]
+	min, max := math.Inf(1), math.Inf(-1)
+	for _, v := range plane {
+		min = math.Min(min, v)
+		max = math.Max(max, v)
+	}
+	ret := image.NewGray(image.Rect(0, 0, t.Size.X, t.Size.Y))
+	for i, v := range plane {
+		scaled := 0.0
+		if max > min {
+			scaled = (v - min) / (max - min)
+		}
+		ret.Pix[i] = toUint8(scaled)
+	}
+	return ret, nil
+}
@@ -0,0 +1,66 @@
+package im
+
+import (
+	"image"
+	"image/color"
+	"math"
+	"testing"
+)
+
+func TestTensorConversion(t *testing.T) {
+	img := image.NewNRGBA(image.Rect(0, 0, 3, 2))
+	img.SetNRGBA(0, 0, color.NRGBA{R: 255, G: 0, B: 0, A: 255})
+	img.SetNRGBA(2, 1, color.NRGBA{R: 0, G: 51, B: 255, A: 102})
+
+	rgba := ToTensor(img, RGBA)
+	if rgba.Size.X != 3 || rgba.Size.Y != 2 || rgba.Size.Z != 4 {
+		t.Errorf("Tensor size should be 3x2x4, but got %dx%dx%d", rgba.Size.X, rgba.Size.Y, rgba.Size.Z)
+		return
+	}
+	if rgba.Get(0, 0, 0) != 1.0 || rgba.Get(2, 1, 1) != 0.2 || rgba.Get(2, 1, 3) != 0.4 {
+		t.Errorf("Wrong values: %f %f %f", rgba.Get(0, 0, 0), rgba.Get(2, 1, 1), rgba.Get(2, 1, 3))
+	}
+	gray := ToTensor(img, Grayscale)
+	if math.Abs(gray.Get(0, 0, 0)-0.299) > 1e-9 {
+		t.Errorf("Luminance is wrong. Expected value: %f. Got: %f", 0.299, gray.Get(0, 0, 0))
+	}
+
+	back, err := FromTensor(rgba)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	for _, p := range []image.Point{{0, 0}, {2, 1}, {1, 1}} {
+		if back.At(p.X, p.Y) != img.At(p.X, p.Y) {
+			t.Errorf("Pixel %v after round trip is wrong. Expected value: %v. Got: %v", p, img.At(p.X, p.Y), back.At(p.X, p.Y))
+		}
+	}
+
+	rgb, err := ToTensorNormalized(img, RGB, []float64{0.5, 0.5, 0.5}, []float64{0.25, 0.5, 1})
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	if rgb.Get(0, 0, 0) != 2.0 || rgb.Get(0, 0, 1) != -1.0 {
+		t.Errorf("Normalized values are wrong: %f %f", rgb.Get(0, 0, 0), rgb.Get(0, 0, 1))
+	}
+	if err = Denormalize(rgb, []float64{0.5, 0.5, 0.5}, []float64{0.25, 0.5, 1}); err != nil {
+		t.Error(err)
+		return
+	}
+	if rgb.Get(0, 0, 0) != 1.0 || rgb.Get(0, 0, 1) != 0.0 {
+		t.Errorf("Denormalized values are wrong: %f %f", rgb.Get(0, 0, 0), rgb.Get(0, 0, 1))
+	}
+	if _, err = ToTensorNormalized(img, RGB, []float64{0.5}, nil); err == nil {
+		t.Errorf("Mean of wrong size should produce error")
+	}
+
+	feature, err := ChannelToGray(rgba, 2)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	if feature.GrayAt(2, 1).Y != 255 || feature.GrayAt(0, 0).Y != 0 {
+		t.Errorf("Feature map should be scaled to [0, 255]")
+	}
+}
@@ -2,14 +2,19 @@ package u
 
 import (
 	"errors"
+	"fmt"
 	"image"
-	"image/jpeg"
-	"image/png"
 	"math"
 	"math/rand"
 	"os"
 
-	"golang.org/x/image/bmp"
+	// Decoders are registered for image.Decode
+	_ "image/gif"
+	_ "image/jpeg"
+	_ "image/png"
+
+	_ "golang.org/x/image/bmp"
+	_ "golang.org/x/image/tiff"
 )
 
 // AndINT - Logical AND for two inputs of type int.
@@ -95,52 +100,24 @@ func NormalizeRange(f float64, max int, limitMin bool) int {
 	return int(math.Floor(f))
 }
 
-//ReadImage - wrapper function for reading png/jpeg/jpg or bmp images
+// ReadImage - reads png, jpeg, gif, bmp or tiff image
 /*
-	It can determine type of image and use proper decoder for it
+	Type of image is determined by its content (not by extension of file)
 */
 func ReadImage(fname string) (image.Image, error) {
-	var err error
-
 	reader, err := os.Open(fname)
 	if err != nil {
 		return nil, err
 	}
-
-	var im image.Image
-	var imType string
-
-	// Try to decode
-	_, imType, err = image.Decode(reader)
+	defer reader.Close()
+	img, _, err := image.Decode(reader)
 	if err != nil {
-		// NOTHING
-		// NEED TO DO SEEK(0,0) and use proper decoder
-	}
-
-	reader.Seek(0, 0)
-	switch imType {
-	case "png":
-		im, err = png.Decode(reader)
-		if err != nil {
-			return nil, err
-		}
-	case "jpeg", "jpg":
-		im, err = jpeg.Decode(reader)
-		if err != nil {
-			return nil, err
-		}
-	case "bmp":
-		im, err = bmp.Decode(reader)
-		if err != nil {
-			return nil, err
+		if errors.Is(err, image.ErrFormat) {
+			return nil, fmt.Errorf("Can't decode '%s': %w. Please use only png/jpeg/gif/bmp or tiff", fname, err)
 		}
-	default:
-		return nil, errors.New(err.Error() + ": Please use only png/jpeg/jpg or bmp")
+		return nil, fmt.Errorf("Can't decode '%s': %w", fname, err)
 	}
-
-	reader.Close()
-
-	return im, nil
+	return img, nil
 }
 
 // Round Round float64 to 0 decimal places
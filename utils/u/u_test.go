@@ -0,0 +1,62 @@
+package u
+
+import (
+	"image"
+	"image/color"
+	"image/gif"
+	"image/png"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"golang.org/x/image/tiff"
+)
+
+func TestReadImage(t *testing.T) {
+	dir := t.TempDir()
+	img := image.NewGray(image.Rect(0, 0, 4, 3))
+	img.SetGray(1, 2, color.Gray{Y: 255})
+	encoders := map[string]func(w io.Writer, m image.Image) error{
+		"image.png": png.Encode,
+		"image.gif": func(w io.Writer, m image.Image) error {
+			return gif.Encode(w, m, nil)
+		},
+		// Extension is not used to determine format
+		"image.dat": func(w io.Writer, m image.Image) error {
+			return tiff.Encode(w, m, nil)
+		},
+	}
+	for name, encode := range encoders {
+		fname := filepath.Join(dir, name)
+		f, err := os.Create(fname)
+		if err != nil {
+			t.Fatal(err)
+		}
+		err = encode(f, img)
+		f.Close()
+		if err != nil {
+			t.Fatal(err)
+		}
+		decoded, err := ReadImage(fname)
+		if err != nil {
+			t.Errorf("Can't read %s: %s", name, err)
+			continue
+		}
+		if decoded.Bounds().Dx() != 4 || decoded.Bounds().Dy() != 3 {
+			t.Errorf("Size of %s is wrong: %v", name, decoded.Bounds())
+		}
+		r, _, _, _ := decoded.At(1, 2).RGBA()
+		if r>>8 != 255 {
+			t.Errorf("Pixel of %s is wrong. Expected value: %d. Got: %d", name, 255, r>>8)
+		}
+	}
+
+	fname := filepath.Join(dir, "broken.png")
+	if err := os.WriteFile(fname, []byte("not an image"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := ReadImage(fname); err == nil {
+		t.Errorf("Broken image should produce error")
+	}
+}
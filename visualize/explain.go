@@ -0,0 +1,195 @@
+package visualize
+
+import (
+	"fmt"
+	"image"
+	"image/color"
+	"math"
+
+	"github.com/LdDl/cnns"
+	"github.com/LdDl/cnns/tensor"
+	"github.com/LdDl/cnns/utils/im"
+)
+
+// classGradient - feeds input to the net and backpropagates gradient of output of chosen class (without updating weights)
+func classGradient[T tensor.Float](net *cnns.WholeNetOf[T], input *tensor.TensorOf[T], class int) (*tensor.TensorOf[T], error) {
+	if len(net.Layers) == 0 {
+		return nil, fmt.Errorf("network has no layers")
+	}
+	net.FeedForward(input)
+	output := net.GetOutput()
+	if class < 0 || class >= len(output.Data) {
+		return nil, fmt.Errorf("Class %d is out of range [0, %d)", class, len(output.Data))
+	}
+	grad := tensor.NewTensorOf[T](output.Size.X, output.Size.Y, output.Size.Z)
+	grad.Data[class] = 1
+	return net.BackpropagateGradient(grad)
+}
+
+// InputGradient - returns gradient of output of chosen class with respect to input (same size as input). Weights are not changed
+func InputGradient[T tensor.Float](net *cnns.WholeNetOf[T], input *tensor.TensorOf[T], class int) (*tensor.TensorOf[T], error) {
+	grad, err := classGradient(net, input, class)
+	if err != nil {
+		return nil, err
+	}
+	ret := tensor.NewTensorOf[T](grad.Size.X, grad.Size.Y, grad.Size.Z)
+	copy(ret.Data, grad.Data)
+	return ret, nil
+}
+
+// Saliency - returns saliency map (X x Y x 1) of chosen class: maximum over channels of absolute value of input gradient, scaled to [0, 1]. See ref. https://arxiv.org/abs/1312.6034
+func Saliency[T tensor.Float](net *cnns.WholeNetOf[T], input *tensor.TensorOf[T], class int) (*tensor.TensorOf[T], error) {
+	grad, err := classGradient(net, input, class)
+	if err != nil {
+		return nil, err
+	}
+	ret := tensor.NewTensorOf[T](grad.Size.X, grad.Size.Y, 1)
+	for z := 0; z < grad.Size.Z; z++ {
+		for y := 0; y < grad.Size.Y; y++ {
+			for x := 0; x < grad.Size.X; x++ {
+				v := T(math.Abs(float64(grad.Get(x, y, z))))
+				if v > ret.Get(x, y, 0) {
+					ret.Set(x, y, 0, v)
+				}
+			}
+		}
+	}
+	scaleToUnit(ret)
+	return ret, nil
+}
+
+// GradCAM - returns Grad-CAM heatmap (size of layer's output X x Y x 1) of chosen class for convolutional layer with index layerIndex, scaled to [0, 1]. See ref. https://arxiv.org/abs/1610.02391
+/*
+	Weight of every feature map is mean of gradient of class output with respect to this feature map.
+	Heatmap is ReLU of weighted sum of feature maps.
+*/
+func GradCAM[T tensor.Float](net *cnns.WholeNetOf[T], input *tensor.TensorOf[T], class, layerIndex int) (*tensor.TensorOf[T], error) {
+	if layerIndex < 0 || layerIndex >= len(net.Layers) {
+		return nil, fmt.Errorf("Layer index %d is out of range [0, %d)", layerIndex, len(net.Layers))
+	}
+	if net.Layers[layerIndex].GetType() != "conv" {
+		return nil, fmt.Errorf("Layer #%d should be convolutional, but it is '%s'", layerIndex, net.Layers[layerIndex].GetType())
+	}
+	_, err := classGradient(net, input, class)
+	if err != nil {
+		return nil, err
+	}
+	activations := net.Layers[layerIndex].GetOutput()
+	var grad *tensor.TensorOf[T]
+	if layerIndex == len(net.Layers)-1 {
+		grad = tensor.NewTensorOf[T](activations.Size.X, activations.Size.Y, activations.Size.Z)
+		grad.Data[class] = 1
+	} else {
+		grad = net.Layers[layerIndex+1].GetGradients()
+	}
+	if !grad.IsEqualDims(activations) {
+		return nil, tensor.ErrDimensionsNotFit
+	}
+	plane := activations.Size.X * activations.Size.Y
+	ret := tensor.NewTensorOf[T](activations.Size.X, activations.Size.Y, 1)
+	for k := 0; k < activations.Size.Z; k++ {
+		weight := 0.0
+		for i := k * plane; i < (k+1)*plane; i++ {
+			weight += float64(grad.Data[i])
+		}
+		weight /= float64(plane)
+		for i := 0; i < plane; i++ {
+			ret.Data[i] += T(weight * float64(activations.Data[k*plane+i]))
+		}
+	}
+	for i := range ret.Data {
+		if ret.Data[i] < 0 {
+			ret.Data[i] = 0
+		}
+	}
+	scaleToUnit(ret)
+	return ret, nil
+}
+
+// scaleToUnit - divides values by maximum, so they are in [0, 1] (for non-negative values)
+func scaleToUnit[T tensor.Float](t *tensor.TensorOf[T]) {
+	var max T
+	for _, v := range t.Data {
+		if v > max {
+			max = v
+		}
+	}
+	if max == 0 {
+		return
+	}
+	for i := range t.Data {
+		t.Data[i] /= max
+	}
+}
+
+// heatColor - jet color map for value in [0, 1]: blue - cyan - yellow - red
+func heatColor(v float64) (float64, float64, float64) {
+	clamp := func(c float64) float64 {
+		return math.Min(math.Max(c, 0), 1)
+	}
+	return clamp(1.5 - math.Abs(4*v-3)), clamp(1.5 - math.Abs(4*v-2)), clamp(1.5 - math.Abs(4*v-1))
+}
+
+// Overlay - draws heatmap (values in [0, 1], e.g. result of Saliency or GradCAM) over input image. Heatmap is resized to size of input with bilinear interpolation.
+/*
+	input - image tensor with values in [0, 1] (1 or 3 channels are drawn as is, mean of channels is drawn otherwise);
+	alpha - opacity of heatmap in [0, 1].
+*/
+func Overlay[T tensor.Float](input, heatmap *tensor.TensorOf[T], alpha float64) (image.Image, error) {
+	base := tensor.Convert[float64](input)
+	if base.Size.Z != 1 && base.Size.Z != 3 {
+		gray := tensor.NewTensor(base.Size.X, base.Size.Y, 1)
+		for z := 0; z < base.Size.Z; z++ {
+			for y := 0; y < base.Size.Y; y++ {
+				for x := 0; x < base.Size.X; x++ {
+					gray.SetAdd(x, y, 0, base.Get(x, y, z)/float64(base.Size.Z))
+				}
+			}
+		}
+		base = gray
+	}
+	baseImg, err := im.FromTensor(base)
+	if err != nil {
+		return nil, err
+	}
+	heat := tensor.Convert[float64](heatmap)
+	width, height := base.Size.X, base.Size.Y
+	if heat.Size.X != width || heat.Size.Y != height {
+		sx := float64(heat.Size.X) / float64(width)
+		sy := float64(heat.Size.Y) / float64(height)
+		// Border points are extended, so heatmap does not fade out to edges
+		heat = heat.Crop2D(-1, -1, heat.Size.X+2, heat.Size.Y+2, 0)
+		for y := 0; y < heat.Size.Y; y++ {
+			heat.Set(0, y, 0, heat.Get(1, y, 0))
+			heat.Set(heat.Size.X-1, y, 0, heat.Get(heat.Size.X-2, y, 0))
+		}
+		for x := 0; x < heat.Size.X; x++ {
+			heat.Set(x, 0, 0, heat.Get(x, 1, 0))
+			heat.Set(x, heat.Size.Y-1, 0, heat.Get(x, heat.Size.Y-2, 0))
+		}
+		heat = heat.Warp2D(width, height, func(x, y int) (float64, float64) {
+			return (float64(x)+0.5)*sx + 0.5, (float64(y)+0.5)*sy + 0.5
+		}, 0)
+	}
+	ret := image.NewNRGBA(image.Rect(0, 0, width, height))
+	for y := 0; y < height; y++ {
+		for x := 0; x < width; x++ {
+			r, g, b, _ := baseImg.At(x, y).RGBA()
+			hr, hg, hb := heatColor(heat.Get(x, y, 0))
+			blend := func(c uint32, h float64) uint8 {
+				return uint8(math.Round(((1-alpha)*float64(c)/0xffff + alpha*h) * 255))
+			}
+			ret.SetNRGBA(x, y, color.NRGBA{R: blend(r, hr), G: blend(g, hg), B: blend(b, hb), A: 255})
+		}
+	}
+	return ret, nil
+}
+
+// SaveOverlay - draws heatmap over input image (see Overlay) and writes it to PNG file
+func SaveOverlay[T tensor.Float](input, heatmap *tensor.TensorOf[T], alpha float64, fname string) error {
+	img, err := Overlay(input, heatmap, alpha)
+	if err != nil {
+		return err
+	}
+	return SavePNG(img, fname)
+}
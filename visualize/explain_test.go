@@ -0,0 +1,66 @@
+package visualize
+
+import (
+	"math/rand"
+	"path/filepath"
+	"testing"
+
+	"github.com/LdDl/cnns"
+	"github.com/LdDl/cnns/tensor"
+)
+
+func TestGradCAM(t *testing.T) {
+	rand.Seed(5)
+	conv := cnns.NewConvLayer(1, 3, 4, tensor.TDsize{X: 10, Y: 10, Z: 1})
+	relu := cnns.NewReLULayer(conv.GetOutputSize())
+	pool := cnns.NewMaxPoolingLayer(2, 2, relu.GetOutputSize())
+	fc := cnns.NewFullyConnectedLayer(pool.GetOutputSize(), 2)
+	net := cnns.WholeNet{Layers: []cnns.Layer{conv, relu, pool, fc}}
+
+	input := tensor.NewTensor(10, 10, 1)
+	for i := range input.Data {
+		input.Data[i] = rand.Float64()
+	}
+	saliency, err := Saliency(&net, input, 0)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	cam, err := GradCAM(&net, input, 0, 0)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	if saliency.Size.X != 10 || saliency.Size.Y != 10 || saliency.Size.Z != 1 {
+		t.Errorf("Saliency map should be 10x10x1, but got %dx%dx%d", saliency.Size.X, saliency.Size.Y, saliency.Size.Z)
+	}
+	if cam.Size.X != 8 || cam.Size.Y != 8 || cam.Size.Z != 1 {
+		t.Errorf("Grad-CAM heatmap should be 8x8x1, but got %dx%dx%d", cam.Size.X, cam.Size.Y, cam.Size.Z)
+	}
+	for _, heatmap := range []*tensor.Tensor{saliency, cam} {
+		for _, v := range heatmap.Data {
+			if v < 0 || v > 1 {
+				t.Errorf("Heatmap values should be in [0, 1], but got %f", v)
+				break
+			}
+		}
+	}
+	if _, err = GradCAM(&net, input, 0, 1); err == nil {
+		t.Errorf("Grad-CAM for non-convolutional layer should produce error")
+	}
+	if _, err = Saliency(&net, input, 2); err == nil {
+		t.Errorf("Class out of range should produce error")
+	}
+
+	img, err := Overlay(input, cam, 0.5)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	if img.Bounds().Dx() != 10 || img.Bounds().Dy() != 10 {
+		t.Errorf("Overlay should have size of input, but got %v", img.Bounds())
+	}
+	if err = SaveOverlay(input, saliency, 0.5, filepath.Join(t.TempDir(), "saliency.png")); err != nil {
+		t.Error(err)
+	}
+}
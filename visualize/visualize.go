@@ -0,0 +1,286 @@
+// Package visualize renders kernels of convolutional layers and outputs (feature maps) of layers as grids of grayscale tiles saved to PNG.
+package visualize
+
+import (
+	"errors"
+	"fmt"
+	"image"
+	"image/color"
+	"image/draw"
+	"image/png"
+	"math"
+	"os"
+	"path/filepath"
+
+	"github.com/LdDl/cnns"
+	"github.com/LdDl/cnns/tensor"
+	"github.com/LdDl/cnns/utils/im"
+	"golang.org/x/image/font"
+	"golang.org/x/image/font/basicfont"
+	"golang.org/x/image/math/fixed"
+)
+
+var (
+	// Background - color of grid's background
+	Background = color.Gray{Y: 48}
+	// TextColor - color of labels and title
+	TextColor = color.White
+)
+
+// Tile - grayscale image with label
+type Tile struct {
+	Image *image.Gray
+	Label string
+}
+
+// GridConfig - layout of grid
+/*
+	Columns - number of columns (square root of number of tiles if not set);
+	Scale - magnification of tiles (nearest neighbour). If not set, tiles are magnified so their larger side is at least 48 points;
+	Padding - space between tiles (4 if not set);
+	Title - text above grid;
+	SharedScale - normalize all tiles of tensor with the same minimum and maximum (every tile is normalized separately otherwise).
+*/
+type GridConfig struct {
+	Columns     int
+	Scale       int
+	Padding     int
+	Title       string
+	SharedScale bool
+}
+
+// TensorTiles - converts every channel of tensor to tile labeled "<prefix><channel>". Values are scaled so minimum is black and maximum is white (see GridConfig.SharedScale)
+func TensorTiles[T tensor.Float](t *tensor.TensorOf[T], prefix string, sharedScale bool) ([]Tile, error) {
+	data := tensor.Convert[float64](t)
+	if sharedScale {
+		min, max := math.Inf(1), math.Inf(-1)
+		for _, v := range data.Data {
+			min = math.Min(min, v)
+			max = math.Max(max, v)
+		}
+		for i, v := range data.Data {
+			if max > min {
+				data.Data[i] = (v - min) / (max - min)
+			} else {
+				data.Data[i] = 0
+			}
+		}
+	}
+	plane := data.Size.X * data.Size.Y
+	ret := make([]Tile, data.Size.Z)
+	for z := range ret {
+		var gray *image.Gray
+		var err error
+		if sharedScale {
+			channel := tensor.NewTensor(data.Size.X, data.Size.Y, 1)
+			copy(channel.Data, data.Data[z*plane:(z+1)*plane])
+			var img image.Image
+			img, err = im.FromTensor(channel)
+			if err == nil {
+				gray = img.(*image.Gray)
+			}
+		} else {
+			gray, err = im.ChannelToGray(data, z)
+		}
+		if err != nil {
+			return nil, err
+		}
+		ret[z] = Tile{Image: gray, Label: fmt.Sprintf("%s%d", prefix, z)}
+	}
+	return ret, nil
+}
+
+// KernelTiles - converts kernels of convolutional layer to tiles. Every channel of kernel is separate tile labeled "k<kernel>" (or "k<kernel>c<channel>" for multichannel kernels)
+func KernelTiles[T tensor.Float](layer cnns.LayerOf[T], sharedScale bool) ([]Tile, error) {
+	if layer.GetType() != "conv" {
+		return nil, fmt.Errorf("Layer of type '%s' has no kernels", layer.GetType())
+	}
+	kernels := layer.GetWeights()
+	if len(kernels) == 0 {
+		return nil, errors.New("layer has no kernels")
+	}
+	all := tensor.NewTensorOf[T](kernels[0].Size.X, kernels[0].Size.Y, len(kernels)*kernels[0].Size.Z)
+	labels := make([]string, 0, all.Size.Z)
+	offset := 0
+	for k := range kernels {
+		offset += copy(all.Data[offset:], kernels[k].Data)
+		for c := 0; c < kernels[k].Size.Z; c++ {
+			if kernels[k].Size.Z == 1 {
+				labels = append(labels, fmt.Sprintf("k%d", k))
+			} else {
+				labels = append(labels, fmt.Sprintf("k%dc%d", k, c))
+			}
+		}
+	}
+	ret, err := TensorTiles(all, "", sharedScale)
+	if err != nil {
+		return nil, err
+	}
+	for i := range ret {
+		ret[i].Label = labels[i]
+	}
+	return ret, nil
+}
+
+// OutputTiles - converts every channel of layer's output (feature maps) to tile labeled "ch<channel>"
+func OutputTiles[T tensor.Float](layer cnns.LayerOf[T], sharedScale bool) ([]Tile, error) {
+	return TensorTiles(layer.GetOutput(), "ch", sharedScale)
+}
+
+// Grid - assembles tiles into labeled grid
+func Grid(tiles []Tile, cfg GridConfig) *image.RGBA {
+	if len(tiles) == 0 {
+		return image.NewRGBA(image.Rect(0, 0, 1, 1))
+	}
+	face := basicfont.Face7x13
+	lineHeight := face.Metrics().Height.Ceil()
+	columns := cfg.Columns
+	if columns < 1 {
+		columns = int(math.Ceil(math.Sqrt(float64(len(tiles)))))
+	}
+	rows := (len(tiles) + columns - 1) / columns
+	padding := cfg.Padding
+	if padding < 1 {
+		padding = 4
+	}
+	scale := cfg.Scale
+	tileWidth, tileHeight := 0, 0
+	for _, t := range tiles {
+		tileWidth = maxInt(tileWidth, t.Image.Bounds().Dx())
+		tileHeight = maxInt(tileHeight, t.Image.Bounds().Dy())
+	}
+	if scale < 1 {
+		scale = maxInt(1, int(math.Ceil(48.0/float64(maxInt(tileWidth, tileHeight)))))
+	}
+	cellWidth := tileWidth * scale
+	for _, t := range tiles {
+		cellWidth = maxInt(cellWidth, font.MeasureString(face, t.Label).Ceil())
+	}
+	cellHeight := tileHeight*scale + lineHeight
+	top := padding
+	if cfg.Title != "" {
+		top += lineHeight + padding
+	}
+	width := maxInt(columns*(cellWidth+padding)+padding, font.MeasureString(face, cfg.Title).Ceil()+2*padding)
+	height := top + rows*(cellHeight+padding)
+	ret := image.NewRGBA(image.Rect(0, 0, width, height))
+	draw.Draw(ret, ret.Bounds(), image.NewUniform(Background), image.Point{}, draw.Src)
+
+	drawer := &font.Drawer{Dst: ret, Src: image.NewUniform(TextColor), Face: face}
+	if cfg.Title != "" {
+		drawer.Dot = fixed.P(padding, padding+face.Metrics().Ascent.Ceil())
+		drawer.DrawString(cfg.Title)
+	}
+	for i, t := range tiles {
+		x0 := padding + (i%columns)*(cellWidth+padding)
+		y0 := top + (i/columns)*(cellHeight+padding)
+		bounds := t.Image.Bounds()
+		for y := 0; y < bounds.Dy()*scale; y++ {
+			for x := 0; x < bounds.Dx()*scale; x++ {
+				ret.Set(x0+x, y0+y, t.Image.GrayAt(bounds.Min.X+x/scale, bounds.Min.Y+y/scale))
+			}
+		}
+		drawer.Dot = fixed.P(x0, y0+tileHeight*scale+face.Metrics().Ascent.Ceil())
+		drawer.DrawString(t.Label)
+	}
+	return ret
+}
+
+// SavePNG - writes image to PNG file
+func SavePNG(img image.Image, fname string) error {
+	f, err := os.Create(fname)
+	if err != nil {
+		return err
+	}
+	err = png.Encode(f, img)
+	if err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+// SaveKernels - renders kernels of convolutional layer to PNG file
+func SaveKernels[T tensor.Float](layer cnns.LayerOf[T], fname string, cfg GridConfig) error {
+	tiles, err := KernelTiles(layer, cfg.SharedScale)
+	if err != nil {
+		return err
+	}
+	return SavePNG(Grid(tiles, cfg), fname)
+}
+
+// SaveFeatureMaps - renders output of layer to PNG file (net should be fed with input before)
+func SaveFeatureMaps[T tensor.Float](layer cnns.LayerOf[T], fname string, cfg GridConfig) error {
+	tiles, err := OutputTiles(layer, cfg.SharedScale)
+	if err != nil {
+		return err
+	}
+	return SavePNG(Grid(tiles, cfg), fname)
+}
+
+// DumpLayers - feeds input to the net and renders input, output of every layer and kernels of convolutional layers to directory. Returns names of written files.
+/*
+	Files are named "input.png", "layer_<index>_<type>_output.png" and "layer_<index>_<type>_kernels.png".
+	Title of every grid is set to name of layer (cfg.Title is ignored).
+*/
+func DumpLayers[T tensor.Float](net *cnns.WholeNetOf[T], input *tensor.TensorOf[T], dir string, cfg GridConfig) ([]string, error) {
+	if len(net.Layers) == 0 {
+		return nil, errors.New("network has no layers")
+	}
+	err := os.MkdirAll(dir, 0755)
+	if err != nil {
+		return nil, err
+	}
+	net.FeedForward(input)
+
+	var files []string
+	save := func(name string, tiles []Tile, title string) error {
+		fname := filepath.Join(dir, name)
+		gridCfg := cfg
+		gridCfg.Title = title
+		err := SavePNG(Grid(tiles, gridCfg), fname)
+		if err != nil {
+			return err
+		}
+		files = append(files, fname)
+		return nil
+	}
+	tiles, err := TensorTiles(input, "ch", cfg.SharedScale)
+	if err != nil {
+		return nil, err
+	}
+	err = save("input.png", tiles, fmt.Sprintf("input %dx%dx%d", input.Size.X, input.Size.Y, input.Size.Z))
+	if err != nil {
+		return nil, err
+	}
+	for i, layer := range net.Layers {
+		name := fmt.Sprintf("layer_%02d_%s", i, layer.GetType())
+		if layer.GetType() == "conv" {
+			tiles, err = KernelTiles(layer, cfg.SharedScale)
+			if err != nil {
+				return nil, err
+			}
+			err = save(name+"_kernels.png", tiles, fmt.Sprintf("#%d %s kernels", i, layer.GetType()))
+			if err != nil {
+				return nil, err
+			}
+		}
+		tiles, err = OutputTiles(layer, cfg.SharedScale)
+		if err != nil {
+			return nil, err
+		}
+		out := layer.GetOutputSize()
+		err = save(name+"_output.png", tiles, fmt.Sprintf("#%d %s output %dx%dx%d", i, layer.GetType(), out.X, out.Y, out.Z))
+		if err != nil {
+			return nil, err
+		}
+	}
+	return files, nil
+}
+
+func maxInt(a, b int) int {
+	if a > b {
+		return a
+	}
+	return b
+}
@@ -0,0 +1,68 @@
+package visualize
+
+import (
+	"image/png"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/LdDl/cnns"
+	"github.com/LdDl/cnns/tensor"
+)
+
+func TestDumpLayers(t *testing.T) {
+	conv := cnns.NewConvLayer(1, 3, 4, tensor.TDsize{X: 8, Y: 8, Z: 2})
+	relu := cnns.NewReLULayer(conv.GetOutputSize())
+	pool := cnns.NewMaxPoolingLayer(2, 2, relu.GetOutputSize())
+	fc := cnns.NewFullyConnectedLayer(pool.GetOutputSize(), 3)
+	net := cnns.WholeNet{Layers: []cnns.Layer{conv, relu, pool, fc}}
+
+	input := tensor.NewTensor(8, 8, 2)
+	for i := range input.Data {
+		input.Data[i] = float64(i%7) / 7.0
+	}
+	dir := filepath.Join(t.TempDir(), "layers")
+	files, err := DumpLayers(&net, input, dir, GridConfig{Scale: 4})
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	expected := []string{"input.png", "layer_00_conv_kernels.png", "layer_00_conv_output.png", "layer_01_relu_output.png", "layer_02_pool_output.png", "layer_03_fc_output.png"}
+	if len(files) != len(expected) {
+		t.Errorf("Number of files is wrong. Expected value: %d. Got: %d", len(expected), len(files))
+		return
+	}
+	for i := range expected {
+		if filepath.Base(files[i]) != expected[i] {
+			t.Errorf("File #%d should be %s, but got %s", i, expected[i], filepath.Base(files[i]))
+		}
+		f, err := os.Open(files[i])
+		if err != nil {
+			t.Error(err)
+			continue
+		}
+		_, err = png.Decode(f)
+		f.Close()
+		if err != nil {
+			t.Errorf("Can't decode %s: %s", files[i], err)
+		}
+	}
+
+	// 4 kernels with 2 channels each
+	tiles, err := KernelTiles(conv, false)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	if len(tiles) != 8 || tiles[3].Label != "k1c1" {
+		t.Errorf("Expected 8 tiles, 4th labeled k1c1. Got: %d tiles", len(tiles))
+	}
+	grid := Grid(tiles, GridConfig{Columns: 4, Scale: 10, Padding: 2})
+	// 4 columns of 30 points, 2 rows of 30 points plus label
+	if grid.Bounds().Dx() != 4*32+2 || grid.Bounds().Dy() != 2*(30+13+2)+2 {
+		t.Errorf("Grid size is wrong: %v", grid.Bounds())
+	}
+	if _, err = KernelTiles(relu, false); err == nil {
+		t.Errorf("Layer without kernels should produce error")
+	}
+}